@@ -0,0 +1,95 @@
+package spatial_hash
+
+// Violation is a set of checks a node's new position failed.
+type Violation int
+
+const (
+	// ViolationOutOfBounds means the position lies outside the world bounds set with SetWorldBounds.
+	ViolationOutOfBounds Violation = 1 << iota
+	// ViolationTooFast means the node moved further than the maximum step since its last update.
+	ViolationTooFast
+	// ViolationBlocked means the position lies in a cell marked with SetCellBlocked.
+	ViolationBlocked
+)
+
+// Corrector is called when the new position of n fails the checks in violations. It must move n
+// to the position the spatial hash should index instead, for example by clamping it into the
+// world or moving it back to its old position, which is still available from GetOldPos.
+type Corrector[Id comparable, N Number] func(n Node[Id, N], violations Violation)
+
+// authority validates positions on update, for SetCorrector.
+type authority[Id comparable, N Number] struct {
+	// maxStep is the maximum distance a node may move in one update, or zero if unlimited.
+	maxStep N
+	// maxStepSq is maxStep squared.
+	maxStepSq N
+
+	correct Corrector[Id, N]
+}
+
+// SetCorrector makes Update and UpdateBounded validate the new position of every node before
+// indexing it, and call correct when it lies outside the world bounds, in a blocked cell, or
+// further than maxStep from its old position. A maxStep of zero or less does not limit steps.
+// This makes the spatial hash the choke point for server-authoritative movement validation.
+// The position correct leaves the node at is indexed without being checked again.
+// A nil correct disables validation.
+//
+// correct must not call methods of the spatial hash.
+// SetCorrector must be called before the spatial hash is used.
+func (sh *SpatialHash[Id, N]) SetCorrector(maxStep N, correct Corrector[Id, N]) {
+	if correct == nil {
+		sh.authority = nil
+
+		return
+	}
+
+	a := &authority[Id, N]{correct: correct}
+
+	if maxStep > 0 {
+		a.maxStep = maxStep
+		a.maxStepSq = maxStep * maxStep
+	}
+
+	sh.authority = a
+}
+
+// Validate returns the checks the current position of n fails, against its old position.
+// It returns no violations if SetCorrector has not been called.
+func (sh *SpatialHash[Id, N]) Validate(n Node[Id, N]) Violation {
+	if sh.authority == nil {
+		return 0
+	}
+
+	x, y := sh.Quantize(n.GetX()), sh.Quantize(n.GetY())
+
+	var violations Violation
+
+	if sh.world != nil && !sh.world.Contains(x, y) {
+		violations |= ViolationOutOfBounds
+	}
+
+	if sh.authority.maxStepSq > 0 {
+		oldX, oldY := n.GetOldPos()
+
+		if distanceSq(x-sh.Quantize(oldX), y-sh.Quantize(oldY)) > sh.authority.maxStepSq {
+			violations |= ViolationTooFast
+		}
+	}
+
+	if sh.IsCellBlocked(sh.cellCoord(x), sh.cellCoord(y)) {
+		violations |= ViolationBlocked
+	}
+
+	return violations
+}
+
+// correct calls the corrector if the new position of n fails validation.
+func (sh *SpatialHash[Id, N]) correct(n Node[Id, N]) {
+	if sh.authority == nil {
+		return
+	}
+
+	if violations := sh.Validate(n); violations != 0 {
+		sh.authority.correct(n, violations)
+	}
+}
@@ -0,0 +1,75 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashCorrector(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetWorldBounds(Rect[float64]{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100})
+	sh.SetCellBlocked(5, 5, true)
+
+	var got Violation
+
+	sh.SetCorrector(20, func(n Node[int, float64], violations Violation) {
+		got = violations
+
+		p := n.(*Point)
+
+		if violations&ViolationOutOfBounds != 0 {
+			p.x, p.y = min(max(p.x, 0), 100), min(max(p.y, 0), 100)
+		} else {
+			p.x, p.y = p.GetOldPos()
+		}
+	})
+
+	n := newPoint(0, 90, 90)
+	sh.Put(n)
+
+	testCases := []struct {
+		name string
+
+		x, y float64
+
+		violations Violation
+		wantX      float64
+		wantY      float64
+	}{
+		{"valid", 95, 92, 0, 95, 92},
+		{"out of bounds", 110, 95, ViolationOutOfBounds, 100, 95},
+		{"too fast", 60, 95, ViolationTooFast, 100, 95},
+		{"blocked", 55, 55, ViolationTooFast | ViolationBlocked, 100, 95},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got = 0
+
+			n.x, n.y = tc.x, tc.y
+			sh.Update(n)
+
+			if got != tc.violations {
+				t.Errorf("Expected violations %b, got %b", tc.violations, got)
+			}
+
+			if n.x != tc.wantX || n.y != tc.wantY {
+				t.Errorf("Expected the node at (%v, %v), got (%v, %v)", tc.wantX, tc.wantY, n.x, n.y)
+			}
+
+			if len(sh.AtPosition(tc.wantX, tc.wantY)) != 1 {
+				t.Error("Expected the corrected position to be indexed")
+			}
+		})
+	}
+
+	// A blocked cell reached with a valid step
+	for _, step := range [][2]float64{{86, 82}, {72, 70}, {60, 60}} {
+		n.x, n.y = step[0], step[1]
+		sh.Update(n)
+	}
+
+	n.x, n.y = 55, 58
+	sh.Update(n)
+
+	if got != ViolationBlocked || n.x != 60 || n.y != 60 {
+		t.Errorf("Expected a blocked move to be reverted, got violations %b and (%v, %v)", got, n.x, n.y)
+	}
+}
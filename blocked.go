@@ -0,0 +1,26 @@
+package spatial_hash
+
+// cell identifies a cell by its coordinates.
+type cell struct{ x, y int }
+
+// SetCellBlocked marks cell (cx, cy) as blocked or unblocked.
+// Blocked cells are static obstacles, such as walls, that grid algorithms treat as impassable.
+func (sh *SpatialHash[Id, N]) SetCellBlocked(cx, cy int, blocked bool) {
+	if blocked {
+		sh.blocked.Store(cell{cx, cy}, struct{}{})
+	} else {
+		sh.blocked.Delete(cell{cx, cy})
+	}
+}
+
+// IsCellBlocked reports whether cell (cx, cy) is blocked.
+func (sh *SpatialHash[Id, N]) IsCellBlocked(cx, cy int) bool {
+	_, blocked := sh.blocked.Load(cell{cx, cy})
+
+	return blocked
+}
+
+// ClearBlockedCells unblocks every cell.
+func (sh *SpatialHash[Id, N]) ClearBlockedCells() {
+	sh.blocked.Clear()
+}
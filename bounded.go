@@ -0,0 +1,286 @@
+package spatial_hash
+
+// BoundedNode is a node with an axis-aligned bounding box centered on its position.
+type BoundedNode[Id comparable, N Number] interface {
+	Node[Id, N]
+
+	// GetHalfWidth returns half the width of the node's bounding box.
+	GetHalfWidth() N
+	// GetHalfHeight returns half the height of the node's bounding box.
+	GetHalfHeight() N
+}
+
+// boundedState is what the spatial hash remembers about a bounded node between updates.
+type boundedState struct {
+	// span is the range of cells the node is stored in.
+	span CellRect
+
+	// homeX, homeY is the cell containing the node's center.
+	homeX, homeY int
+}
+
+// boundedStateOf returns the cells covered by the bounding box of n.
+func (sh *SpatialHash[Id, N]) boundedStateOf(n BoundedNode[Id, N]) boundedState {
+	x, y := sh.Quantize(n.GetX()), sh.Quantize(n.GetY())
+	halfWidth, halfHeight := max(n.GetHalfWidth(), 0), max(n.GetHalfHeight(), 0)
+
+	return boundedState{
+		span: CellRect{
+			MinX: sh.cellCoord(x - halfWidth),
+			MinY: sh.cellCoord(y - halfHeight),
+			MaxX: sh.cellCoord(x + halfWidth),
+			MaxY: sh.cellCoord(y + halfHeight),
+		},
+
+		homeX: sh.cellCoord(x),
+		homeY: sh.cellCoord(y),
+	}
+}
+
+// loadOrCreateBucket returns the bucket of cell c, creating it if needed.
+func (sh *SpatialHash[Id, N]) loadOrCreateBucket(c cell) *bucket[Id, N] {
+	b, _ := sh.buckets.LoadOrCompute(c, func() (*bucket[Id, N], bool) {
+		return newBucket[Id, N](), false
+	})
+
+	return b
+}
+
+// PutBounded adds a node to every bucket its bounding box overlaps, so that Search and
+// QueryRect find it whenever its box intersects the query region, not only its center.
+//
+// Other queries treat bounded nodes as points at their center. Bounded nodes must be
+// moved with UpdateBounded and removed with RemoveBounded or RemoveWhere.
+func (sh *SpatialHash[Id, N]) PutBounded(n BoundedNode[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.checkId(n)
+
+	sh.hooked(OperationPut, n, func() {
+		sh.moveBounded(n)
+
+		if sh.tracker != nil {
+			sh.tracker.Add(n)
+		}
+
+		if sh.maxSpeed != nil {
+			sh.trackSpeed(n)
+		}
+	})
+}
+
+// UpdateBounded updates a bounded node's position and extents in the spatial hash.
+// Only the buckets entering or leaving its bounding box are touched, so a node that
+// moves, grows or shrinks within the same cells costs nothing.
+func (sh *SpatialHash[Id, N]) UpdateBounded(n BoundedNode[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.updateBounded(n)
+}
+
+// updateBounded moves n to the buckets its bounding box now overlaps.
+func (sh *SpatialHash[Id, N]) updateBounded(n BoundedNode[Id, N]) {
+	sh.hooked(OperationUpdate, n, func() { sh.applyUpdateBounded(n) })
+}
+
+// applyUpdateBounded does the work of updateBounded, inside its hooks.
+func (sh *SpatialHash[Id, N]) applyUpdateBounded(n BoundedNode[Id, N]) {
+	sh.correct(n)
+
+	sh.moveBounded(n)
+
+	if sh.sleep != nil {
+		sh.sleep.idle.Delete(n.GetId())
+	}
+
+	if sh.interp != nil {
+		sh.recordStart(n)
+	}
+
+	if sh.maxSpeed != nil {
+		sh.trackSpeed(n)
+	}
+
+	// Set old position for next update
+	n.SetOldPos(n.GetX(), n.GetY())
+}
+
+// moveBounded stores n in the buckets its bounding box overlaps, diffing them against
+// the buckets it was previously stored in.
+func (sh *SpatialHash[Id, N]) moveBounded(n BoundedNode[Id, N]) {
+	id := n.GetId()
+
+	state := sh.boundedStateOf(n)
+
+	old, moved := sh.bounded.LoadAndStore(id, state)
+
+	if moved {
+		for cy := old.span.MinY; cy <= old.span.MaxY; cy++ {
+			for cx := old.span.MinX; cx <= old.span.MaxX; cx++ {
+				if state.span.Contains(cx, cy) {
+					continue
+				}
+
+				if b, ok := sh.cellBucket(cx, cy); ok {
+					b.Delete(n)
+				}
+
+				sh.cellEvent(CellRemoved, cell{cx, cy}, n)
+			}
+		}
+	}
+
+	for cy := state.span.MinY; cy <= state.span.MaxY; cy++ {
+		for cx := state.span.MinX; cx <= state.span.MaxX; cx++ {
+			home := cx == state.homeX && cy == state.homeY
+			wasHome := moved && cx == old.homeX && cy == old.homeY
+
+			if moved && old.span.Contains(cx, cy) && home == wasHome {
+				// The node may have moved within the cell
+				if b, ok := sh.cellBucket(cx, cy); ok {
+					b.touch()
+				}
+
+				continue
+			}
+
+			sh.loadOrCreateBucket(cell{cx, cy}).addSpanning(n, home)
+
+			// A node whose center moved between cells it already spanned is only re-added
+			if !moved || !old.span.Contains(cx, cy) {
+				sh.cellEvent(CellAdded, cell{cx, cy}, n)
+			}
+		}
+	}
+
+	if sh.flow != nil {
+		home := cell{state.homeX, state.homeY}
+
+		if !moved {
+			sh.flow.count(home, 1, 0)
+		} else if from := (cell{old.homeX, old.homeY}); from != home {
+			sh.flow.move(from, home)
+		}
+	}
+
+	if sh.exact != nil {
+		sh.exact.Move(n, sh.exactPosition(n))
+	}
+}
+
+// RemoveBounded removes a bounded node from every bucket it was stored in.
+func (sh *SpatialHash[Id, N]) RemoveBounded(n BoundedNode[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.removeBounded(n)
+}
+
+// removeBounded removes n from the buckets it was stored in, reporting whether it was stored.
+func (sh *SpatialHash[Id, N]) removeBounded(n Node[Id, N]) bool {
+	if _, ok := sh.bounded.Load(n.GetId()); !ok {
+		return false
+	}
+
+	sh.deleteBounded(n)
+
+	return true
+}
+
+// deleteBounded removes the bounded node n from the buckets it was stored in, reporting
+// whether it did, rather than a concurrent remove.
+func (sh *SpatialHash[Id, N]) deleteBounded(n Node[Id, N]) (deleted bool) {
+	sh.hooked(OperationRemove, n, func() {
+		state, ok := sh.bounded.LoadAndDelete(n.GetId())
+		if !ok {
+			return
+		}
+
+		deleted = true
+
+		for cy := state.span.MinY; cy <= state.span.MaxY; cy++ {
+			for cx := state.span.MinX; cx <= state.span.MaxX; cx++ {
+				if b, ok := sh.cellBucket(cx, cy); ok {
+					b.Delete(n)
+				}
+
+				sh.cellEvent(CellRemoved, cell{cx, cy}, n)
+			}
+		}
+
+		if sh.flow != nil {
+			sh.flow.count(cell{state.homeX, state.homeY}, 0, 1)
+		}
+
+		sh.forget(n.GetId())
+	})
+
+	return deleted
+}
+
+// spanSeen records the spanning nodes a query has already visited,
+// so that a node stored in several buckets is reported once.
+type spanSeen[Id comparable] map[Id]struct{}
+
+// first reports whether id is visited for the first time, recording it.
+func (s *spanSeen[Id]) first(id Id) bool {
+	if *s == nil {
+		*s = make(spanSeen[Id])
+	} else if _, ok := (*s)[id]; ok {
+		return false
+	}
+
+	(*s)[id] = struct{}{}
+
+	return true
+}
+
+// boxGap returns how far offset d lies beyond half, the half extent of a box, or zero if inside.
+func boxGap[N Number](d, half N) N {
+	if d < 0 {
+		d = -d
+	}
+
+	if d <= half {
+		return 0
+	}
+
+	return d - half
+}
+
+// boundsInCircle reports whether the bounding box of n intersects the circle of radius
+// centered on (x, y), with radiusSq the squared radius.
+func (sh *SpatialHash[Id, N]) boundsInCircle(n Node[Id, N], x, y, radiusSq N) bool {
+	b := n.(BoundedNode[Id, N])
+
+	dx := boxGap(sh.Quantize(b.GetX())-x, max(b.GetHalfWidth(), 0))
+	dy := boxGap(sh.Quantize(b.GetY())-y, max(b.GetHalfHeight(), 0))
+
+	return distanceSq(dx, dy) <= radiusSq
+}
+
+// boundsInRect reports whether the bounding box of n intersects the rectangle of the given
+// half extents centered on (x, y).
+func (sh *SpatialHash[Id, N]) boundsInRect(n Node[Id, N], x, y, halfWidth, halfHeight N) bool {
+	b := n.(BoundedNode[Id, N])
+
+	return boxGap(sh.Quantize(b.GetX())-x, max(b.GetHalfWidth(), 0)) <= halfWidth &&
+		boxGap(sh.Quantize(b.GetY())-y, max(b.GetHalfHeight(), 0)) <= halfHeight
+}
+
+// nodeDistanceSq returns the squared distance from (x, y) to n, measured to the bounding box
+// of nodes put with PutBounded and to the position of other nodes.
+func (sh *SpatialHash[Id, N]) nodeDistanceSq(n Node[Id, N], x, y N) N {
+	if b, ok := n.(BoundedNode[Id, N]); ok {
+		if _, bounded := sh.bounded.Load(n.GetId()); bounded {
+			dx := boxGap(sh.Quantize(b.GetX())-x, max(b.GetHalfWidth(), 0))
+			dy := boxGap(sh.Quantize(b.GetY())-y, max(b.GetHalfHeight(), 0))
+
+			return distanceSq(dx, dy)
+		}
+	}
+
+	return distanceSq(sh.Quantize(n.GetX())-x, sh.Quantize(n.GetY())-y)
+}
@@ -0,0 +1,238 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// BoxPoint is a Point with a bounding box, for testing BoundedNode.
+type BoxPoint struct {
+	Point
+
+	halfWidth, halfHeight float64
+}
+
+var _ BoundedNode[int, float64] = (*BoxPoint)(nil) // *BoxPoint must implement BoundedNode
+
+func (n *BoxPoint) GetHalfWidth() float64  { return n.halfWidth }
+func (n *BoxPoint) GetHalfHeight() float64 { return n.halfHeight }
+
+func newBoxPoint(id int, x, y, halfWidth, halfHeight float64) *BoxPoint {
+	return &BoxPoint{Point: *newPoint(id, x, y), halfWidth: halfWidth, halfHeight: halfHeight}
+}
+
+// naiveBoxSearch returns the ids of the points within radius of (x, y) and the boxes intersecting that circle.
+func naiveBoxSearch(points []*Point, boxes []*BoxPoint, x, y, radius float64) map[int]bool {
+	ids := make(map[int]bool)
+
+	for _, p := range NaiveSearch(points, x, y, radius) {
+		ids[p.id] = true
+	}
+
+	for _, b := range boxes {
+		dx := math.Max(math.Abs(b.x-x)-b.halfWidth, 0)
+		dy := math.Max(math.Abs(b.y-y)-b.halfHeight, 0)
+
+		if dx*dx+dy*dy <= radius*radius {
+			ids[b.id] = true
+		}
+	}
+
+	return ids
+}
+
+// naiveBoxRect returns the ids of the boxes intersecting the rectangle centered on (x, y).
+func naiveBoxRect(boxes []*BoxPoint, x, y, width, height float64) map[int]bool {
+	ids := make(map[int]bool)
+
+	for _, b := range boxes {
+		if math.Abs(b.x-x) <= b.halfWidth+width/2 && math.Abs(b.y-y) <= b.halfHeight+height/2 {
+			ids[b.id] = true
+		}
+	}
+
+	return ids
+}
+
+// checkBoxSearch compares Search against naiveBoxSearch, failing on missing, extra or duplicate nodes.
+func checkBoxSearch(t *testing.T, sh *SpatialHash[int, float64], points []*Point, boxes []*BoxPoint, x, y, radius float64) {
+	t.Helper()
+
+	expected := naiveBoxSearch(points, boxes, x, y, radius)
+
+	found := sh.Search(x, y, radius)
+
+	seen := make(map[int]bool)
+
+	for _, n := range found {
+		if seen[n.GetId()] {
+			t.Fatalf("Search(%v, %v, %v) returned node %d twice", x, y, radius, n.GetId())
+		}
+
+		seen[n.GetId()] = true
+
+		if !expected[n.GetId()] {
+			t.Fatalf("Search(%v, %v, %v) returned node %d outside the radius", x, y, radius, n.GetId())
+		}
+	}
+
+	if len(found) != len(expected) {
+		t.Fatalf("Search(%v, %v, %v): expected %d nodes, got %d", x, y, radius, len(expected), len(found))
+	}
+}
+
+func TestSpatialHashBoundedLarge(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	points := CreateTestNodes(500, 1000, 1000)
+
+	for _, p := range points {
+		sh.Put(p)
+	}
+
+	// Boxes several times larger than a cell
+	boxes := make([]*BoxPoint, 200)
+
+	for i := range boxes {
+		boxes[i] = newBoxPoint(len(points)+i, 1000*rand.Float64(), 1000*rand.Float64(), 5+50*rand.Float64(), 5+50*rand.Float64())
+
+		sh.PutBounded(boxes[i])
+	}
+
+	for range 500 {
+		x, y := 1000*rand.Float64(), 1000*rand.Float64()
+
+		checkBoxSearch(t, sh, points, boxes, x, y, 1+40*rand.Float64())
+
+		width, height := 80*rand.Float64(), 80*rand.Float64()
+
+		expected := naiveBoxRect(boxes, x, y, width, height)
+
+		count := 0
+
+		for _, n := range sh.QueryRect(x, y, width, height) {
+			if _, ok := n.(*BoxPoint); !ok {
+				continue
+			}
+
+			if !expected[n.GetId()] {
+				t.Fatalf("QueryRect returned box %d outside the area", n.GetId())
+			}
+
+			count++
+		}
+
+		if count != len(expected) {
+			t.Fatalf("QueryRect: expected %d boxes, got %d", len(expected), count)
+		}
+	}
+
+	// Move, grow and shrink every box
+	for _, b := range boxes {
+		b.x, b.y = 1000*rand.Float64(), 1000*rand.Float64()
+		b.halfWidth, b.halfHeight = 60*rand.Float64(), 2*rand.Float64()
+
+		sh.UpdateBounded(b)
+	}
+
+	for range 500 {
+		checkBoxSearch(t, sh, points, boxes, 1000*rand.Float64(), 1000*rand.Float64(), 1+40*rand.Float64())
+	}
+
+	for _, b := range boxes[:100] {
+		sh.RemoveBounded(b)
+	}
+
+	for range 500 {
+		checkBoxSearch(t, sh, points, boxes[100:], 1000*rand.Float64(), 1000*rand.Float64(), 1+40*rand.Float64())
+	}
+
+	removed := sh.RemoveWhere(func(n TestingNode) bool {
+		_, ok := n.(*BoxPoint)
+
+		return ok
+	})
+
+	if removed != 100 {
+		t.Errorf("Expected RemoveWhere to remove 100 boxes, got %d", removed)
+	}
+
+	for range 100 {
+		checkBoxSearch(t, sh, points, nil, 1000*rand.Float64(), 1000*rand.Float64(), 1+40*rand.Float64())
+	}
+}
+
+func TestSpatialHashBoundedOnCellBoundaries(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	var boxes []*BoxPoint
+
+	// Boxes whose edges lie exactly on cell boundaries, centered on boundaries and cell centers
+	for i := range 10 {
+		for j := range 10 {
+			x, y := float64(i*10), float64(j*10)
+
+			if (i+j)%2 == 0 {
+				x, y = x+5, y+5
+			}
+
+			b := newBoxPoint(len(boxes), x, y, 5*float64(i%3), 5*float64(j%3))
+
+			boxes = append(boxes, b)
+
+			sh.PutBounded(b)
+		}
+	}
+
+	for i := range 21 {
+		for j := range 21 {
+			x, y := float64(i*5), float64(j*5)
+
+			checkBoxSearch(t, sh, nil, boxes, x, y, 5)
+			checkBoxSearch(t, sh, nil, boxes, x, y, 10)
+			checkBoxSearch(t, sh, nil, boxes, x, y, 0.5)
+		}
+	}
+
+	// Edges touching the query circle from outside a cell boundary
+	b := boxes[0]
+
+	if len(sh.Search(b.x+b.halfWidth+3, b.y, 3)) == 0 {
+		t.Error("Expected a box touching the circle to be found")
+	}
+}
+
+func TestSpatialHashBoundedUpdateKeepsOthers(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	b := newBoxPoint(1, 5, 5, 30, 30)
+	sh.PutBounded(b)
+
+	// Shrink within the same center cell
+	b.halfWidth, b.halfHeight = 1, 1
+	sh.UpdateBounded(b)
+
+	if found := sh.Search(30, 30, 2); len(found) != 0 {
+		t.Errorf("Expected shrunk box to be gone from far cells, got %d nodes", len(found))
+	}
+
+	// Move the center into a cell the box already covered
+	b.halfWidth, b.halfHeight = 30, 30
+	sh.UpdateBounded(b)
+
+	b.x, b.y = 25, 25
+	sh.UpdateBounded(b)
+
+	if found := sh.AtPosition(25, 25); len(found) != 1 {
+		t.Errorf("Expected box at its new center, got %d nodes", len(found))
+	}
+
+	if found := sh.AtPosition(5, 5); len(found) != 0 {
+		t.Errorf("Expected box gone from its old center, got %d nodes", len(found))
+	}
+
+	if found := sh.Search(-4, -4, 1); len(found) != 1 {
+		t.Errorf("Expected box found through its bounds, got %d nodes", len(found))
+	}
+}
@@ -0,0 +1,40 @@
+package spatial_hash
+
+// SetWorldBounds declares that every node lies inside bounds. Queries then skip the cells
+// outside the bounds, which can never hold a node, instead of looking them up; searches
+// near the edge of the world touch up to 4 times fewer cells.
+//
+// Nodes put outside the bounds are still stored, but queries will not find them.
+// SetWorldBounds must be called before the spatial hash is used.
+func (sh *SpatialHash[Id, N]) SetWorldBounds(bounds Rect[N]) {
+	sh.world = &bounds
+	sh.worldCells = sh.CellRectOf(bounds)
+}
+
+// WorldBounds returns the bounds set with SetWorldBounds. ok is false if none were set.
+func (sh *SpatialHash[Id, N]) WorldBounds() (bounds Rect[N], ok bool) {
+	if sh.world == nil {
+		return Rect[N]{}, false
+	}
+
+	return *sh.world, true
+}
+
+// clampCellRange clamps a range of cells to the cells of the world bounds, if set.
+// The result is empty (min greater than max) if the range lies entirely outside.
+func (sh *SpatialHash[Id, N]) clampCellRange(minX, minY, maxX, maxY int) (int, int, int, int) {
+	if sh.world == nil {
+		return minX, minY, maxX, maxY
+	}
+
+	w := sh.worldCells
+
+	return max(minX, w.MinX), max(minY, w.MinY), min(maxX, w.MaxX), min(maxY, w.MaxY)
+}
+
+// clampCells clamps a range of cells to the cells of the world bounds, if set.
+func (sh *SpatialHash[Id, N]) clampCells(r CellRect) CellRect {
+	r.MinX, r.MinY, r.MaxX, r.MaxY = sh.clampCellRange(r.MinX, r.MinY, r.MaxX, r.MaxY)
+
+	return r
+}
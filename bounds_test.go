@@ -0,0 +1,43 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSpatialHashWorldBounds(t *testing.T) {
+	const size = 1000
+
+	nodes := CreateTestNodes(5000, size, size)
+
+	sh := NewSpatialHash[int, float64](50)
+
+	sh.SetWorldBounds(Rect[float64]{MinX: 0, MinY: 0, MaxX: size, MaxY: size})
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	// Searches hugging the border, reaching outside the world
+	for range 1000 {
+		x, y := size*rand.Float64(), 20*rand.Float64()
+		if rand.IntN(2) == 0 {
+			x, y = y, x
+		}
+
+		if got, want := len(sh.Search(x, y, 80)), len(NaiveSearch(nodes, x, y, 80)); got != want {
+			t.Fatalf("Search(%v, %v, 80): expected %d nodes, got %d", x, y, want, got)
+		}
+	}
+
+	// Cells outside the world are never looked up
+	sh.Put(newPoint(-1, -100, -100))
+
+	if result := sh.Search(-100, -100, 10); len(result) != 0 {
+		t.Errorf("Expected node outside the world to be skipped, got %d nodes", len(result))
+	}
+
+	if bounds, ok := sh.WorldBounds(); !ok || bounds.MaxX != size {
+		t.Errorf("Unexpected world bounds %+v", bounds)
+	}
+}
@@ -0,0 +1,143 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Priority ranks the queries sharing a Budget.
+type Priority int
+
+const (
+	// PriorityLow is for work that may starve, such as analytics scans.
+	PriorityLow Priority = iota
+	// PriorityNormal is for regular gameplay queries.
+	PriorityNormal
+	// PriorityCritical is for queries that must complete, such as hit detection.
+	// They are never denied cells, and use up the budget of lower priorities.
+	PriorityCritical
+
+	priorities = int(PriorityCritical) + 1
+)
+
+// Budget bounds how many cells the budgeted queries of a tick scan, arbitrating between their
+// priorities. Queries take a cell from the budget before scanning it, so a low-priority scan is
+// preempted between two cells as soon as higher priorities have used up what it may use, and
+// starves for the rest of the tick. Only cells holding nodes are counted.
+//
+// Budget is safe for concurrent use.
+type Budget struct {
+	limit int64
+
+	// ceilings holds, per priority, how much of the budget may be used before queries of that
+	// priority are denied, leaving the rest to higher priorities.
+	ceilings [priorities]int64
+
+	// reserved holds, per priority, the cells kept for queries of that priority or above.
+	reserved [priorities]int64
+
+	used atomic.Int64
+
+	preempted [priorities]atomic.Int64
+}
+
+// NewBudget creates a budget of cells scanned per tick. It panics if cells is negative.
+func NewBudget(cells int) *Budget {
+	if cells < 0 {
+		panic(fmt.Sprintf("spatial_hash: invalid budget %d", cells))
+	}
+
+	b := &Budget{limit: int64(cells)}
+
+	b.updateCeilings()
+
+	return b
+}
+
+// Reserve keeps cells of every tick's budget for queries of priority p or above, so that
+// lower priorities cannot use them even when idle. Reserving for PriorityCritical is only
+// useful to hold cells back from PriorityNormal, since critical queries are never denied.
+//
+// Reserve must be called before the budget is used.
+func (b *Budget) Reserve(p Priority, cells int) {
+	b.reserved[p] = int64(max(cells, 0))
+
+	b.updateCeilings()
+}
+
+// updateCeilings derives the ceiling of every priority from the reservations of higher ones.
+func (b *Budget) updateCeilings() {
+	held := int64(0)
+
+	for p := priorities - 1; p >= 0; p-- {
+		b.ceilings[p] = b.limit - held
+
+		held = max(held, b.reserved[p])
+	}
+}
+
+// Reset starts a new tick, restoring the whole budget.
+func (b *Budget) Reset() {
+	b.used.Store(0)
+
+	for i := range b.preempted {
+		b.preempted[i].Store(0)
+	}
+}
+
+// Used returns how many cells have been scanned since the last Reset. Critical queries may
+// have scanned more than the budget.
+func (b *Budget) Used() int {
+	return int(b.used.Load())
+}
+
+// Preempted returns how many queries of priority p were stopped for lack of budget since the
+// last Reset.
+func (b *Budget) Preempted(p Priority) int {
+	return int(b.preempted[p].Load())
+}
+
+// take takes one cell from the budget for a query of priority p, reporting whether it may scan it.
+func (b *Budget) take(p Priority) bool {
+	if p >= PriorityCritical {
+		b.used.Add(1)
+
+		return true
+	}
+
+	ceiling := b.ceilings[max(p, PriorityLow)]
+
+	for {
+		used := b.used.Load()
+		if used >= ceiling {
+			return false
+		}
+
+		if b.used.CompareAndSwap(used, used+1) {
+			return true
+		}
+	}
+}
+
+// QueryBudgeted calls fn for every node inside shape centered on (x, y), scanning cells only
+// as long as b grants them to priority p. It returns false if the query was preempted before
+// visiting every node, in which case fn has seen only some of them; the query can be run
+// again next tick. Iteration also stops early if fn returns false. Bounded nodes are treated
+// as points at their center.
+//
+// fn must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) QueryBudgeted(b *Budget, p Priority, shape Shape[N], x, y N, fn func(n Node[Id, N]) bool) bool {
+	preempted := false
+
+	sh.queryShape(shape, x, y, func() bool {
+		preempted = !b.take(p)
+
+		return !preempted
+	}, fn)
+
+	if preempted {
+		b.preempted[max(p, PriorityLow)].Add(1)
+	}
+
+	return !preempted
+}
@@ -0,0 +1,66 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashQueryBudgeted(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// One node per cell in a 10x10 block
+	for i := range 100 {
+		sh.Put(newPoint(i, float64(i%10)*10+5, float64(i/10)*10+5))
+	}
+
+	area := Box[float64]{Width: 98, Height: 98}
+
+	b := NewBudget(30)
+	b.Reserve(PriorityNormal, 10)
+
+	count := func(p Priority) (int, bool) {
+		found := 0
+
+		completed := sh.QueryBudgeted(b, p, area, 50, 50, func(n Node[int, float64]) bool {
+			found++
+
+			return true
+		})
+
+		return found, completed
+	}
+
+	// Low priority cannot use the cells reserved for normal ones
+	if found, completed := count(PriorityLow); found != 20 || completed {
+		t.Errorf("Expected the low priority scan preempted after 20 cells, got %d nodes (completed %v)", found, completed)
+	}
+
+	if found, completed := count(PriorityNormal); found != 10 || completed {
+		t.Errorf("Expected the normal scan to get the 10 reserved cells, got %d nodes (completed %v)", found, completed)
+	}
+
+	// Critical queries always complete, beyond the budget
+	if found, completed := count(PriorityCritical); found != 100 || !completed {
+		t.Errorf("Expected the critical scan to complete, got %d nodes (completed %v)", found, completed)
+	}
+
+	if b.Used() != 130 {
+		t.Errorf("Expected 130 cells used, got %d", b.Used())
+	}
+
+	if b.Preempted(PriorityLow) != 1 || b.Preempted(PriorityNormal) != 1 {
+		t.Errorf("Expected 1 preempted query per priority, got %d and %d", b.Preempted(PriorityLow), b.Preempted(PriorityNormal))
+	}
+
+	b.Reset()
+
+	// Critical work starves low priority work within the tick
+	count(PriorityCritical)
+
+	if found, completed := count(PriorityLow); found != 0 || completed {
+		t.Errorf("Expected the low priority scan to starve, got %d nodes (completed %v)", found, completed)
+	}
+
+	b.Reset()
+
+	if !sh.QueryBudgeted(b, PriorityLow, Circle[float64]{Radius: 4}, 55, 55, func(n Node[int, float64]) bool { return true }) {
+		t.Errorf("Expected a scan within the budget to complete")
+	}
+}
@@ -0,0 +1,103 @@
+package spatial_hash
+
+// Shape queries run in two tiers. The candidate tier walks the cells a shape may cover and
+// yields the nodes stored in them, each with whether its cell lies fully inside the shape. The
+// exact tier checks the remaining candidates against the shape. QueryRect stops at the
+// candidate tier, which is why it returns whole cells.
+
+// CandidatesFunc runs only the candidate tier of a query for shape centered on (x, y): it calls
+// fn for every node whose center is stored in a cell the shape may cover, a superset of the
+// nodes inside it. inside reports that the node's cell lies fully inside the shape, so the node
+// is known to be inside it; other candidates are left to a narrow-phase check of the caller's
+// own, such as one against the node's own extents or a geometry no Shape describes. Bounded
+// nodes are treated as points at their center. Iteration stops early if fn returns false.
+//
+// Other goroutines may put, update and remove nodes while fn runs, but fn itself
+// must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) CandidatesFunc(shape Shape[N], x, y N, fn func(n Node[Id, N], inside bool) bool) {
+	sh.candidates(shape, x, y, nil, fn)
+}
+
+// QueryShape returns the nodes inside shape centered on (x, y), running both tiers. Bounded
+// nodes are treated as points at their center.
+func (sh *SpatialHash[Id, N]) QueryShape(shape Shape[N], x, y N) NodeSlice[Id, N] {
+	var nodes NodeSlice[Id, N]
+
+	sh.queryShape(shape, x, y, nil, func(n Node[Id, N]) bool {
+		nodes = append(nodes, n)
+
+		return true
+	})
+
+	return nodes
+}
+
+// QueryShapeFunc calls fn for every node QueryShape would return, without building a slice.
+// Iteration stops early if fn returns false.
+//
+// Other goroutines may put, update and remove nodes while fn runs, but fn itself
+// must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) QueryShapeFunc(shape Shape[N], x, y N, fn func(n Node[Id, N]) bool) {
+	sh.queryShape(shape, x, y, nil, fn)
+}
+
+// candidates is the candidate tier: it calls fn for every node stored in a cell shape centered
+// on (x, y) may cover, with whether that cell lies fully inside the shape, stopping early if fn
+// returns false. If admit is not nil, it is called before scanning each cell holding nodes, and
+// the query stops if it returns false.
+func (sh *SpatialHash[Id, N]) candidates(shape Shape[N], x, y N, admit func() bool, fn func(n Node[Id, N], inside bool) bool) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	halfWidth, halfHeight := shape.HalfExtents()
+
+	minX, minY, maxX, maxY := sh.queryCells(x, y, halfWidth, halfHeight)
+
+	fx, fy := float64(x), float64(y)
+
+	completed := true
+
+	for yy := minY; yy <= maxY && completed; yy++ {
+		for xx := minX; xx <= maxX && completed; xx++ {
+			coverage := shape.Classify(sh.cellBounds(xx, yy, fx, fy))
+
+			if coverage == CoverageOutside {
+				continue
+			}
+
+			bucket, ok := sh.cellBucket(xx, yy)
+			if !ok {
+				continue
+			}
+
+			if admit != nil && !admit() {
+				return
+			}
+
+			inside := coverage == CoverageInside
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				completed = fn(n, inside)
+
+				return completed
+			})
+		}
+	}
+}
+
+// queryShape is the exact tier: it calls fn for every candidate inside shape centered on
+// (x, y), stopping early if fn returns false. Candidates in cells fully inside the shape are
+// reported without per-node checks. admit is passed on to the candidate tier.
+func (sh *SpatialHash[Id, N]) queryShape(shape Shape[N], x, y N, admit func() bool, fn func(n Node[Id, N]) bool) {
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	sh.candidates(shape, x, y, admit, func(n Node[Id, N], inside bool) bool {
+		if inside || shape.Contains(sh.Quantize(n.GetX())-x, sh.Quantize(n.GetY())-y) {
+			return fn(n)
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,89 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCandidatesFunc(t *testing.T) {
+	nodes := CreateTestNodes(3000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](25)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	circle := Circle[float64]{Radius: 90}
+
+	for range 200 {
+		x, y := 1000*rand.Float64(), 1000*rand.Float64()
+
+		want := make(map[int]bool)
+
+		for _, n := range NaiveSearch(nodes, x, y, 90) {
+			want[n.GetId()] = true
+		}
+
+		// A custom narrow phase over the candidates matches the exact tier
+		got, seen := make(map[int]bool), make(map[int]bool)
+
+		sh.CandidatesFunc(circle, x, y, func(n Node[int, float64], inside bool) bool {
+			contained := circle.Contains(n.GetX()-x, n.GetY()-y)
+
+			if inside && !contained {
+				t.Fatalf("Expected node %d reported inside to be inside the circle", n.GetId())
+			}
+
+			if seen[n.GetId()] {
+				t.Fatalf("Expected node %d once", n.GetId())
+			}
+
+			seen[n.GetId()], got[n.GetId()] = true, contained
+
+			return true
+		})
+
+		for id := range want {
+			if !got[id] {
+				t.Fatalf("Expected node %d among the candidates", id)
+			}
+		}
+
+		if exact := sh.QueryShape(circle, x, y); len(exact) != len(want) {
+			t.Fatalf("Expected %d nodes, got %d", len(want), len(exact))
+		}
+	}
+}
+
+func TestCandidatesFuncStops(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	for i := range 50 {
+		sh.Put(newPoint(i, float64(i), 0))
+	}
+
+	visited := 0
+
+	sh.CandidatesFunc(Box[float64]{Width: 100, Height: 10}, 25, 0, func(Node[int, float64], bool) bool {
+		visited++
+
+		return visited < 3
+	})
+
+	if visited != 3 {
+		t.Errorf("Expected 3 visits, got %d", visited)
+	}
+
+	visited = 0
+
+	sh.QueryShapeFunc(Box[float64]{Width: 100, Height: 10}, 25, 0, func(Node[int, float64]) bool {
+		visited++
+
+		return false
+	})
+
+	if visited != 1 {
+		t.Errorf("Expected 1 visit, got %d", visited)
+	}
+}
@@ -0,0 +1,126 @@
+package spatial_hash
+
+import "fmt"
+
+// contains reports whether a node with the id of n is in the spatial hash. Without an index by id,
+// which SetExactIndex, SetMoveNotifications and SetHysteresis maintain, only the bucket of the
+// cell containing (x, y) is looked at.
+func (sh *SpatialHash[Id, N]) contains(n Node[Id, N], x, y N) bool {
+	id := n.GetId()
+
+	if _, ok := sh.bounded.Load(id); ok {
+		return true
+	}
+
+	var ok bool
+
+	switch {
+	case sh.tracker != nil:
+		_, ok = sh.tracker.nodes.Load(id)
+
+	case sh.exact != nil:
+		_, ok = sh.exact.positions.Load(id)
+
+	case sh.placed != nil:
+		_, ok = sh.placed.Load(id)
+
+	default:
+		if bucket, found := sh.buckets.Load(sh.placedCell(n, x, y)); found {
+			_, ok = bucket.nodes.Load(id)
+		}
+	}
+
+	return ok
+}
+
+// outOfBounds returns ErrOutOfBounds for n if its position lies outside the world bounds.
+func (sh *SpatialHash[Id, N]) outOfBounds(n Node[Id, N]) error {
+	x, y := sh.Quantize(n.GetX()), sh.Quantize(n.GetY())
+
+	if sh.world != nil && !sh.world.Contains(x, y) {
+		return fmt.Errorf("%w: node %v at (%v, %v)", ErrOutOfBounds, n.GetId(), x, y)
+	}
+
+	return nil
+}
+
+// PutChecked puts n as Put does, unless it would fail silently: it returns ErrOutOfBounds if n
+// lies outside the world bounds, where queries would never find it, and ErrDuplicateId if a
+// node with its id is already in the spatial hash. Without an index by id, which
+// SetExactIndex, SetMoveNotifications and SetHysteresis maintain, duplicates are only
+// detected in the cell n is put in.
+//
+// Checks are not atomic with concurrent mutations of the same id.
+func (sh *SpatialHash[Id, N]) PutChecked(n Node[Id, N]) error {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.checkId(n)
+
+	if err := sh.outOfBounds(n); err != nil {
+		return err
+	}
+
+	if sh.contains(n, n.GetX(), n.GetY()) {
+		return fmt.Errorf("%w: %v", ErrDuplicateId, n.GetId())
+	}
+
+	sh.hooked(OperationPut, n, func() { sh.put(n) })
+
+	return nil
+}
+
+// UpdateChecked updates n as Update, or UpdateBounded for bounded nodes, does, unless it would
+// fail silently: it returns ErrNotFound if n is not in the spatial hash, and ErrOutOfBounds if
+// its new position lies outside the world bounds, leaving it where it was. Without an index by
+// id, n is looked for in the cell of its old position.
+//
+// Checks are not atomic with concurrent mutations of the same id.
+func (sh *SpatialHash[Id, N]) UpdateChecked(n Node[Id, N]) error {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	oldX, oldY := n.GetOldPos()
+
+	if !sh.contains(n, oldX, oldY) {
+		return fmt.Errorf("%w: %v", ErrNotFound, n.GetId())
+	}
+
+	if err := sh.outOfBounds(n); err != nil {
+		return err
+	}
+
+	if b, ok := n.(BoundedNode[Id, N]); ok {
+		if _, bounded := sh.bounded.Load(n.GetId()); bounded {
+			sh.updateBounded(b)
+
+			return nil
+		}
+	}
+
+	sh.update(n)
+
+	return nil
+}
+
+// RemoveChecked removes n as Remove, or RemoveBounded for bounded nodes, does, but returns
+// ErrNotFound if n is not in the spatial hash. Without an index by id, n is looked for in the
+// cell of its position, as localized Remove does.
+//
+// Checks are not atomic with concurrent mutations of the same id.
+func (sh *SpatialHash[Id, N]) RemoveChecked(n Node[Id, N]) error {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	if sh.removeBounded(n) {
+		return nil
+	}
+
+	if !sh.contains(n, n.GetX(), n.GetY()) {
+		return fmt.Errorf("%w: %v", ErrNotFound, n.GetId())
+	}
+
+	sh.hooked(OperationRemove, n, func() { sh.remove(n) })
+
+	return nil
+}
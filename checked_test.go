@@ -0,0 +1,85 @@
+package spatial_hash
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSpatialHashChecked(t *testing.T) {
+	for _, exact := range []bool{false, true} {
+		sh := NewSpatialHash[int, float64](10)
+		sh.SetExactIndex(exact)
+		sh.SetWorldBounds(Rect[float64]{0, 0, 100, 100})
+
+		p := newPoint(0, 5, 5)
+
+		if err := sh.PutChecked(p); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		if err := sh.PutChecked(newPoint(0, 6, 6)); !errors.Is(err, ErrDuplicateId) {
+			t.Errorf("Expected ErrDuplicateId, got %v", err)
+		}
+
+		if err := sh.PutChecked(newPoint(1, 150, 5)); !errors.Is(err, ErrOutOfBounds) {
+			t.Errorf("Expected ErrOutOfBounds, got %v", err)
+		}
+
+		p.x = 50
+		if err := sh.UpdateChecked(p); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		if found := sh.Search(50, 5, 1); len(found) != 1 {
+			t.Errorf("Expected the node updated, got %d nodes", len(found))
+		}
+
+		// The node stays where it was
+		p.x = -10
+		if err := sh.UpdateChecked(p); !errors.Is(err, ErrOutOfBounds) {
+			t.Errorf("Expected ErrOutOfBounds, got %v", err)
+		}
+
+		p.x = 50
+
+		if err := sh.UpdateChecked(newPoint(2, 5, 5)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		if err := sh.RemoveChecked(p); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		if err := sh.RemoveChecked(p); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound once removed, got %v", err)
+		}
+	}
+}
+
+func TestSpatialHashCheckedBounded(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	box := newBoxPoint(0, 5, 5, 8, 8)
+	sh.PutBounded(box)
+
+	if err := sh.PutChecked(newPoint(0, 5, 5)); !errors.Is(err, ErrDuplicateId) {
+		t.Errorf("Expected ErrDuplicateId, got %v", err)
+	}
+
+	box.x = 40
+	if err := sh.UpdateChecked(box); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if found := sh.Search(40, 5, 1); len(found) != 1 {
+		t.Errorf("Expected the bounded node updated, got %d nodes", len(found))
+	}
+
+	if err := sh.RemoveChecked(box); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if err := sh.RemoveChecked(box); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound once removed, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+// Command spatialbench runs the simulation harness across backends, cell sizes and entity
+// counts, and prints one row of tick-time measurements per combination as CSV or JSON.
+//
+// Usage:
+//
+//	spatialbench [flags]
+//
+// For example, to compare cell sizes for 10000 flocking entities:
+//
+//	spatialbench -entities 10000 -cell-sizes 25,50,100,200 -movement flocking
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+	"github.com/youdie323323/go-spatial-hash/spatialsim"
+	"github.com/youdie323323/go-spatial-hash/spatialtest"
+)
+
+// backends creates the index of each backend for a cell size.
+var backends = map[string]func(cellSize float64) spatialsim.Index{
+	"hash": func(cellSize float64) spatialsim.Index {
+		return spatial_hash.NewSpatialHash[int, float64](cellSize)
+	},
+	"hash-global-remove": func(cellSize float64) spatialsim.Index {
+		return spatial_hash.NewSpatialHashWithOptions[int, float64](cellSize, false)
+	},
+	"naive": func(float64) spatialsim.Index {
+		return spatialtest.NewNaive[int, float64]()
+	},
+}
+
+// movements maps the names of the movement patterns to them.
+var movements = map[string]spatialsim.Movement{
+	spatialsim.RandomWalk.String(): spatialsim.RandomWalk,
+	spatialsim.Flocking.String():   spatialsim.Flocking,
+	spatialsim.Orbit.String():      spatialsim.Orbit,
+}
+
+// Row is the measurement of one combination.
+type Row struct {
+	Backend  string  `json:"backend"`
+	CellSize float64 `json:"cell_size"`
+	Entities int     `json:"entities"`
+	Movement string  `json:"movement"`
+
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	Mean time.Duration `json:"mean_ns"`
+
+	Queries    int `json:"queries"`
+	Found      int `json:"found"`
+	Mismatches int `json:"mismatches"`
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "spatialbench:", err)
+		}
+
+		os.Exit(2)
+	}
+}
+
+// run parses args, runs every combination and writes the rows to stdout.
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("spatialbench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		backendList  = fs.String("backends", "hash,hash-global-remove", "comma-separated backends: hash, hash-global-remove, naive")
+		cellSizeList = fs.String("cell-sizes", "25,50,100,200", "comma-separated cell sizes")
+		entityList   = fs.String("entities", "1000,10000", "comma-separated entity counts")
+		movementName = fs.String("movement", "random-walk", "movement pattern: random-walk, flocking, orbit")
+		format       = fs.String("format", "csv", "output format: csv or json")
+
+		worldSize = fs.Float64("world", 5000, "side length of the square world")
+		speed     = fs.Float64("speed", 5, "maximum distance an entity moves per tick")
+		ticks     = fs.Int("ticks", 300, "number of timed ticks")
+		warmup    = fs.Int("warmup", 30, "number of untimed ticks before the timed ones")
+
+		perTick      = fs.Int("queries", 1000, "queries per tick")
+		searchWeight = fs.Int("search-weight", 1, "relative frequency of Search queries")
+		rectWeight   = fs.Int("rect-weight", 0, "relative frequency of QueryRect queries")
+		radius       = fs.Float64("radius", 50, "Search radius")
+		rectSize     = fs.Float64("rect-size", 100, "QueryRect width and height")
+
+		verifyEvery = fs.Int("verify-every", 0, "check every n-th tick's Search results against brute force")
+		seed        = fs.Uint64("seed", 1, "random seed")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	movement, ok := movements[*movementName]
+	if !ok {
+		return fmt.Errorf("unknown movement %q", *movementName)
+	}
+
+	names := strings.Split(*backendList, ",")
+
+	for _, name := range names {
+		if _, ok := backends[name]; !ok {
+			return fmt.Errorf("unknown backend %q", name)
+		}
+	}
+
+	cellSizes, err := parseList(*cellSizeList, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err != nil {
+		return fmt.Errorf("cell sizes: %w", err)
+	}
+
+	entityCounts, err := parseList(*entityList, strconv.Atoi)
+	if err != nil {
+		return fmt.Errorf("entities: %w", err)
+	}
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	for _, cellSize := range cellSizes {
+		if !(cellSize > 0) {
+			return fmt.Errorf("cell sizes: %v is not positive", cellSize)
+		}
+	}
+
+	for _, entities := range entityCounts {
+		if entities <= 0 {
+			return fmt.Errorf("entities: %d is not positive", entities)
+		}
+	}
+
+	if *ticks <= 0 {
+		return fmt.Errorf("ticks: %d is not positive", *ticks)
+	}
+
+	for _, flag := range []struct {
+		name  string
+		value int
+	}{
+		{"warmup", *warmup},
+		{"queries", *perTick},
+		{"search-weight", *searchWeight},
+		{"rect-weight", *rectWeight},
+		{"verify-every", *verifyEvery},
+	} {
+		if flag.value < 0 {
+			return fmt.Errorf("%s: %d is negative", flag.name, flag.value)
+		}
+	}
+
+	if *perTick > 0 && *searchWeight+*rectWeight == 0 {
+		return errors.New("queries: every query weight is zero")
+	}
+
+	var rows []Row
+
+	for _, name := range names {
+		for _, entities := range entityCounts {
+			for _, cellSize := range cellSizes {
+				result := spatialsim.Run(backends[name](cellSize), spatialsim.Config{
+					Entities:  entities,
+					WorldSize: *worldSize,
+
+					Movement: movement,
+					Speed:    *speed,
+
+					Ticks:  *ticks,
+					Warmup: *warmup,
+
+					Queries: spatialsim.QueryMix{
+						PerTick: *perTick,
+
+						SearchWeight: *searchWeight,
+						RectWeight:   *rectWeight,
+
+						Radius: *radius,
+						Width:  *rectSize,
+						Height: *rectSize,
+					},
+
+					VerifyEvery: *verifyEvery,
+					Seed:        *seed,
+				})
+
+				rows = append(rows, Row{
+					Backend:  name,
+					CellSize: cellSize,
+					Entities: entities,
+					Movement: movement.String(),
+
+					P50:  result.Percentile(50),
+					P90:  result.Percentile(90),
+					P99:  result.Percentile(99),
+					Mean: result.Mean(),
+
+					Queries:    result.Queries,
+					Found:      result.Found,
+					Mismatches: result.Mismatches,
+				})
+
+				// The naive backend ignores the cell size
+				if name == "naive" {
+					break
+				}
+			}
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(rows)
+	}
+
+	return writeCSV(stdout, rows)
+}
+
+// writeCSV writes rows as CSV with a header line, durations in nanoseconds.
+func writeCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"backend", "cell_size", "entities", "movement", "p50_ns", "p90_ns", "p99_ns", "mean_ns", "queries", "found", "mismatches"})
+
+	for _, r := range rows {
+		cw.Write([]string{
+			r.Backend,
+			strconv.FormatFloat(r.CellSize, 'g', -1, 64),
+			strconv.Itoa(r.Entities),
+			r.Movement,
+			strconv.FormatInt(int64(r.P50), 10),
+			strconv.FormatInt(int64(r.P90), 10),
+			strconv.FormatInt(int64(r.P99), 10),
+			strconv.FormatInt(int64(r.Mean), 10),
+			strconv.Itoa(r.Queries),
+			strconv.Itoa(r.Found),
+			strconv.Itoa(r.Mismatches),
+		})
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// parseList parses a comma-separated list of values.
+func parseList[T any](s string, parse func(string) (T, error)) ([]T, error) {
+	var values []T
+
+	for _, field := range strings.Split(s, ",") {
+		v, err := parse(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+// smallRun are flags for a run fast enough for tests.
+var smallRun = []string{"-entities", "200", "-world", "500", "-ticks", "3", "-warmup", "1", "-queries", "20", "-verify-every", "1"}
+
+func TestRunCSV(t *testing.T) {
+	var out bytes.Buffer
+
+	args := append([]string{"-backends", "hash,naive", "-cell-sizes", "25,50"}, smallRun...)
+
+	if err := run(args, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+
+	// Header, two cell sizes for the hash, one row for the naive backend
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 CSV lines, got %d", len(records))
+	}
+
+	for _, r := range records[1:] {
+		if mismatches := r[len(r)-1]; mismatches != "0" {
+			t.Errorf("Expected no mismatches for %s, got %s", r[0], mismatches)
+		}
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	var out bytes.Buffer
+
+	args := append([]string{"-format", "json", "-cell-sizes", "50", "-movement", "orbit"}, smallRun...)
+
+	if err := run(args, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	var rows []Row
+
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected a row per default backend, got %d", len(rows))
+	}
+
+	if rows[0].Movement != "orbit" || rows[0].Queries != 60 {
+		t.Errorf("Unexpected row %+v", rows[0])
+	}
+}
+
+func TestRunInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-backends", "octree"},
+		{"-movement", "teleport"},
+		{"-cell-sizes", "big"},
+		{"-format", "xml"},
+		{"-cell-sizes", "0"},
+		{"-cell-sizes", "50,-25"},
+		{"-entities", "-5"},
+		{"-ticks", "0"},
+		{"-warmup", "-1"},
+		{"-queries", "-1"},
+		{"-search-weight", "-1"},
+		{"-search-weight", "0", "-rect-weight", "0"},
+	} {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+}
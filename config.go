@@ -0,0 +1,202 @@
+package spatial_hash
+
+// Config holds the settings of a spatial hash, each matching a setter of SpatialHash.
+// The zero value of a field leaves the setting disabled.
+type Config[Id comparable, N Number] struct {
+	// CellSize is the size of the cells, see NewSpatialHash.
+	CellSize N
+	// LocalizedRemove is whether Remove only deletes from the bucket of the node, see
+	// NewSpatialHashWithOptions.
+	LocalizedRemove bool
+
+	// Quantization is the step coordinates are snapped to, see SetQuantization.
+	Quantization N
+	// WorldBounds is the rectangle every node lies in, or nil if unbounded, see SetWorldBounds.
+	WorldBounds *Rect[N]
+	// Hysteresis is the fraction of a cell nodes may move beyond it before migrating, see SetHysteresis.
+	Hysteresis float64
+
+	// ExactIndex enables the exact position index, see SetExactIndex.
+	ExactIndex bool
+	// MoveNotifications enables NotifyMoved and UpdateMoved, see SetMoveNotifications.
+	MoveNotifications bool
+	// SleepAfter is the number of idle UpdateAll calls after which nodes fall asleep, see SetSleepAfter.
+	SleepAfter int
+	// Interpolation enables SearchInterpolated, see SetInterpolation.
+	Interpolation bool
+	// Extrapolation enables SearchExtrapolated, see SetExtrapolation.
+	Extrapolation bool
+	// FlowWindow is the number of steps arrivals and departures are counted over, see SetFlowWindow.
+	FlowWindow int
+	// PoolCheck is how misuse of SearchOwned slices is reported, see SetPoolCheck.
+	PoolCheck PoolCheck
+
+	// IdValidator rejects invalid ids on Put, see SetIdValidator.
+	IdValidator func(id Id) bool
+	// MaxStep and Corrector validate positions on Update, see SetCorrector.
+	MaxStep   N
+	Corrector Corrector[Id, N]
+	// Hooks are called around every mutation, see SetHooks.
+	Hooks Hooks[Id, N]
+}
+
+// Option sets a field of the Config a spatial hash is created with.
+type Option[Id comparable, N Number] func(c *Config[Id, N])
+
+// New creates a spatial hash with the given cell size and options applied to the default
+// settings of NewSpatialHash.
+func New[Id comparable, N Number](cellSize N, opts ...Option[Id, N]) *SpatialHash[Id, N] {
+	c := Config[Id, N]{CellSize: cellSize, LocalizedRemove: true}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return NewFromConfig(c)
+}
+
+// NewFromConfig creates a spatial hash with the settings of c.
+func NewFromConfig[Id comparable, N Number](c Config[Id, N]) *SpatialHash[Id, N] {
+	sh := NewSpatialHashWithOptions[Id](c.CellSize, c.LocalizedRemove)
+
+	sh.SetQuantization(c.Quantization)
+
+	if c.WorldBounds != nil {
+		sh.SetWorldBounds(*c.WorldBounds)
+	}
+
+	sh.SetHysteresis(c.Hysteresis)
+	sh.SetExactIndex(c.ExactIndex)
+	sh.SetMoveNotifications(c.MoveNotifications)
+	sh.SetSleepAfter(c.SleepAfter)
+	sh.SetInterpolation(c.Interpolation)
+	sh.SetExtrapolation(c.Extrapolation)
+	sh.SetFlowWindow(c.FlowWindow)
+	sh.SetPoolCheck(c.PoolCheck)
+	sh.SetIdValidator(c.IdValidator)
+	sh.SetCorrector(c.MaxStep, c.Corrector)
+	sh.SetHooks(c.Hooks)
+
+	return sh
+}
+
+// Config returns the effective settings of the spatial hash, as set by its constructor and
+// setters. Settings rounded to the coordinate type, such as Hysteresis with integer
+// coordinates, are returned as rounded.
+func (sh *SpatialHash[Id, N]) Config() Config[Id, N] {
+	c := Config[Id, N]{
+		CellSize:        sh.cellSize,
+		LocalizedRemove: sh.localizedRemove,
+
+		Quantization: sh.quantum,
+
+		ExactIndex:        sh.exact != nil,
+		MoveNotifications: sh.tracker != nil,
+		Interpolation:     sh.interp != nil,
+		Extrapolation:     sh.maxSpeed != nil,
+
+		IdValidator: sh.validId,
+	}
+
+	if sh.world != nil {
+		bounds := *sh.world
+		c.WorldBounds = &bounds
+	}
+
+	if sh.placed != nil {
+		c.Hysteresis = float64(sh.hysteresis) / float64(sh.cellSize)
+	}
+
+	if sh.sleep != nil {
+		c.SleepAfter = sh.sleep.after
+	}
+
+	if sh.flow != nil {
+		c.FlowWindow = sh.flow.window
+	}
+
+	if sh.poolCheck != nil {
+		c.PoolCheck = sh.poolCheck.mode
+	}
+
+	if sh.authority != nil {
+		c.MaxStep = sh.authority.maxStep
+		c.Corrector = sh.authority.correct
+	}
+
+	if sh.hooks != nil {
+		c.Hooks = sh.hooks.Hooks
+	}
+
+	return c
+}
+
+// WithLocalizedRemove sets Config.LocalizedRemove.
+func WithLocalizedRemove[Id comparable, N Number](enabled bool) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.LocalizedRemove = enabled }
+}
+
+// WithQuantization sets Config.Quantization.
+func WithQuantization[Id comparable, N Number](step N) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.Quantization = step }
+}
+
+// WithWorldBounds sets Config.WorldBounds.
+func WithWorldBounds[Id comparable, N Number](bounds Rect[N]) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.WorldBounds = &bounds }
+}
+
+// WithHysteresis sets Config.Hysteresis.
+func WithHysteresis[Id comparable, N Number](fraction float64) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.Hysteresis = fraction }
+}
+
+// WithExactIndex sets Config.ExactIndex.
+func WithExactIndex[Id comparable, N Number]() Option[Id, N] {
+	return func(c *Config[Id, N]) { c.ExactIndex = true }
+}
+
+// WithMoveNotifications sets Config.MoveNotifications.
+func WithMoveNotifications[Id comparable, N Number]() Option[Id, N] {
+	return func(c *Config[Id, N]) { c.MoveNotifications = true }
+}
+
+// WithSleepAfter sets Config.SleepAfter.
+func WithSleepAfter[Id comparable, N Number](ticks int) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.SleepAfter = ticks }
+}
+
+// WithInterpolation sets Config.Interpolation.
+func WithInterpolation[Id comparable, N Number]() Option[Id, N] {
+	return func(c *Config[Id, N]) { c.Interpolation = true }
+}
+
+// WithExtrapolation sets Config.Extrapolation.
+func WithExtrapolation[Id comparable, N Number]() Option[Id, N] {
+	return func(c *Config[Id, N]) { c.Extrapolation = true }
+}
+
+// WithFlowWindow sets Config.FlowWindow.
+func WithFlowWindow[Id comparable, N Number](window int) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.FlowWindow = window }
+}
+
+// WithPoolCheck sets Config.PoolCheck.
+func WithPoolCheck[Id comparable, N Number](mode PoolCheck) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.PoolCheck = mode }
+}
+
+// WithIdValidator sets Config.IdValidator.
+func WithIdValidator[Id comparable, N Number](valid func(id Id) bool) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.IdValidator = valid }
+}
+
+// WithCorrector sets Config.MaxStep and Config.Corrector.
+func WithCorrector[Id comparable, N Number](maxStep N, correct Corrector[Id, N]) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.MaxStep, c.Corrector = maxStep, correct }
+}
+
+// WithHooks sets Config.Hooks.
+func WithHooks[Id comparable, N Number](hooks Hooks[Id, N]) Option[Id, N] {
+	return func(c *Config[Id, N]) { c.Hooks = hooks }
+}
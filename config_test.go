@@ -0,0 +1,57 @@
+package spatial_hash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpatialHashConfig(t *testing.T) {
+	bounds := Rect[float64]{0, 0, 100, 100}
+
+	sh := New(10,
+		WithExactIndex[int, float64](),
+		WithWorldBounds[int](bounds),
+		WithHysteresis[int, float64](0.25),
+		WithFlowWindow[int, float64](8),
+		WithPoolCheck[int, float64](PoolCheckError),
+	)
+
+	c := sh.Config()
+
+	if c.CellSize != 10 || !c.LocalizedRemove || !c.ExactIndex || c.Hysteresis != 0.25 || c.FlowWindow != 8 || c.PoolCheck != PoolCheckError {
+		t.Errorf("Expected the options to be applied, got %+v", c)
+	}
+
+	if c.WorldBounds == nil || *c.WorldBounds != bounds {
+		t.Errorf("Expected world bounds %v, got %v", bounds, c.WorldBounds)
+	}
+
+	if c.MoveNotifications || c.Interpolation || c.SleepAfter != 0 || c.Corrector != nil {
+		t.Errorf("Expected other settings disabled, got %+v", c)
+	}
+
+	// Settings changed after creation are reported too
+	sh.SetSleepAfter(3)
+
+	if c := sh.Config(); c.SleepAfter != 3 {
+		t.Errorf("Expected SleepAfter 3, got %d", c.SleepAfter)
+	}
+
+	// A config recreates an equivalent spatial hash
+	copied := NewFromConfig(sh.Config()).Config()
+	original := sh.Config()
+
+	if !reflect.DeepEqual(copied.WorldBounds, original.WorldBounds) {
+		t.Errorf("Expected the same world bounds, got %v", copied.WorldBounds)
+	}
+
+	copied.WorldBounds, original.WorldBounds = nil, nil
+
+	if !reflect.DeepEqual(copied, original) {
+		t.Errorf("Expected %+v, got %+v", original, copied)
+	}
+
+	if c := NewSpatialHash[int, float64](10).Config(); !reflect.DeepEqual(c, Config[int, float64]{CellSize: 10, LocalizedRemove: true}) {
+		t.Errorf("Expected default settings, got %+v", c)
+	}
+}
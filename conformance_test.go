@@ -0,0 +1,22 @@
+package spatial_hash_test
+
+import (
+	"testing"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+	"github.com/youdie323323/go-spatial-hash/spatialtest"
+)
+
+func TestSpatialHashConformance(t *testing.T) {
+	t.Run("LocalizedRemove", func(t *testing.T) {
+		spatialtest.RunConformance(t, func(cellSize float64) spatialtest.Index {
+			return spatial_hash.NewSpatialHashWithOptions[int](cellSize, true)
+		})
+	})
+
+	t.Run("GlobalRemove", func(t *testing.T) {
+		spatialtest.RunConformance(t, func(cellSize float64) spatialtest.Index {
+			return spatial_hash.NewSpatialHashWithOptions[int](cellSize, false)
+		})
+	})
+}
@@ -0,0 +1,119 @@
+package spatial_hash
+
+import "math"
+
+// crowdingMaxWeight caps the weight of a single neighbor, so that co-located nodes stay finite.
+const crowdingMaxWeight = 16
+
+// crowdingWeight returns the weight of a neighbor at squared distance distSq within radius:
+// radius divided by the distance, so a neighbor on the edge weighs 1 and closer ones weigh more.
+// A co-located neighbor weighs crowdingMaxWeight even for a zero radius, and a negative radius
+// finds no neighbors.
+func crowdingWeight(distSq, radius float64) float64 {
+	if !(radius >= 0) {
+		return 0
+	}
+
+	if distSq == 0 {
+		return crowdingMaxWeight
+	}
+
+	return math.Min(radius/math.Sqrt(distSq), crowdingMaxWeight)
+}
+
+// forEachInCells calls fn for every node in the cells from (minX, minY) to (maxX, maxY),
+// stopping early if fn returns false. It reports whether iteration ran to completion.
+func (sh *SpatialHash[Id, N]) forEachInCells(minX, minY, maxX, maxY int, fn func(n Node[Id, N]) bool) bool {
+	minX, minY, maxX, maxY = sh.clampCellRange(minX, minY, maxX, maxY)
+
+	for yy := minY; yy <= maxY; yy++ {
+		for xx := minX; xx <= maxX; xx++ {
+			bucket, ok := sh.cellBucket(xx, yy)
+			if !ok {
+				continue
+			}
+
+			completed := true
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				completed = fn(n)
+
+				return completed
+			})
+
+			if !completed {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// crowding sums the crowding weights of the nodes within radius of (x, y), other than the node with id self.
+func (sh *SpatialHash[Id, N]) crowding(self Id, x, y, radius N) float64 {
+	radiusSq := float64(radius) * float64(radius)
+
+	factor := 0.0
+
+	minX, minY, maxX, maxY := sh.queryCells(x, y, radius, radius)
+
+	sh.forEachInCells(minX, minY, maxX, maxY, func(other Node[Id, N]) bool {
+		if other.GetId() == self {
+			return true
+		}
+
+		dx := float64(sh.Quantize(other.GetX()) - x)
+		dy := float64(sh.Quantize(other.GetY()) - y)
+
+		if distSq := distanceSq(dx, dy); distSq <= radiusSq {
+			factor += crowdingWeight(distSq, float64(radius))
+		}
+
+		return true
+	})
+
+	return factor
+}
+
+// CrowdingFactor returns how crowded the surroundings of n are: the sum over every other node
+// within radius of radius divided by its distance, capped at 16 per neighbor.
+// A neighbor on the edge of the radius adds 1, and closer neighbors add more.
+func (sh *SpatialHash[Id, N]) CrowdingFactor(n Node[Id, N], radius N) float64 {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	return sh.crowding(n.GetId(), sh.Quantize(n.GetX()), sh.Quantize(n.GetY()), radius)
+}
+
+// CrowdingFactors computes the CrowdingFactor of every node in one sweep, calling fn with each
+// node and its factor. Every pair of cells within reach is visited once and every pair of nodes
+// is measured once, crediting both nodes, so this is about twice as fast as calling
+// CrowdingFactor for each node.
+//
+// fn must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) CrowdingFactors(radius N, fn func(n Node[Id, N], factor float64)) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	radiusF := float64(radius)
+	radiusSq := radiusF * radiusF
+
+	entries := sh.sweepPairs(radius, func(a, b *pairEntry[Id, N]) bool {
+		dx := float64(a.x - b.x)
+		dy := float64(a.y - b.y)
+
+		if distSq := distanceSq(dx, dy); distSq <= radiusSq {
+			w := crowdingWeight(distSq, radiusF)
+
+			a.factor += w
+			b.factor += w
+		}
+
+		return true
+	})
+
+	for _, e := range entries {
+		fn(e.node, e.factor)
+	}
+}
@@ -0,0 +1,86 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+// naiveCrowding computes the crowding factor of n by brute force.
+func naiveCrowding(nodes []*Point, n *Point, radius float64) float64 {
+	factor := 0.0
+
+	for _, other := range nodes {
+		if other == n {
+			continue
+		}
+
+		if d := math.Hypot(other.x-n.x, other.y-n.y); d <= radius {
+			factor += math.Min(radius/d, crowdingMaxWeight)
+		}
+	}
+
+	return factor
+}
+
+func TestSpatialHashCrowding(t *testing.T) {
+	const radius = 30
+
+	nodes := CreateTestNodes(2000, 500, 500)
+
+	// Co-located pair
+	nodes = append(nodes, newPoint(len(nodes), 250, 250), newPoint(len(nodes)+1, 250, 250))
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	const tolerance = 1e-9
+
+	for _, n := range nodes[:100] {
+		if got, want := sh.CrowdingFactor(n, radius), naiveCrowding(nodes, n, radius); math.Abs(got-want) > tolerance {
+			t.Fatalf("Node %d: expected crowding %v, got %v", n.id, want, got)
+		}
+	}
+
+	visited := 0
+
+	sh.CrowdingFactors(radius, func(n TestingNode, factor float64) {
+		visited++
+
+		if want := naiveCrowding(nodes, n.(*Point), radius); math.Abs(factor-want) > tolerance {
+			t.Fatalf("Node %d: expected batched crowding %v, got %v", n.GetId(), want, factor)
+		}
+	})
+
+	if visited != len(nodes) {
+		t.Errorf("Expected %d nodes visited, got %d", len(nodes), visited)
+	}
+}
+
+func TestSpatialHashCrowdingZeroRadius(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	a, b, c := newPoint(0, 5, 5), newPoint(1, 5, 5), newPoint(2, 6, 5)
+
+	sh.Put(a)
+	sh.Put(b)
+	sh.Put(c)
+
+	if got := sh.CrowdingFactor(a, 0); got != crowdingMaxWeight {
+		t.Errorf("Expected crowding %v for a co-located neighbor, got %v", crowdingMaxWeight, got)
+	}
+
+	if got := sh.CrowdingFactor(a, -1); got != 0 {
+		t.Errorf("Expected no crowding for a negative radius, got %v", got)
+	}
+
+	want := map[int]float64{0: crowdingMaxWeight, 1: crowdingMaxWeight, 2: 0}
+
+	sh.CrowdingFactors(0, func(n TestingNode, factor float64) {
+		if factor != want[n.GetId()] {
+			t.Errorf("Node %d: expected batched crowding %v, got %v", n.GetId(), want[n.GetId()], factor)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package spatial_hash
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Cursor pages through every node of a spatial hash over any number of calls, such as an admin
+// tool listing all entities across ticks, while other goroutines keep mutating it.
+//
+// The cells holding nodes are listed when the cursor is created, and each is read in one go
+// when the cursor reaches it: nodes are delivered as they were in their cell at that moment,
+// even if they are removed before being returned. Each node is delivered at most once, however
+// it moves between cells. A node that stays in its cell throughout the iteration is delivered
+// exactly once; a node put, or moved into a cell the cursor has already read or did not list,
+// may be missed. Bounded nodes are delivered once, from the cell of their center.
+//
+// A Cursor must not be used by several goroutines at once.
+type Cursor[Id comparable, N Number] struct {
+	sh *SpatialHash[Id, N]
+
+	// keys holds the cells of the buckets listed at creation, in a stable order.
+	keys []cell
+	next int
+
+	// pending holds the entries left of the bucket being read, as of when it was reached.
+	pending []bucketEntry[Id, N]
+
+	delivered map[Id]struct{}
+}
+
+// NewCursor creates a cursor over the nodes of the spatial hash. It does not hold any lock
+// between calls, so Drain and ResetSafe are never blocked by a cursor left unfinished.
+func (sh *SpatialHash[Id, N]) NewCursor() *Cursor[Id, N] {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	var keys []cell
+
+	sh.buckets.Range(func(key cell, _ *bucket[Id, N]) bool {
+		keys = append(keys, key)
+
+		return true
+	})
+
+	slices.SortFunc(keys, func(a, b cell) int {
+		return cmp.Or(cmp.Compare(a.y, b.y), cmp.Compare(a.x, b.x))
+	})
+
+	return &Cursor[Id, N]{sh: sh, keys: keys, delivered: make(map[Id]struct{})}
+}
+
+// Next returns up to limit more nodes, fewer only once the iteration is done, after which it
+// returns nil. It panics if limit is not positive.
+func (c *Cursor[Id, N]) Next(limit int) NodeSlice[Id, N] {
+	if limit < 1 {
+		panic(fmt.Sprintf("spatial_hash: invalid cursor limit %d", limit))
+	}
+
+	t := c.sh.drainMu.RLock()
+	defer c.sh.drainMu.RUnlock(t)
+
+	var nodes NodeSlice[Id, N]
+
+	for len(nodes) < limit && c.advance() {
+		n := c.pending[0].node
+
+		c.pending = c.pending[1:]
+		c.delivered[n.GetId()] = struct{}{}
+
+		nodes = append(nodes, n)
+	}
+
+	return nodes
+}
+
+// Done reports whether every node has been delivered, so that Next would return nil.
+func (c *Cursor[Id, N]) Done() bool {
+	t := c.sh.drainMu.RLock()
+	defer c.sh.drainMu.RUnlock(t)
+
+	return !c.advance()
+}
+
+// Delivered returns how many nodes the cursor has delivered so far.
+func (c *Cursor[Id, N]) Delivered() int {
+	return len(c.delivered)
+}
+
+// advance skips the pending entries that are not to be delivered, reading the next listed
+// buckets as needed, and reports whether a node is left to deliver at the head of pending.
+func (c *Cursor[Id, N]) advance() bool {
+	for {
+		for len(c.pending) > 0 {
+			e := c.pending[0]
+
+			if _, ok := c.delivered[e.node.GetId()]; e.home && !ok {
+				return true
+			}
+
+			c.pending = c.pending[1:]
+		}
+
+		if c.next == len(c.keys) {
+			// Release the snapshot of the last bucket read
+			c.pending = nil
+
+			return false
+		}
+
+		if b, ok := c.sh.buckets.Load(c.keys[c.next]); ok {
+			c.pending = b.entries()
+		}
+
+		c.next++
+	}
+}
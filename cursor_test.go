@@ -0,0 +1,153 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+func TestSpatialHashCursor(t *testing.T) {
+	nodes := CreateTestNodes(1000, 500, 500)
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	sh.PutBounded(newBoxPoint(1000, 250, 250, 60, 60))
+
+	c := sh.NewCursor()
+
+	seen := make(map[int]bool)
+
+	for !c.Done() {
+		page := c.Next(64)
+
+		if len(page) == 0 || len(page) > 64 {
+			t.Fatalf("Expected a page of 1 to 64 nodes, got %d", len(page))
+		}
+
+		for _, n := range page {
+			if seen[n.GetId()] {
+				t.Fatalf("Expected node %d once", n.GetId())
+			}
+
+			seen[n.GetId()] = true
+		}
+	}
+
+	if len(seen) != 1001 || c.Delivered() != 1001 {
+		t.Errorf("Expected 1001 nodes, got %d (delivered %d)", len(seen), c.Delivered())
+	}
+
+	if page := c.Next(10); page != nil {
+		t.Errorf("Expected no nodes after the end, got %d", len(page))
+	}
+}
+
+func TestSpatialHashCursorConcurrent(t *testing.T) {
+	static := CreateTestNodes(500, 1000, 1000)
+	movers := CreateTestNodes(500, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](25)
+
+	for _, n := range static {
+		sh.Put(n)
+	}
+
+	for i, n := range movers {
+		n.id = 500 + i
+
+		sh.Put(n)
+	}
+
+	c := sh.NewCursor()
+
+	var (
+		wg   sync.WaitGroup
+		stop = make(chan struct{})
+	)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			n := movers[rand.IntN(len(movers))]
+
+			n.oldX, n.oldY = n.x, n.y
+			n.x, n.y = 1000*rand.Float64(), 1000*rand.Float64()
+
+			sh.Update(n)
+
+			if rand.IntN(10) == 0 {
+				sh.Remove(n)
+				sh.Put(n)
+			}
+		}
+	}()
+
+	seen := make(map[int]bool)
+
+	for page := c.Next(7); page != nil; page = c.Next(7) {
+		for _, n := range page {
+			if seen[n.GetId()] {
+				t.Fatalf("Expected node %d once", n.GetId())
+			}
+
+			seen[n.GetId()] = true
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	for _, n := range static {
+		if !seen[n.id] {
+			t.Fatalf("Expected static node %d to be delivered", n.id)
+		}
+	}
+}
+
+func TestSpatialHashCursorReset(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	for i := range 100 {
+		sh.Put(newPoint(i, float64(i), float64(i)))
+	}
+
+	c := sh.NewCursor()
+
+	first := c.Next(5)
+
+	sh.ResetSafe()
+
+	// The rest of the cell being read, then nothing
+	rest := len(c.Next(100))
+
+	if len(first)+rest > 10 {
+		t.Errorf("Expected at most the 10 nodes of the first cell, got %d", len(first)+rest)
+	}
+
+	if !c.Done() {
+		t.Error("Expected the cursor to be done")
+	}
+}
+
+func TestSpatialHashCursorInvalidLimit(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a zero limit")
+		}
+	}()
+
+	NewSpatialHash[int, float64](10).NewCursor().Next(0)
+}
@@ -0,0 +1,161 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"time"
+)
+
+// DegradePolicy describes how a Ticker reduces its work when ticks run over budget. Ticks
+// raise or lower a degradation level, from 0 for none up to MaxLevel, and each action of the
+// policy starts at a level of its own. An action starting at level zero is disabled.
+type DegradePolicy struct {
+	// Budget is the time a tick may take; ticks taking longer are overloaded.
+	Budget time.Duration
+
+	// Overloaded is the number of consecutive overloaded ticks after which the level rises by
+	// one, and Recovered the number of consecutive ticks within budget after which it falls by
+	// one. Values below 1 count as 1.
+	Overloaded, Recovered int
+
+	// MaxLevel is the highest level. Zero disables degradation.
+	MaxLevel int
+
+	// StretchFrom is the level from which scheduled queries are refreshed less often: at level
+	// L, the scheduler advances once every L-StretchFrom+2 ticks, so that every query waits
+	// that many times its period between refreshes.
+	StretchFrom int
+	// ApproximateFrom is the level from which scheduled searches return every node in the
+	// cells their radius covers, skipping per-node distance checks. Interned and relevance
+	// queries are refreshed as usual.
+	ApproximateFrom int
+	// PauseFrom is the level from which PriorityLow cell subscriptions are paused, and
+	// PriorityNormal ones too from the next level. PriorityCritical ones are never paused.
+	// Paused subscriptions drop their events, see CellSubscription.Dropped.
+	PauseFrom int
+}
+
+// Degradation reports the work a Ticker skipped during a tick to stay within its budget.
+type Degradation struct {
+	// Level is the degradation level the tick ran at.
+	Level int
+
+	// DeferredQueries is the number of scheduled queries due on the tick that were deferred
+	// by StretchFrom.
+	DeferredQueries int
+	// ApproximateQueries is the number of scheduled queries refreshed approximately.
+	ApproximateQueries int
+	// DroppedEvents is the number of cell events paused subscriptions were not delivered.
+	DroppedEvents int
+}
+
+// degradation is the state of a Ticker degrading under a policy.
+type degradation struct {
+	DegradePolicy
+
+	level int
+
+	// overloaded and recovered count the consecutive ticks over and within budget.
+	overloaded, recovered int
+
+	// held is the number of ticks the scheduler has not advanced for.
+	held int
+}
+
+// SetDegradePolicy makes the ticker reduce its work according to p when ticks run over
+// budget, so that overload degrades the quality of the spatial layer instead of sending it
+// into a death spiral. What was skipped is reported in TickStats.Degradation. It panics if
+// MaxLevel is negative or Budget is not positive with degradation enabled.
+//
+// SetDegradePolicy must be called before the ticker is started.
+func (t *Ticker[Id, N]) SetDegradePolicy(p DegradePolicy) {
+	if p.MaxLevel < 0 || p.MaxLevel > 0 && p.Budget <= 0 {
+		panic(fmt.Sprintf("spatial_hash: invalid degrade policy %+v", p))
+	}
+
+	if p.MaxLevel == 0 {
+		t.degrade = nil
+
+		return
+	}
+
+	p.Overloaded, p.Recovered = max(p.Overloaded, 1), max(p.Recovered, 1)
+
+	t.degrade = &degradation{DegradePolicy: p}
+}
+
+// Level returns the degradation level the next tick runs at, 0 when not degraded.
+func (t *Ticker[Id, N]) Level() int {
+	if t.degrade == nil {
+		return 0
+	}
+
+	return t.degrade.level
+}
+
+// active reports whether an action starting at level from applies at the current level.
+func (d *degradation) active(from int) bool {
+	return from > 0 && d.level >= from
+}
+
+// pausedBelow returns the priority below which subscriptions are paused at the current level.
+func (d *degradation) pausedBelow() Priority {
+	switch {
+	case d.PauseFrom == 0:
+		return PriorityLow
+	case d.active(d.PauseFrom + 1):
+		return PriorityCritical
+	case d.active(d.PauseFrom):
+		return PriorityNormal
+	default:
+		return PriorityLow
+	}
+}
+
+// refreshQueries runs the scheduler for a tick, deferring or approximating its queries as the
+// current level requires, and returns how many queries were refreshed.
+func (t *Ticker[Id, N]) refreshQueries(report *Degradation) int {
+	d := t.degrade
+	if d == nil {
+		return t.scheduler.Tick()
+	}
+
+	if d.active(d.StretchFrom) {
+		d.held++
+
+		if d.held < d.level-d.StretchFrom+2 {
+			report.DeferredQueries = t.scheduler.due()
+
+			return 0
+		}
+	}
+
+	d.held = 0
+
+	refreshed, approximated := t.scheduler.advance(d.active(d.ApproximateFrom))
+	report.ApproximateQueries = approximated
+
+	return refreshed
+}
+
+// adjust raises or lowers the level after a tick that took total.
+func (d *degradation) adjust(total time.Duration) {
+	if total > d.Budget {
+		d.overloaded++
+		d.recovered = 0
+
+		if d.overloaded >= d.Overloaded && d.level < d.MaxLevel {
+			d.level++
+			d.overloaded = 0
+		}
+
+		return
+	}
+
+	d.recovered++
+	d.overloaded = 0
+
+	if d.recovered >= d.Recovered && d.level > 0 {
+		d.level--
+		d.recovered = 0
+	}
+}
@@ -0,0 +1,157 @@
+package spatial_hash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickerDegradation(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	observer, p := newPoint(0, 100, 100), newPoint(1, 5, 5)
+
+	sh.Put(observer)
+	sh.Put(p)
+
+	sched := NewScheduler(sh, 1)
+	sched.Register(observer, 20)
+
+	received := make(map[Priority]int)
+
+	subs := make(map[Priority]*CellSubscription[int, float64])
+
+	for _, priority := range []Priority{PriorityLow, PriorityNormal, PriorityCritical} {
+		subs[priority] = sh.SubscribeCells(CellRect{0, 0, 1, 0}, func(CellEvent[int, float64]) {
+			received[priority]++
+		})
+
+		subs[priority].SetPriority(priority)
+	}
+
+	overloaded := true
+
+	ticker := NewTicker(sh, time.Millisecond, func(time.Duration) {
+		// Cross a cell boundary every tick
+		p.oldX = p.x
+		p.x = 20 - p.x
+
+		if overloaded {
+			time.Sleep(8 * time.Millisecond)
+		}
+	})
+
+	ticker.SetScheduler(sched)
+	ticker.SetDegradePolicy(DegradePolicy{
+		Budget:     5 * time.Millisecond,
+		Overloaded: 2,
+		MaxLevel:   3,
+
+		StretchFrom:     1,
+		ApproximateFrom: 2,
+		PauseFrom:       1,
+	})
+
+	expected := []struct {
+		overloaded bool
+
+		level                            int
+		refreshed, deferred, approximate int
+		delivered                        map[Priority]int
+	}{
+		{true, 0, 1, 0, 0, map[Priority]int{PriorityLow: 2, PriorityNormal: 2, PriorityCritical: 2}},
+		{true, 0, 1, 0, 0, map[Priority]int{PriorityLow: 2, PriorityNormal: 2, PriorityCritical: 2}},
+		{true, 1, 0, 1, 0, map[Priority]int{PriorityNormal: 2, PriorityCritical: 2}},
+		{true, 1, 1, 0, 0, map[Priority]int{PriorityNormal: 2, PriorityCritical: 2}},
+		{true, 2, 0, 1, 0, map[Priority]int{PriorityCritical: 2}},
+		{true, 2, 0, 1, 0, map[Priority]int{PriorityCritical: 2}},
+		{false, 3, 0, 1, 0, map[Priority]int{PriorityCritical: 2}},
+		{false, 2, 1, 0, 1, map[Priority]int{PriorityCritical: 2}},
+		{false, 1, 0, 1, 0, map[Priority]int{PriorityNormal: 2, PriorityCritical: 2}},
+		{false, 0, 1, 0, 0, map[Priority]int{PriorityLow: 2, PriorityNormal: 2, PriorityCritical: 2}},
+	}
+
+	for i, want := range expected {
+		overloaded = want.overloaded
+
+		clear(received)
+
+		stats := ticker.Step()
+		d := stats.Degradation
+
+		if d.Level != want.level || stats.Refreshed != want.refreshed || d.DeferredQueries != want.deferred || d.ApproximateQueries != want.approximate {
+			t.Fatalf("Tick %d: expected level %d with %d refreshed, %d deferred and %d approximate queries, got %d with %d, %d and %d",
+				i+1, want.level, want.refreshed, want.deferred, want.approximate, d.Level, stats.Refreshed, d.DeferredQueries, d.ApproximateQueries)
+		}
+
+		if dropped := 6 - want.delivered[PriorityLow] - want.delivered[PriorityNormal] - want.delivered[PriorityCritical]; d.DroppedEvents != dropped {
+			t.Fatalf("Tick %d: expected %d dropped events, got %d", i+1, dropped, d.DroppedEvents)
+		}
+
+		for _, priority := range []Priority{PriorityLow, PriorityNormal, PriorityCritical} {
+			if received[priority] != want.delivered[priority] {
+				t.Fatalf("Tick %d: expected %d events for priority %d, got %d", i+1, want.delivered[priority], priority, received[priority])
+			}
+		}
+	}
+
+	if got := subs[PriorityLow].Dropped(); got != 14 {
+		t.Errorf("Expected 14 dropped events for the low priority subscription, got %d", got)
+	}
+
+	if ticker.Level() != 0 {
+		t.Errorf("Expected to have recovered, got level %d", ticker.Level())
+	}
+}
+
+func TestTickerInvalidDegradePolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a policy without a budget")
+		}
+	}()
+
+	NewTicker(NewSpatialHash[int, float64](10), time.Millisecond, nil).SetDegradePolicy(DegradePolicy{MaxLevel: 1})
+}
+
+func TestTickerDegradationWithoutPause(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	p := newPoint(0, 5, 5)
+	sh.Put(p)
+
+	received := 0
+
+	sub := sh.SubscribeCells(CellRect{0, 0, 1, 0}, func(CellEvent[int, float64]) {
+		received++
+	})
+
+	sub.SetPriority(PriorityLow)
+
+	ticker := NewTicker(sh, time.Millisecond, func(time.Duration) {
+		p.oldX = p.x
+		p.x = 20 - p.x
+
+		time.Sleep(2 * time.Millisecond)
+	})
+
+	// Every action but pausing is enabled
+	ticker.SetDegradePolicy(DegradePolicy{
+		Budget:   time.Millisecond,
+		MaxLevel: 2,
+
+		StretchFrom:     1,
+		ApproximateFrom: 2,
+	})
+
+	for range 4 {
+		ticker.Step()
+	}
+
+	if ticker.Level() != 2 {
+		t.Fatalf("Expected level 2, got %d", ticker.Level())
+	}
+
+	if received != 8 || sub.Dropped() != 0 {
+		t.Errorf("Expected 8 events delivered and none dropped, got %d and %d", received, sub.Dropped())
+	}
+}
@@ -0,0 +1,96 @@
+package spatial_hash
+
+import "math"
+
+// DistanceField holds, for every cell in a range, the distance to the nearest obstacle cell.
+type DistanceField struct {
+	// Cells is the range of cells covered by the field.
+	Cells CellRect
+
+	// dists holds the distance of each cell, in row-major order.
+	dists []float32
+}
+
+// Distance returns the distance from cell (cx, cy) to the nearest obstacle cell, in world units
+// measured between cell centers. It returns +Inf if the range holds no obstacle or the cell lies
+// outside the field.
+func (f *DistanceField) Distance(cx, cy int) float32 {
+	if !f.Cells.Contains(cx, cy) {
+		return float32(math.Inf(1))
+	}
+
+	return f.dists[f.Cells.Index(cx, cy)]
+}
+
+// DistanceField computes the distance from every cell overlapped by rect to the nearest cell
+// containing a node for which isObstacle returns true, or any node if isObstacle is nil.
+// Cells marked with SetCellBlocked count as obstacles too. Obstacles outside rect are ignored.
+//
+// Distances are computed with a two-pass chamfer transform using steps of 1 and √2 cells,
+// which approximates Euclidean distance to within about 8%.
+//
+// isObstacle must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) DistanceField(rect Rect[N], isObstacle func(n Node[Id, N]) bool) *DistanceField {
+	obstacles := sh.OccupancyBits(rect, isObstacle)
+	cells := obstacles.Cells
+
+	f := &DistanceField{
+		Cells: cells,
+
+		dists: make([]float32, cells.Len()),
+	}
+
+	inf := float32(math.Inf(1))
+
+	for i := range f.dists {
+		cx, cy := cells.Cell(i)
+
+		if obstacles.Has(cx, cy) || sh.IsCellBlocked(cx, cy) {
+			f.dists[i] = 0
+		} else {
+			f.dists[i] = inf
+		}
+	}
+
+	const diagonal = math.Sqrt2
+
+	relax := func(i, cx, cy int, step float32) {
+		if cells.Contains(cx, cy) {
+			if d := f.dists[cells.Index(cx, cy)] + step; d < f.dists[i] {
+				f.dists[i] = d
+			}
+		}
+	}
+
+	// Forward pass, from the top-left neighbors
+	for cy := cells.MinY; cy <= cells.MaxY; cy++ {
+		for cx := cells.MinX; cx <= cells.MaxX; cx++ {
+			i := cells.Index(cx, cy)
+
+			relax(i, cx-1, cy, 1)
+			relax(i, cx-1, cy-1, diagonal)
+			relax(i, cx, cy-1, 1)
+			relax(i, cx+1, cy-1, diagonal)
+		}
+	}
+
+	// Backward pass, from the bottom-right neighbors
+	for cy := cells.MaxY; cy >= cells.MinY; cy-- {
+		for cx := cells.MaxX; cx >= cells.MinX; cx-- {
+			i := cells.Index(cx, cy)
+
+			relax(i, cx+1, cy, 1)
+			relax(i, cx+1, cy+1, diagonal)
+			relax(i, cx, cy+1, 1)
+			relax(i, cx-1, cy+1, diagonal)
+		}
+	}
+
+	cellSize := float32(sh.cellSize)
+
+	for i := range f.dists {
+		f.dists[i] *= cellSize
+	}
+
+	return f
+}
@@ -0,0 +1,39 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpatialHashDistanceField(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	sh.Put(newPoint(1, 5, 5))   // Obstacle in cell (0, 0)
+	sh.Put(newPoint(2, 95, 95)) // Not an obstacle, in cell (9, 9)
+
+	sh.SetCellBlocked(9, 0, true)
+
+	rect := Rect[float64]{MinX: 0, MinY: 0, MaxX: 99, MaxY: 99}
+
+	f := sh.DistanceField(rect, func(n TestingNode) bool { return n.GetId() == 1 })
+
+	const tolerance = 0.09 // Chamfer error bound
+
+	for cy := range 10 {
+		for cx := range 10 {
+			exact := 10 * math.Min(math.Hypot(float64(cx), float64(cy)), math.Hypot(float64(cx-9), float64(cy)))
+
+			if got := float64(f.Distance(cx, cy)); math.Abs(got-exact) > exact*tolerance {
+				t.Errorf("Cell (%d, %d): expected distance about %.2f, got %.2f", cx, cy, exact, got)
+			}
+		}
+	}
+
+	if d := f.Distance(0, 0); d != 0 {
+		t.Errorf("Expected zero distance in obstacle cell, got %v", d)
+	}
+
+	if d := f.Distance(100, 100); !math.IsInf(float64(d), 1) {
+		t.Errorf("Expected +Inf outside the field, got %v", d)
+	}
+}
@@ -0,0 +1,54 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"math"
+)
+
+// DistanceMatrix returns the distances between every pair of the nodes with the given ids,
+// where matrix[i][j] is the distance between ids[i] and ids[j]. Positions are read from the
+// exact position index, as of the last Put or Update, rather than through the nodes, so
+// squad and formation logic can run on a small selection every tick cheaply.
+// For integer coordinates, distances are truncated.
+//
+// It returns ErrNotFound for the first id not indexed by the exact index, which holds none
+// unless enabled with SetExactIndex. Bounded nodes are indexed at their center.
+func (sh *SpatialHash[Id, N]) DistanceMatrix(ids []Id) ([][]N, error) {
+	if sh.exact == nil && len(ids) > 0 {
+		return nil, fmt.Errorf("%w: %v, exact index disabled", ErrNotFound, ids[0])
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	positions := make([]position[N], len(ids))
+
+	for i, id := range ids {
+		pos, ok := sh.exact.positions.Load(id)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrNotFound, id)
+		}
+
+		positions[i] = pos
+	}
+
+	// Rows share one allocation
+	cells := make([]N, len(ids)*len(ids))
+	matrix := make([][]N, len(ids))
+
+	for i := range matrix {
+		matrix[i] = cells[i*len(ids) : (i+1)*len(ids) : (i+1)*len(ids)]
+	}
+
+	for i, a := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			b := positions[j]
+
+			d := N(math.Sqrt(distanceSq(float64(a.x)-float64(b.x), float64(a.y)-float64(b.y))))
+
+			matrix[i][j], matrix[j][i] = d, d
+		}
+	}
+
+	return matrix, nil
+}
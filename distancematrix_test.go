@@ -0,0 +1,62 @@
+package spatial_hash
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSpatialHashDistanceMatrix(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	a, b, c := newPoint(0, 0, 0), newPoint(1, 3, 4), newPoint(2, 30, 40)
+	sh.Put(a)
+	sh.Put(b)
+	sh.Put(c)
+
+	if _, err := sh.DistanceMatrix([]int{0, 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without the exact index, got %v", err)
+	}
+
+	sh = NewSpatialHash[int, float64](10)
+	sh.SetExactIndex(true)
+
+	for _, p := range []*Point{a, b, c} {
+		sh.Put(p)
+	}
+
+	c.x, c.y = 6, 8
+	sh.Update(c)
+
+	m, err := sh.DistanceMatrix([]int{0, 1, 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := [][]float64{
+		{0, 5, 10},
+		{5, 0, 5},
+		{10, 5, 0},
+	}
+
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(m[i][j]-expected[i][j]) > 1e-9 {
+				t.Errorf("Expected distance %v between %d and %d, got %v", expected[i][j], i, j, m[i][j])
+			}
+		}
+	}
+
+	sh.Remove(b)
+
+	if _, err := sh.DistanceMatrix([]int{0, 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a removed node, got %v", err)
+	}
+
+	// Bounded nodes are measured from their center
+	sh.PutBounded(newBoxPoint(3, 0, 20, 15, 15))
+
+	if m, err := sh.DistanceMatrix([]int{0, 3}); err != nil || m[0][1] != 20 {
+		t.Errorf("Expected a distance of 20 to the bounded node, got %v (%v)", m, err)
+	}
+}
@@ -0,0 +1,19 @@
+package spatial_hash
+
+import "errors"
+
+var (
+	// ErrNotFound is returned for a node or id that is not in the spatial hash.
+	ErrNotFound = errors.New("spatial_hash: node not found")
+	// ErrDuplicateId is returned when putting a node whose id is already in the spatial hash.
+	ErrDuplicateId = errors.New("spatial_hash: duplicate node id")
+	// ErrOutOfBounds is returned for a position outside the world bounds set with SetWorldBounds.
+	ErrOutOfBounds = errors.New("spatial_hash: position out of world bounds")
+
+	// ErrDoubleRelease is reported when a slice is passed to ReleaseResults twice.
+	ErrDoubleRelease = errors.New("spatial_hash: result slice released twice")
+	// ErrForeignRelease is reported when ReleaseResults is passed a slice SearchOwned did not return.
+	ErrForeignRelease = errors.New("spatial_hash: released slice was not returned by SearchOwned")
+	// ErrUseAfterRelease is reported when a node is read from a slice after it was released.
+	ErrUseAfterRelease = errors.New("spatial_hash: result slice used after release")
+)
@@ -0,0 +1,113 @@
+package spatial_hash
+
+import (
+	"slices"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// position is a quantized coordinate pair, used as a map key.
+type position[N Number] struct{ x, y N }
+
+// exactIndex indexes nodes by their exact position.
+type exactIndex[Id comparable, N Number] struct {
+	// positions holds the position each node is indexed at.
+	positions *xsync.Map[Id, position[N]]
+
+	// nodes holds the nodes at each position.
+	// Slices are never modified in place, so readers may use them without locking.
+	nodes *xsync.Map[position[N], NodeSlice[Id, N]]
+}
+
+// newExactIndex creates an empty exact position index.
+func newExactIndex[Id comparable, N Number]() *exactIndex[Id, N] {
+	return &exactIndex[Id, N]{
+		positions: xsync.NewMap[Id, position[N]](),
+		nodes:     xsync.NewMap[position[N], NodeSlice[Id, N]](),
+	}
+}
+
+// Move indexes n at pos, removing it from the position it was previously indexed at.
+func (ix *exactIndex[Id, N]) Move(n Node[Id, N], pos position[N]) {
+	id := n.GetId()
+
+	if old, ok := ix.positions.LoadAndStore(id, pos); ok {
+		if old == pos {
+			return
+		}
+
+		ix.delete(id, old)
+	}
+
+	ix.nodes.Compute(pos, func(nodes NodeSlice[Id, N], _ bool) (NodeSlice[Id, N], xsync.ComputeOp) {
+		return append(slices.Clip(nodes), n), xsync.UpdateOp
+	})
+}
+
+// Remove removes the node with the given id from the index.
+func (ix *exactIndex[Id, N]) Remove(id Id) {
+	if old, ok := ix.positions.LoadAndDelete(id); ok {
+		ix.delete(id, old)
+	}
+}
+
+// delete removes the node with the given id from the nodes at pos, dropping the position once empty.
+func (ix *exactIndex[Id, N]) delete(id Id, pos position[N]) {
+	ix.nodes.Compute(pos, func(nodes NodeSlice[Id, N], loaded bool) (NodeSlice[Id, N], xsync.ComputeOp) {
+		if !loaded {
+			return nil, xsync.CancelOp
+		}
+
+		nodes = slices.DeleteFunc(slices.Clone(nodes), func(n Node[Id, N]) bool { return n.GetId() == id })
+		if len(nodes) == 0 {
+			return nil, xsync.DeleteOp
+		}
+
+		return nodes, xsync.UpdateOp
+	})
+}
+
+// At returns a copy of the nodes indexed at pos.
+func (ix *exactIndex[Id, N]) At(pos position[N]) NodeSlice[Id, N] {
+	nodes, _ := ix.nodes.Load(pos)
+
+	return slices.Clone(nodes)
+}
+
+// Clear removes all nodes from the index.
+func (ix *exactIndex[Id, N]) Clear() {
+	ix.positions.Clear()
+	ix.nodes.Clear()
+}
+
+// SetExactIndex enables or disables the secondary index of nodes by exact position,
+// which answers AtExact with a single lookup instead of scanning a bucket.
+// Maintaining it costs a little on every Put, Remove and Update.
+//
+// SetExactIndex must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetExactIndex(enabled bool) {
+	if enabled {
+		sh.exact = newExactIndex[Id, N]()
+	} else {
+		sh.exact = nil
+	}
+}
+
+// exactPosition returns the quantized position of n, the key of the exact position index.
+func (sh *SpatialHash[Id, N]) exactPosition(n Node[Id, N]) position[N] {
+	return position[N]{sh.Quantize(n.GetX()), sh.Quantize(n.GetY())}
+}
+
+// AtExact returns the nodes located exactly at (x, y), as of their last Put or Update.
+// It uses the exact position index if enabled with SetExactIndex, and falls back to
+// AtPosition otherwise.
+func (sh *SpatialHash[Id, N]) AtExact(x, y N) NodeSlice[Id, N] {
+	if sh.exact == nil {
+		return sh.AtPosition(x, y)
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	return sh.exact.At(position[N]{sh.Quantize(x), sh.Quantize(y)})
+}
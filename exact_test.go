@@ -0,0 +1,60 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashAtExact(t *testing.T) {
+	sh := NewSpatialHash[int, float64](100)
+
+	sh.SetExactIndex(true)
+
+	a, b, c := newPoint(1, 10, 10), newPoint(2, 10, 10), newPoint(3, 20, 20)
+
+	sh.Put(a)
+	sh.Put(b)
+	sh.Put(c)
+
+	if result := sh.AtExact(10, 10); len(result) != 2 {
+		t.Errorf("Expected 2 nodes at (10, 10), got %d", len(result))
+	}
+
+	// Move within the same cell
+	c.x, c.y = 10, 10
+
+	sh.Update(c)
+
+	if result := sh.AtExact(10, 10); len(result) != 3 {
+		t.Errorf("Expected 3 nodes at (10, 10) after update, got %d", len(result))
+	}
+
+	if result := sh.AtExact(20, 20); len(result) != 0 {
+		t.Errorf("Expected 0 nodes at old position, got %d", len(result))
+	}
+
+	sh.Remove(a)
+
+	if result := sh.AtExact(10, 10); len(result) != 2 {
+		t.Errorf("Expected 2 nodes at (10, 10) after remove, got %d", len(result))
+	}
+
+	sh.RemoveWhere(func(n TestingNode) bool { return n.GetId() == 2 })
+
+	if result := sh.AtExact(10, 10); len(result) != 1 || result[0].GetId() != 3 {
+		t.Errorf("Expected only node 3 at (10, 10), got %v", result)
+	}
+
+	sh.Reset()
+
+	if result := sh.AtExact(10, 10); len(result) != 0 {
+		t.Errorf("Expected 0 nodes after reset, got %d", len(result))
+	}
+}
+
+func TestSpatialHashAtExactFallback(t *testing.T) {
+	sh := NewSpatialHash[int, float64](100)
+
+	sh.Put(newPoint(1, 10, 10))
+
+	if result := sh.AtExact(10, 10); len(result) != 1 {
+		t.Errorf("Expected 1 node without exact index, got %d", len(result))
+	}
+}
@@ -0,0 +1,133 @@
+package spatial_hash
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// VelocityNode is a node that reports its velocity, so that queries can extrapolate where it
+// will be. Nodes that do not implement it are treated as standing still.
+type VelocityNode[Id comparable, N Number] interface {
+	Node[Id, N]
+
+	// GetVelocity returns the velocity of the node, in units per unit of time.
+	GetVelocity() (vx, vy N)
+}
+
+// speedTracker tracks the fastest speed of the nodes put or updated during the current step
+// and the previous one, as the bits of float64 values.
+type speedTracker struct {
+	// max is the fastest speed of the current and previous steps, step of the current one.
+	max, step atomic.Uint64
+}
+
+// raise raises the fastest speeds to speed.
+func (t *speedTracker) raise(speed float64) {
+	raiseBits(&t.max, speed)
+	raiseBits(&t.step, speed)
+}
+
+// raiseBits raises the float64 whose bits v holds to f.
+func raiseBits(v *atomic.Uint64, f float64) {
+	for {
+		bits := v.Load()
+
+		if !(f > math.Float64frombits(bits)) || v.CompareAndSwap(bits, math.Float64bits(f)) {
+			return
+		}
+	}
+}
+
+// load returns the fastest speed of the current and previous steps.
+func (t *speedTracker) load() float64 {
+	return math.Float64frombits(t.max.Load())
+}
+
+// advance starts a new step, forgetting the speeds of the steps before the one ending.
+func (t *speedTracker) advance() {
+	t.max.Store(t.step.Swap(0))
+}
+
+// clear forgets every speed.
+func (t *speedTracker) clear() {
+	t.max.Store(0)
+	t.step.Store(0)
+}
+
+// SetExtrapolation enables SearchExtrapolated. Put and Update then track the fastest speed
+// of the nodes implementing VelocityNode, which bounds how far extrapolated positions can lie
+// from indexed ones.
+//
+// Speeds are forgotten by Reset and ResetSafe, and, when BeginStep is called every step, once
+// a full step has passed without a node being put or updated at them, so that a single burst
+// of speed, such as a teleport, does not widen extrapolated searches for good. Nodes that keep
+// their velocity must then be updated every step, or their extrapolated speed is capped.
+//
+// SetExtrapolation must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetExtrapolation(enabled bool) {
+	if enabled {
+		sh.maxSpeed = new(speedTracker)
+	} else {
+		sh.maxSpeed = nil
+	}
+}
+
+// trackSpeed raises the fastest speed seen to the speed of n.
+func (sh *SpatialHash[Id, N]) trackSpeed(n Node[Id, N]) {
+	v, ok := n.(VelocityNode[Id, N])
+	if !ok {
+		return
+	}
+
+	vx, vy := v.GetVelocity()
+
+	sh.maxSpeed.raise(math.Sqrt(distanceSq(float64(vx), float64(vy))))
+}
+
+// ExtrapolatedPos returns the position of n dt units of time from now, moving at its current
+// velocity. A speed above the fastest one tracked by Put and Update is capped to it, so that
+// SearchExtrapolated stays consistent with ExtrapolatedPos.
+func (sh *SpatialHash[Id, N]) ExtrapolatedPos(n Node[Id, N], dt float64) (x, y float64) {
+	x, y = float64(n.GetX()), float64(n.GetY())
+
+	v, ok := n.(VelocityNode[Id, N])
+	if !ok || sh.maxSpeed == nil {
+		return x, y
+	}
+
+	vx, vy := v.GetVelocity()
+	fvx, fvy := float64(vx), float64(vy)
+
+	maxSpeed := sh.maxSpeed.load()
+
+	if speed := math.Sqrt(fvx*fvx + fvy*fvy); speed > maxSpeed {
+		if speed == 0 || maxSpeed == 0 {
+			return x, y
+		}
+
+		fvx, fvy = fvx*maxSpeed/speed, fvy*maxSpeed/speed
+	}
+
+	return x + fvx*dt, y + fvy*dt
+}
+
+// SearchExtrapolated returns the nodes that will be within radius of (x, y) dt units of time from
+// now, at the positions ExtrapolatedPos returns for them. Latency-hiding clients can query the
+// world slightly ahead of the last update this way, with results consistent with the index.
+// Bounded nodes are returned if their extrapolated bounding box intersects the circle.
+// Without SetExtrapolation, it is Search.
+func (sh *SpatialHash[Id, N]) SearchExtrapolated(dt float64, x, y, radius N) NodeSlice[Id, N] {
+	if sh.maxSpeed == nil {
+		return sh.Search(x, y, radius)
+	}
+
+	if !(radius >= 0) {
+		return nil
+	}
+
+	reach := sh.maxSpeed.load() * math.Abs(dt)
+
+	return sh.searchDisplaced(x, y, radius, reach, func(n Node[Id, N]) (float64, float64) {
+		return sh.ExtrapolatedPos(n, dt)
+	})
+}
@@ -0,0 +1,106 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// MovingPoint is a point with a velocity.
+type MovingPoint struct {
+	*Point
+
+	vx, vy float64
+}
+
+func (p *MovingPoint) GetVelocity() (float64, float64) {
+	return p.vx, p.vy
+}
+
+func TestSpatialHashSearchExtrapolated(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetExtrapolation(true)
+
+	runner := &MovingPoint{newPoint(0, 0, 0), 20, 0}
+	post := newPoint(1, 30, 0)
+	sh.Put(runner)
+	sh.Put(post)
+
+	if found := sh.SearchExtrapolated(1.5, 30, 0, 1); len(found) != 2 {
+		t.Errorf("Expected the runner to reach the post, got %d nodes", len(found))
+	}
+
+	if found := sh.SearchExtrapolated(1.5, 0, 0, 1); len(found) != 0 {
+		t.Errorf("Expected the runner to have left, got %d nodes", len(found))
+	}
+
+	if found := sh.SearchExtrapolated(0, 0, 0, 1); len(found) != 1 {
+		t.Errorf("Expected no extrapolation for dt=0, got %d nodes", len(found))
+	}
+}
+
+func TestSpatialHashSearchExtrapolatedRandom(t *testing.T) {
+	sh := NewSpatialHash[int, float64](20)
+	sh.SetExtrapolation(true)
+
+	var nodes []*MovingPoint
+
+	for i := range 300 {
+		p := &MovingPoint{newPoint(i, 500*rand.Float64(), 500*rand.Float64()), 40*rand.Float64() - 20, 40*rand.Float64() - 20}
+
+		nodes = append(nodes, p)
+		sh.Put(p)
+	}
+
+	for range 50 {
+		dt := rand.Float64()
+		x, y, radius := 500*rand.Float64(), 500*rand.Float64(), 10+30*rand.Float64()
+
+		expected := 0
+
+		for _, n := range nodes {
+			if math.Hypot(n.x+n.vx*dt-x, n.y+n.vy*dt-y) <= radius {
+				expected++
+			}
+		}
+
+		if found := len(sh.SearchExtrapolated(dt, x, y, radius)); found != expected {
+			t.Fatalf("Expected %d nodes, got %d", expected, found)
+		}
+	}
+}
+
+func TestSpatialHashExtrapolationSpeedDecay(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetExtrapolation(true)
+
+	// Spawned with an impulse, then slowed down
+	p := &MovingPoint{newPoint(0, 0, 0), 1000, 0}
+	sh.Put(p)
+
+	probe := &MovingPoint{newPoint(1, 0, 0), 1000, 0}
+
+	for step := range 3 {
+		sh.BeginStep()
+
+		p.vx = 10
+		p.x += p.vx
+		sh.Update(p)
+
+		want := 10.0
+		if step == 0 {
+			// The impulse of the previous step is still remembered
+			want = 1000
+		}
+
+		if x, _ := sh.ExtrapolatedPos(probe, 1); x != want {
+			t.Errorf("Step %d: expected speeds capped at %v, got %v", step, want, x)
+		}
+	}
+
+	sh.Reset()
+
+	if x, _ := sh.ExtrapolatedPos(probe, 1); x != 0 {
+		t.Errorf("Expected no speed after a reset, got %v", x)
+	}
+}
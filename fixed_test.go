@@ -0,0 +1,107 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// FixedPoint is a node with fixed-point coordinates.
+type FixedPoint struct {
+	id int
+
+	x, y       Fixed
+	oldX, oldY Fixed
+}
+
+var _ Node[int, Fixed] = (*FixedPoint)(nil) // *FixedPoint must implement Node
+
+func (n *FixedPoint) GetId() int { return n.id }
+
+func (n *FixedPoint) GetX() Fixed { return n.x }
+func (n *FixedPoint) GetY() Fixed { return n.y }
+
+func (n *FixedPoint) SetOldPos(x, y Fixed)      { n.oldX, n.oldY = x, y }
+func (n *FixedPoint) GetOldPos() (Fixed, Fixed) { return n.oldX, n.oldY }
+
+func TestFixedConversion(t *testing.T) {
+	if f := FixedFromFloat(-1.5); f.Float64() != -1.5 || f.Floor() != -2 {
+		t.Errorf("Expected -1.5 flooring to -2, got %v flooring to %d", f, f.Floor())
+	}
+
+	if f := FixedFromInt(3).Mul(FixedFromFloat(0.5)); f != FixedFromFloat(1.5) {
+		t.Errorf("Expected 3 * 0.5 = 1.5, got %v", f)
+	}
+
+	if f := FixedFromInt(3).Div(FixedFromInt(4)); f != FixedFromFloat(0.75) {
+		t.Errorf("Expected 3 / 4 = 0.75, got %v", f)
+	}
+}
+
+func TestSpatialHashFixed(t *testing.T) {
+	const count = 2000
+
+	sh := NewSpatialHash[int](FixedFromInt(32))
+
+	nodes := make([]*FixedPoint, count)
+
+	// Spread across negative coordinates, where truncating division would pick the wrong cell
+	for i := range nodes {
+		x := FixedFromFloat(1000 * (rand.Float64() - 0.5))
+		y := FixedFromFloat(1000 * (rand.Float64() - 0.5))
+
+		nodes[i] = &FixedPoint{id: i, x: x, y: y, oldX: x, oldY: y}
+
+		sh.Put(nodes[i])
+	}
+
+	for range 1000 {
+		x := FixedFromFloat(1000 * (rand.Float64() - 0.5))
+		y := FixedFromFloat(1000 * (rand.Float64() - 0.5))
+		radius := FixedFromFloat(100 * rand.Float64())
+
+		expected := 0
+
+		for _, n := range nodes {
+			dx, dy := n.x-x, n.y-y
+
+			if dx*dx+dy*dy <= radius*radius {
+				expected++
+			}
+		}
+
+		if result := sh.Search(x, y, radius); len(result) != expected {
+			t.Fatalf("Search(%v, %v, %v): expected %d nodes, got %d", x, y, radius, expected, len(result))
+		}
+	}
+}
+
+func TestSpatialHashNegativeIntegerCoordinates(t *testing.T) {
+	sh := NewSpatialHash[int, int](10)
+
+	sh.Put(&IntPoint{id: 1, x: -5, y: -5})
+
+	// (-5, -5) lies in cell (-1, -1), so a query covering only cell (0, 0) must not return it
+	if result := sh.QueryRect(5, 5, 8, 8); len(result) != 0 {
+		t.Errorf("Expected 0 nodes in cell (0, 0), got %d", len(result))
+	}
+
+	if result := sh.Search(-8, -8, 5); len(result) != 1 {
+		t.Errorf("Expected 1 node, got %d", len(result))
+	}
+}
+
+// IntPoint is a node with integer coordinates.
+type IntPoint struct {
+	id int
+
+	x, y       int
+	oldX, oldY int
+}
+
+func (n *IntPoint) GetId() int { return n.id }
+
+func (n *IntPoint) GetX() int { return n.x }
+func (n *IntPoint) GetY() int { return n.y }
+
+func (n *IntPoint) SetOldPos(x, y int)    { n.oldX, n.oldY = x, y }
+func (n *IntPoint) GetOldPos() (int, int) { return n.oldX, n.oldY }
@@ -0,0 +1,168 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// cellFlow counts the nodes entering and leaving a cell over the last steps, one slot per step.
+type cellFlow struct {
+	// step is the latest step counted; slots of older steps wrapped around hold stale counts.
+	step uint64
+
+	arrivals, departures []int
+}
+
+// roll clears the slots of the steps after f.step, up to step.
+func (f *cellFlow) roll(step uint64) {
+	window := uint64(len(f.arrivals))
+
+	for s := f.step + 1; s <= step && s <= f.step+window; s++ {
+		f.arrivals[s%window] = 0
+		f.departures[s%window] = 0
+	}
+
+	f.step = max(f.step, step)
+}
+
+// flowTracker counts arrivals and departures per cell, for SetFlowWindow.
+type flowTracker struct {
+	window int
+
+	step atomic.Uint64
+
+	// cells holds the counts of every cell entered or left within the window.
+	// Counts are only read and written inside Compute, which serializes them per cell.
+	cells *xsync.Map[cell, *cellFlow]
+}
+
+// count adds arrivals and departures to the counts of c for the current step.
+func (ft *flowTracker) count(c cell, arrivals, departures int) {
+	step := ft.step.Load()
+
+	ft.cells.Compute(c, func(f *cellFlow, loaded bool) (*cellFlow, xsync.ComputeOp) {
+		if !loaded {
+			f = &cellFlow{step: step, arrivals: make([]int, ft.window), departures: make([]int, ft.window)}
+		}
+
+		f.roll(step)
+
+		slot := step % uint64(ft.window)
+		f.arrivals[slot] += arrivals
+		f.departures[slot] += departures
+
+		return f, xsync.UpdateOp
+	})
+}
+
+// move counts a node leaving cell from for cell to.
+func (ft *flowTracker) move(from, to cell) {
+	ft.count(from, 0, 1)
+	ft.count(to, 1, 0)
+}
+
+// advance starts a new step, dropping the cells nothing entered or left within the window.
+func (ft *flowTracker) advance() {
+	step := ft.step.Add(1)
+
+	ft.cells.Range(func(c cell, _ *cellFlow) bool {
+		ft.cells.Compute(c, func(f *cellFlow, loaded bool) (*cellFlow, xsync.ComputeOp) {
+			if !loaded || step-f.step >= uint64(ft.window) {
+				return nil, xsync.DeleteOp
+			}
+
+			return f, xsync.CancelOp
+		})
+
+		return true
+	})
+}
+
+// SetFlowWindow makes Put, Update and Remove count the nodes entering and leaving every cell over
+// the last window steps, as delimited by BeginStep, so that gameplay systems can detect population
+// trends, such as players converging on a point, without scanning. A window of zero disables
+// counting. It panics if window is negative.
+//
+// SetFlowWindow must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetFlowWindow(window int) {
+	if window < 0 {
+		panic(fmt.Sprintf("spatial_hash: invalid flow window %d", window))
+	}
+
+	if window == 0 {
+		sh.flow = nil
+
+		return
+	}
+
+	sh.flow = &flowTracker{window: window, cells: xsync.NewMap[cell, *cellFlow]()}
+}
+
+// CellFlow returns how many nodes entered and left cell (cx, cy) over the window set with
+// SetFlowWindow, the current step included. Nodes entering include the ones put, and nodes
+// leaving the ones removed. A bounded node counts in the cell holding its center.
+func (sh *SpatialHash[Id, N]) CellFlow(cx, cy int) (arrivals, departures int) {
+	if sh.flow == nil {
+		return 0, 0
+	}
+
+	step := sh.flow.step.Load()
+
+	sh.flow.cells.Compute(cell{cx, cy}, func(f *cellFlow, loaded bool) (*cellFlow, xsync.ComputeOp) {
+		if !loaded {
+			return nil, xsync.CancelOp
+		}
+
+		f.roll(step)
+
+		for i := range f.arrivals {
+			arrivals += f.arrivals[i]
+			departures += f.departures[i]
+		}
+
+		return f, xsync.CancelOp
+	})
+
+	return arrivals, departures
+}
+
+// RegionFlow returns how many nodes entered and left the cells overlapped by r over the window
+// set with SetFlowWindow, as CellFlow counts them. Nodes moving between two of the cells count
+// as both, so that arrivals minus departures is the net inflow into the region.
+func (sh *SpatialHash[Id, N]) RegionFlow(r Rect[N]) (arrivals, departures int) {
+	if sh.flow == nil {
+		return 0, 0
+	}
+
+	cells := sh.CellRectOf(r)
+
+	add := func(cx, cy int) {
+		a, d := sh.CellFlow(cx, cy)
+
+		arrivals += a
+		departures += d
+	}
+
+	// Regions larger than the set of counted cells are summed over the latter instead
+	if cells.Len() > sh.flow.cells.Size() {
+		sh.flow.cells.Range(func(c cell, _ *cellFlow) bool {
+			if cells.Contains(c.x, c.y) {
+				add(c.x, c.y)
+			}
+
+			return true
+		})
+
+		return arrivals, departures
+	}
+
+	for cy := cells.MinY; cy <= cells.MaxY; cy++ {
+		for cx := cells.MinX; cx <= cells.MaxX; cx++ {
+			add(cx, cy)
+		}
+	}
+
+	return arrivals, departures
+}
@@ -0,0 +1,90 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashCellFlow(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetFlowWindow(2)
+
+	a, b := newPoint(0, 5, 5), newPoint(1, 15, 5)
+	sh.Put(a)
+	sh.Put(b)
+
+	if arrivals, departures := sh.CellFlow(0, 0); arrivals != 1 || departures != 0 {
+		t.Errorf("Expected 1 arrival from Put, got %d arrivals and %d departures", arrivals, departures)
+	}
+
+	sh.BeginStep()
+
+	// Both converge on cell (2, 0)
+	a.x, b.x = 25, 25
+	sh.Update(a)
+	sh.Update(b)
+
+	if arrivals, departures := sh.CellFlow(2, 0); arrivals != 2 || departures != 0 {
+		t.Errorf("Expected 2 arrivals, got %d arrivals and %d departures", arrivals, departures)
+	}
+
+	if arrivals, departures := sh.CellFlow(0, 0); arrivals != 1 || departures != 1 {
+		t.Errorf("Expected 1 arrival and 1 departure within the window, got %d and %d", arrivals, departures)
+	}
+
+	// Moves within the region cancel out
+	if arrivals, departures := sh.RegionFlow(Rect[float64]{0, 0, 29, 9}); arrivals-departures != 2 {
+		t.Errorf("Expected a net inflow of 2, got %d arrivals and %d departures", arrivals, departures)
+	}
+
+	// The puts slide out of the window
+	sh.BeginStep()
+
+	if arrivals, departures := sh.CellFlow(0, 0); arrivals != 0 || departures != 1 {
+		t.Errorf("Expected only the departure left, got %d arrivals and %d departures", arrivals, departures)
+	}
+
+	sh.Remove(a)
+
+	if _, departures := sh.CellFlow(2, 0); departures != 1 {
+		t.Errorf("Expected 1 departure from Remove, got %d", departures)
+	}
+
+	sh.BeginStep()
+	sh.BeginStep()
+
+	if arrivals, departures := sh.CellFlow(2, 0); arrivals != 0 || departures != 0 {
+		t.Errorf("Expected no flow once the window passed, got %d arrivals and %d departures", arrivals, departures)
+	}
+}
+
+func TestSpatialHashCellFlowBounded(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetFlowWindow(1)
+	sh.SetHysteresis(0.5)
+
+	box := newBoxPoint(0, 5, 5, 8, 8)
+	sh.PutBounded(box)
+
+	box.x = 15
+	sh.UpdateBounded(box)
+
+	if arrivals, _ := sh.CellFlow(1, 0); arrivals != 1 {
+		t.Errorf("Expected the bounded node to arrive where its center is, got %d", arrivals)
+	}
+
+	p := newPoint(1, 5, 5)
+	sh.Put(p)
+
+	// Hysteresis keeps the node in its cell
+	p.x = 12
+	sh.Update(p)
+
+	if arrivals, _ := sh.CellFlow(1, 0); arrivals != 1 {
+		t.Errorf("Expected no arrival within the dead zone, got %d", arrivals)
+	}
+
+	p.x = 16
+	sh.Update(p)
+
+	if arrivals, _ := sh.CellFlow(1, 0); arrivals != 2 {
+		t.Errorf("Expected the node to arrive once migrated, got %d", arrivals)
+	}
+}
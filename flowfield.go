@@ -0,0 +1,207 @@
+package spatial_hash
+
+import (
+	"container/heap"
+	"math"
+)
+
+// CellCost returns the cost of entering cell (cx, cy).
+// A negative or infinite cost marks the cell as impassable.
+type CellCost func(cx, cy int) float32
+
+// OccupancyCost returns a cost function that charges base for every cell, plus penalty for
+// cells set in occupied, so that flows route around crowded cells. A penalty of +Inf makes
+// occupied cells impassable.
+func OccupancyCost(occupied *CellBitset, base, penalty float32) CellCost {
+	return func(cx, cy int) float32 {
+		if occupied.Has(cx, cy) {
+			return base + penalty
+		}
+
+		return base
+	}
+}
+
+// FlowField is a direction field over a range of cells, pointing every cell towards
+// the neighbor on its cheapest path to a target.
+type FlowField struct {
+	// Cells is the range of cells covered by the field.
+	Cells CellRect
+
+	// costs holds the cost of reaching the target from each cell, in row-major order.
+	costs []float32
+	// dirs holds the direction of each cell, packed as (dx+1)*3 + (dy+1).
+	dirs []uint8
+}
+
+// noDirection marks a cell without a direction: the target itself, or an unreachable cell.
+const noDirection = 4 // (0+1)*3 + (0+1)
+
+// Direction returns the step, each component -1, 0 or 1, leading from cell (cx, cy)
+// towards the target. ok is false for the target cell, unreachable cells and cells
+// outside the field.
+func (f *FlowField) Direction(cx, cy int) (dx, dy int, ok bool) {
+	if !f.Cells.Contains(cx, cy) {
+		return 0, 0, false
+	}
+
+	dir := f.dirs[f.Cells.Index(cx, cy)]
+	if dir == noDirection {
+		return 0, 0, false
+	}
+
+	return int(dir/3) - 1, int(dir%3) - 1, true
+}
+
+// Cost returns the cost of reaching the target from cell (cx, cy),
+// or +Inf if the target is unreachable or the cell lies outside the field.
+func (f *FlowField) Cost(cx, cy int) float32 {
+	if !f.Cells.Contains(cx, cy) {
+		return float32(math.Inf(1))
+	}
+
+	return f.costs[f.Cells.Index(cx, cy)]
+}
+
+// flowNeighbors are the steps to the eight neighbors of a cell.
+var flowNeighbors = [8][2]int{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{1, 1}, {1, -1}, {-1, 1}, {-1, -1},
+}
+
+// FlowField computes a flow field over the cells overlapped by rect, leading towards
+// the cell containing (targetX, targetY).
+//
+// Moving into a cell costs cost(cx, cy), scaled by √2 for diagonal steps; a nil cost
+// charges 1 per cell. Cells marked with SetCellBlocked and cells with a negative or
+// infinite cost are impassable, and diagonal steps may not cut the corner of an
+// impassable cell. Combine with OccupancyCost to steer around occupied cells.
+func (sh *SpatialHash[Id, N]) FlowField(targetX, targetY N, rect Rect[N], cost CellCost) *FlowField {
+	cells := sh.CellRectOf(rect)
+
+	f := &FlowField{
+		Cells: cells,
+
+		costs: make([]float32, cells.Len()),
+		dirs:  make([]uint8, cells.Len()),
+	}
+
+	inf := float32(math.Inf(1))
+
+	for i := range f.costs {
+		f.costs[i] = inf
+		f.dirs[i] = noDirection
+	}
+
+	// Cost of entering each cell, computed lazily
+	enter := make([]float32, cells.Len())
+	for i := range enter {
+		enter[i] = -1
+	}
+
+	passable := func(cx, cy int) (float32, bool) {
+		i := cells.Index(cx, cy)
+
+		if enter[i] < 0 {
+			c := float32(1)
+
+			if sh.IsCellBlocked(cx, cy) {
+				c = inf
+			} else if cost != nil {
+				if c = cost(cx, cy); c < 0 {
+					c = inf
+				}
+			}
+
+			enter[i] = c
+		}
+
+		return enter[i], !math.IsInf(float64(enter[i]), 1)
+	}
+
+	tx, ty := sh.CellOf(targetX, targetY)
+	if !cells.Contains(tx, ty) {
+		return f
+	}
+
+	if _, ok := passable(tx, ty); !ok {
+		return f
+	}
+
+	// Dijkstra outwards from the target, so each cell learns its cost to reach it
+	f.costs[cells.Index(tx, ty)] = 0
+
+	queue := &cellQueue{{cells.Index(tx, ty), 0}}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(cellQueueItem)
+
+		if item.cost > f.costs[item.index] {
+			continue // Stale entry
+		}
+
+		cx, cy := cells.Cell(item.index)
+
+		for _, step := range flowNeighbors {
+			nx, ny := cx+step[0], cy+step[1]
+			if !cells.Contains(nx, ny) {
+				continue
+			}
+
+			// The neighbor moves into the current cell
+			c, ok := passable(cx, cy)
+			if !ok {
+				continue
+			}
+
+			if _, ok := passable(nx, ny); !ok {
+				continue
+			}
+
+			if step[0] != 0 && step[1] != 0 {
+				_, okX := passable(nx, cy)
+				_, okY := passable(cx, ny)
+
+				if !okX || !okY {
+					continue // Corner cutting
+				}
+
+				c *= math.Sqrt2
+			}
+
+			ni := cells.Index(nx, ny)
+
+			if total := item.cost + c; total < f.costs[ni] {
+				f.costs[ni] = total
+				f.dirs[ni] = uint8((-step[0]+1)*3 + (-step[1] + 1))
+
+				heap.Push(queue, cellQueueItem{ni, total})
+			}
+		}
+	}
+
+	return f
+}
+
+// cellQueueItem is a cell waiting in a cellQueue.
+type cellQueueItem struct {
+	index int
+	cost  float32
+}
+
+// cellQueue is a min-heap of cells ordered by cost.
+type cellQueue []cellQueueItem
+
+func (q cellQueue) Len() int           { return len(q) }
+func (q cellQueue) Less(i, j int) bool { return q[i].cost < q[j].cost }
+func (q cellQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *cellQueue) Push(x any) { *q = append(*q, x.(cellQueueItem)) }
+
+func (q *cellQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return item
+}
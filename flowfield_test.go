@@ -0,0 +1,97 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+// followFlow follows the flow field from cell (cx, cy) and returns the cells visited,
+// stopping at the first cell without a direction.
+func followFlow(t *testing.T, f *FlowField, cx, cy int) [][2]int {
+	t.Helper()
+
+	path := [][2]int{{cx, cy}}
+
+	for range f.Cells.Len() {
+		dx, dy, ok := f.Direction(cx, cy)
+		if !ok {
+			return path
+		}
+
+		cx, cy = cx+dx, cy+dy
+
+		path = append(path, [2]int{cx, cy})
+	}
+
+	t.Fatalf("Flow field loops from %v", path[0])
+
+	return nil
+}
+
+func TestSpatialHashFlowField(t *testing.T) {
+	sh := NewSpatialHash[int, float64](1)
+
+	// Wall at column 5 with a gap at row 9
+	for cy := range 9 {
+		sh.SetCellBlocked(5, cy, true)
+	}
+
+	rect := Rect[float64]{MinX: 0, MinY: 0, MaxX: 9.5, MaxY: 9.5}
+
+	f := sh.FlowField(9.5, 0.5, rect, nil)
+
+	path := followFlow(t, f, 0, 0)
+
+	if last := path[len(path)-1]; last != [2]int{9, 0} {
+		t.Fatalf("Expected flow to end at target (9, 0), ended at %v", last)
+	}
+
+	passedGap := false
+
+	for _, c := range path {
+		if sh.IsCellBlocked(c[0], c[1]) {
+			t.Fatalf("Flow passes through blocked cell %v", c)
+		}
+
+		if c == [2]int{5, 9} {
+			passedGap = true
+		}
+	}
+
+	if !passedGap {
+		t.Error("Expected flow to pass through the gap at (5, 9)")
+	}
+
+	if _, _, ok := f.Direction(5, 0); ok {
+		t.Error("Expected no direction in a blocked cell")
+	}
+
+	if cost := f.Cost(9, 0); cost != 0 {
+		t.Errorf("Expected zero cost at target, got %v", cost)
+	}
+}
+
+func TestSpatialHashFlowFieldOccupancy(t *testing.T) {
+	sh := NewSpatialHash[int, float64](1)
+
+	// A column of nodes at x=2, except for row 4
+	for cy := range 4 {
+		sh.Put(newPoint(cy, 2.5, float64(cy)+0.5))
+	}
+
+	rect := Rect[float64]{MinX: 0, MinY: 0, MaxX: 4.5, MaxY: 4.5}
+
+	occupied := sh.OccupancyBits(rect, nil)
+
+	f := sh.FlowField(4.5, 0.5, rect, OccupancyCost(occupied, 1, float32(math.Inf(1))))
+
+	for _, c := range followFlow(t, f, 0, 0) {
+		if occupied.Has(c[0], c[1]) {
+			t.Fatalf("Flow passes through occupied cell %v", c)
+		}
+	}
+
+	if cost := f.Cost(0, 0); math.IsInf(float64(cost), 1) {
+		t.Error("Expected target to be reachable around the occupied cells")
+	}
+}
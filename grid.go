@@ -0,0 +1,93 @@
+package spatial_hash
+
+// Rect is an axis-aligned rectangle in world coordinates, with inclusive bounds.
+type Rect[N Number] struct {
+	MinX, MinY N
+	MaxX, MaxY N
+}
+
+// Contains reports whether (x, y) lies inside the rectangle.
+func (r Rect[N]) Contains(x, y N) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+// CellRect is a rectangular range of cells, with inclusive bounds.
+type CellRect struct {
+	MinX, MinY int
+	MaxX, MaxY int
+}
+
+// Width returns the number of cell columns in the range.
+func (r CellRect) Width() int {
+	return max(r.MaxX-r.MinX+1, 0)
+}
+
+// Height returns the number of cell rows in the range.
+func (r CellRect) Height() int {
+	return max(r.MaxY-r.MinY+1, 0)
+}
+
+// Len returns the number of cells in the range.
+func (r CellRect) Len() int {
+	return r.Width() * r.Height()
+}
+
+// Contains reports whether cell (cx, cy) lies inside the range.
+func (r CellRect) Contains(cx, cy int) bool {
+	return cx >= r.MinX && cx <= r.MaxX && cy >= r.MinY && cy <= r.MaxY
+}
+
+// Index returns the row-major index of cell (cx, cy) within the range.
+// The cell must lie inside the range.
+func (r CellRect) Index(cx, cy int) int {
+	return (cy-r.MinY)*r.Width() + (cx - r.MinX)
+}
+
+// Cell returns the cell at row-major index i within the range.
+func (r CellRect) Cell(i int) (cx, cy int) {
+	w := r.Width()
+
+	return r.MinX + i%w, r.MinY + i/w
+}
+
+// CellSize returns the cell size of the spatial hash.
+func (sh *SpatialHash[Id, N]) CellSize() N {
+	return sh.cellSize
+}
+
+// CellOf returns the cell containing (x, y).
+func (sh *SpatialHash[Id, N]) CellOf(x, y N) (cx, cy int) {
+	return sh.cellCoord(sh.Quantize(x)), sh.cellCoord(sh.Quantize(y))
+}
+
+// cellAt returns the cell containing (x, y).
+func (sh *SpatialHash[Id, N]) cellAt(x, y N) cell {
+	cx, cy := sh.CellOf(x, y)
+
+	return cell{cx, cy}
+}
+
+// CellRectOf returns the range of cells overlapped by r.
+func (sh *SpatialHash[Id, N]) CellRectOf(r Rect[N]) CellRect {
+	minX, minY := sh.CellOf(r.MinX, r.MinY)
+	maxX, maxY := sh.CellOf(r.MaxX, r.MaxY)
+
+	return CellRect{minX, minY, maxX, maxY}
+}
+
+// CellBounds returns the world-space rectangle covered by cell (cx, cy).
+func (sh *SpatialHash[Id, N]) CellBounds(cx, cy int) Rect[N] {
+	cellSize := sh.cellSize
+
+	return Rect[N]{
+		MinX: N(cx) * cellSize,
+		MinY: N(cy) * cellSize,
+		MaxX: N(cx+1) * cellSize,
+		MaxY: N(cy+1) * cellSize,
+	}
+}
+
+// cellBucket returns the bucket of cell (cx, cy), if it exists.
+func (sh *SpatialHash[Id, N]) cellBucket(cx, cy int) (*bucket[Id, N], bool) {
+	return sh.buckets.Load(cell{cx, cy})
+}
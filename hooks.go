@@ -0,0 +1,87 @@
+package spatial_hash
+
+import "sync"
+
+// Operation is a mutation of the spatial hash, reported to hooks.
+type Operation int
+
+const (
+	// OperationPut is a node put with Put or PutBounded.
+	OperationPut Operation = iota
+	// OperationUpdate is a node updated with Update, UpdateBounded, UpdateMoved or UpdateAll.
+	OperationUpdate
+	// OperationRemove is a node removed with Remove, RemoveBounded or RemoveWhere.
+	OperationRemove
+	// OperationReset is every node removed with Reset or ResetSafe. The node is nil.
+	OperationReset
+)
+
+func (op Operation) String() string {
+	switch op {
+	case OperationPut:
+		return "put"
+	case OperationUpdate:
+		return "update"
+	case OperationRemove:
+		return "remove"
+	case OperationReset:
+		return "reset"
+	default:
+		return "unknown"
+	}
+}
+
+// Hooks are called around every mutation of the spatial hash. Either may be nil.
+type Hooks[Id comparable, N Number] struct {
+	// Before is called before op is applied to n.
+	Before func(op Operation, n Node[Id, N])
+	// After is called once op has been applied to n.
+	After func(op Operation, n Node[Id, N])
+}
+
+// hookState runs hooks around mutations, one mutation at a time.
+type hookState[Id comparable, N Number] struct {
+	Hooks[Id, N]
+
+	mu sync.Mutex
+}
+
+// SetHooks makes every mutation call hooks before and after it is applied, so that sidecar
+// indexes, such as lookups by name or team rosters, stay in sync with the spatial hash.
+// Mutations are then applied one at a time, each with its hooks: hooks see them in exactly
+// the order they were applied, so a log of them replays to the same state. Queries still run
+// concurrently. Hooks must not call methods of the spatial hash.
+//
+// SetHooks must be called before the spatial hash is used.
+func (sh *SpatialHash[Id, N]) SetHooks(hooks Hooks[Id, N]) {
+	if hooks.Before == nil && hooks.After == nil {
+		sh.hooks = nil
+
+		return
+	}
+
+	sh.hooks = &hookState[Id, N]{Hooks: hooks}
+}
+
+// hooked applies a mutation, calling the hooks around it if set.
+func (sh *SpatialHash[Id, N]) hooked(op Operation, n Node[Id, N], apply func()) {
+	h := sh.hooks
+	if h == nil {
+		apply()
+
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.Before != nil {
+		h.Before(op, n)
+	}
+
+	apply()
+
+	if h.After != nil {
+		h.After(op, n)
+	}
+}
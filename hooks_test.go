@@ -0,0 +1,102 @@
+package spatial_hash
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSpatialHashHooks(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// A sidecar index of every node, and the operations seen
+	sidecar := make(map[int]Node[int, float64])
+
+	var (
+		ops    []Operation
+		inside bool
+	)
+
+	sh.SetHooks(Hooks[int, float64]{
+		Before: func(op Operation, n Node[int, float64]) {
+			if inside {
+				t.Errorf("Expected %v to start after the previous operation ended", op)
+			}
+
+			inside = true
+		},
+		After: func(op Operation, n Node[int, float64]) {
+			inside = false
+
+			ops = append(ops, op)
+
+			switch op {
+			case OperationPut:
+				sidecar[n.GetId()] = n
+			case OperationRemove:
+				delete(sidecar, n.GetId())
+			case OperationReset:
+				clear(sidecar)
+			}
+		},
+	})
+
+	nodes := CreateTestNodes(400, 200, 200)
+
+	var wg sync.WaitGroup
+
+	for w := range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for _, n := range nodes[w*100 : (w+1)*100] {
+				sh.Put(n)
+
+				n.x += 5
+				sh.Update(n)
+
+				if n.id%3 == 0 {
+					sh.Remove(n)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	box := newBoxPoint(1000, 50, 50, 20, 20)
+	sh.PutBounded(box)
+
+	removed := sh.RemoveWhere(func(n Node[int, float64]) bool { return n.GetId()%3 == 1 })
+
+	expected := 0
+
+	sh.buckets.Range(func(_ cell, b *bucket[int, float64]) bool {
+		b.ForEach(func(id int, _ Node[int, float64]) bool {
+			if _, ok := sidecar[id]; !ok {
+				t.Errorf("Node %d missing from the sidecar", id)
+			}
+
+			expected++
+
+			return true
+		})
+
+		return true
+	})
+
+	if len(sidecar) != expected {
+		t.Errorf("Expected %d nodes in the sidecar, got %d", expected, len(sidecar))
+	}
+
+	if len(ops) != 400+400+134+1+removed {
+		t.Errorf("Expected an operation per mutation, got %d", len(ops))
+	}
+
+	sh.Reset()
+
+	if len(sidecar) != 0 || ops[len(ops)-1] != OperationReset {
+		t.Error("Expected Reset to clear the sidecar")
+	}
+}
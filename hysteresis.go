@@ -0,0 +1,122 @@
+package spatial_hash
+
+import (
+	"fmt"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// SetHysteresis makes Update keep a node in its current cell until it has moved more than
+// fraction of a cell beyond the cell's boundary, instead of migrating it as soon as it crosses
+// the boundary. Nodes sliding along a cell boundary then stop being moved between buckets on
+// every tick. A fraction of zero disables hysteresis. It panics if fraction is negative or not
+// below 1.
+//
+// Queries that check positions, such as Search, stay exact: they scan the extra margin of cells
+// a node may be kept in. Queries returning whole cells, such as QueryRect and the grid analysis
+// functions, see nodes in the cell they are kept in, which may be a neighbor of their position's.
+// Bounded nodes are not affected.
+//
+// SetHysteresis must be called before any node is put, and before shapes are compiled.
+func (sh *SpatialHash[Id, N]) SetHysteresis(fraction float64) {
+	if !(fraction >= 0 && fraction < 1) {
+		panic(fmt.Sprintf("spatial_hash: invalid hysteresis fraction %v", fraction))
+	}
+
+	if fraction == 0 {
+		sh.hysteresis = 0
+		sh.placed = nil
+
+		return
+	}
+
+	sh.hysteresis = N(fraction * float64(sh.cellSize))
+	sh.placed = xsync.NewMap[Id, cell]()
+}
+
+// place records that n is stored in cell c, if hysteresis is enabled.
+func (sh *SpatialHash[Id, N]) place(n Node[Id, N], c cell) {
+	if sh.placed != nil {
+		sh.placed.Store(n.GetId(), c)
+	}
+}
+
+// placedCell returns the cell n is stored in, given (x, y), the position it was last put or
+// updated at.
+func (sh *SpatialHash[Id, N]) placedCell(n Node[Id, N], x, y N) cell {
+	if sh.placed != nil {
+		if c, ok := sh.placed.Load(n.GetId()); ok {
+			return c
+		}
+	}
+
+	return sh.cellAt(x, y)
+}
+
+// migrate moves n from the cell it is stored in to the cell of (x, y), its new position, unless
+// hysteresis keeps it in place.
+func (sh *SpatialHash[Id, N]) migrate(n Node[Id, N], x, y N) {
+	to := sh.cellAt(x, y)
+
+	from, ok := sh.placed.Load(n.GetId())
+	if !ok {
+		from = sh.cellAt(n.GetOldPos())
+	}
+
+	if from == to || sh.withinDeadZone(from, sh.Quantize(x), sh.Quantize(y)) {
+		return
+	}
+
+	if bucket, ok := sh.buckets.Load(from); ok {
+		bucket.Delete(n)
+	}
+
+	sh.loadOrCreateBucket(to).Add(n)
+
+	sh.placed.Store(n.GetId(), to)
+
+	sh.cellEvent(CellRemoved, from, n)
+	sh.cellEvent(CellAdded, to, n)
+
+	if sh.flow != nil {
+		sh.flow.move(from, to)
+	}
+}
+
+// withinDeadZone reports whether (x, y) lies within the hysteresis margin around cell c.
+func (sh *SpatialHash[Id, N]) withinDeadZone(c cell, x, y N) bool {
+	minX, minY := N(c.x)*sh.cellSize-sh.hysteresis, N(c.y)*sh.cellSize-sh.hysteresis
+	maxX, maxY := N(c.x+1)*sh.cellSize+sh.hysteresis, N(c.y+1)*sh.cellSize+sh.hysteresis
+
+	return x >= minX && x < maxX && y >= minY && y < maxY
+}
+
+// queryCells returns the cells that may store nodes positioned within halfWidth and halfHeight
+// of (x, y), which reach past the extents by the hysteresis margin.
+func (sh *SpatialHash[Id, N]) queryCells(x, y, halfWidth, halfHeight N) (minX, minY, maxX, maxY int) {
+	halfWidth += sh.hysteresis
+	halfHeight += sh.hysteresis
+
+	return sh.clampCellRange(
+		sh.cellCoord(x-halfWidth), sh.cellCoord(y-halfHeight),
+		sh.cellCoord(x+halfWidth), sh.cellCoord(y+halfHeight),
+	)
+}
+
+// rectCells returns the cells that may store nodes positioned inside r.
+func (sh *SpatialHash[Id, N]) rectCells(r Rect[N]) CellRect {
+	margin := sh.hysteresis
+
+	return sh.CellRectOf(Rect[N]{MinX: r.MinX - margin, MinY: r.MinY - margin, MaxX: r.MaxX + margin, MaxY: r.MaxY + margin})
+}
+
+// cellBounds returns the bounds, relative to (fx, fy), of the area the nodes stored in cell
+// (xx, yy) may lie in, up to the hysteresis margin outside it, to be passed to Shape.Classify.
+// Shapes are classified at the call site rather than passed in, so that concrete ones such as
+// the circle of Search are not boxed into an interface.
+func (sh *SpatialHash[Id, N]) cellBounds(xx, yy int, fx, fy float64) (minX, minY, maxX, maxY float64) {
+	cellSize, margin := float64(sh.cellSize), float64(sh.hysteresis)
+
+	return float64(xx)*cellSize - fx - margin, float64(yy)*cellSize - fy - margin,
+		float64(xx+1)*cellSize - fx + margin, float64(yy+1)*cellSize - fy + margin
+}
@@ -0,0 +1,115 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// storedIn reports whether n is stored in cell (cx, cy).
+func storedIn(sh *SpatialHash[int, float64], n *Point, cx, cy int) bool {
+	b, ok := sh.cellBucket(cx, cy)
+	if !ok {
+		return false
+	}
+
+	_, ok = b.nodes.Load(n.id)
+
+	return ok
+}
+
+func TestSpatialHashHysteresisDeadZone(t *testing.T) {
+	sh := NewSpatialHash[int, float64](50)
+	sh.SetHysteresis(0.1)
+
+	n := newPoint(0, 45, 25)
+	sh.Put(n)
+
+	// Sliding along the boundary between cells 0 and 1 keeps the node in cell 0
+	for _, x := range []float64{49, 51, 54, 48, 52} {
+		n.x = x
+		sh.Update(n)
+
+		if !storedIn(sh, n, 0, 0) || storedIn(sh, n, 1, 0) {
+			t.Fatalf("Expected the node at x=%v to stay in cell 0", x)
+		}
+
+		if found := sh.Search(x, 25, 0.5); len(found) != 1 {
+			t.Fatalf("Expected Search to find the node at x=%v, got %d nodes", x, len(found))
+		}
+
+		if found := sh.AtPosition(x, 25); len(found) != 1 {
+			t.Fatalf("Expected AtPosition to find the node at x=%v, got %d nodes", x, len(found))
+		}
+	}
+
+	// Moving past the dead zone migrates it
+	n.x = 56
+	sh.Update(n)
+
+	if storedIn(sh, n, 0, 0) || !storedIn(sh, n, 1, 0) {
+		t.Fatal("Expected the node to migrate to cell 1")
+	}
+
+	// It then stays in cell 1 when crossing back slightly
+	n.x = 47
+	sh.Update(n)
+
+	if !storedIn(sh, n, 1, 0) {
+		t.Fatal("Expected the node to stay in cell 1")
+	}
+
+	sh.Remove(n)
+
+	if storedIn(sh, n, 1, 0) || len(sh.Search(47, 25, 10)) != 0 {
+		t.Error("Expected Remove to delete the node from the cell it is kept in")
+	}
+}
+
+func TestSpatialHashHysteresisQueries(t *testing.T) {
+	sh := NewSpatialHash[int, float64](20)
+	sh.SetHysteresis(0.4)
+
+	nodes := CreateTestNodes(500, 400, 400)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	ellipse := Ellipse[float64]{RadiusX: 30, RadiusY: 12, Angle: 0.5}
+	compiled := sh.CompileShape(ellipse)
+
+	for range 20 {
+		for _, n := range nodes {
+			n.x += 10*rand.Float64() - 5
+			n.y += 10*rand.Float64() - 5
+
+			sh.Update(n)
+		}
+
+		for range 20 {
+			x, y, radius := 400*rand.Float64(), 400*rand.Float64(), 5+40*rand.Float64()
+
+			if expected, found := len(NaiveSearch(nodes, x, y, radius)), len(sh.Search(x, y, radius)); found != expected {
+				t.Fatalf("Search: expected %d nodes, got %d", expected, found)
+			}
+
+			checkKNearest(t, sh, nodes, x, y, 5, radius)
+
+			expected := 0
+
+			for _, n := range nodes {
+				if ellipse.Contains(n.x-x, n.y-y) {
+					expected++
+				}
+			}
+
+			if found := len(sh.QueryEllipse(x, y, ellipse.RadiusX, ellipse.RadiusY, ellipse.Angle)); found != expected {
+				t.Fatalf("QueryEllipse: expected %d nodes, got %d", expected, found)
+			}
+
+			if found := len(sh.QueryCompiled(compiled, x, y)); found != expected {
+				t.Fatalf("QueryCompiled: expected %d nodes, got %d", expected, found)
+			}
+		}
+	}
+}
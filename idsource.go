@@ -0,0 +1,175 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// idSlot is the state of one id index.
+type idSlot struct {
+	// generation is the generation of the id currently or last issued for the index.
+	generation uint32
+	// live is whether that id has not been released yet.
+	live bool
+}
+
+// IdSource allocates unique integer ids, recycling released ones. Each id packs an index,
+// which is reused, with a generation, which is bumped on every reuse, so that an id kept
+// after its release never matches the id reissued for the same index. IdSource is safe for
+// concurrent use.
+type IdSource[Id constraints.Integer] struct {
+	mu sync.RWMutex
+
+	slots []idSlot
+	// free holds the released indices available for reuse.
+	free []int
+
+	indexBits      int
+	generationBits int
+}
+
+// idBits returns the number of non-sign bits of Id.
+func idBits[Id constraints.Integer]() int {
+	bits := 0
+
+	for v := Id(1); v > 0; v <<= 1 {
+		bits++
+	}
+
+	return bits
+}
+
+// NewIdSource creates an id source reserving a quarter of the bits of Id for the generation,
+// and the rest for the index: an int64 id has 15 generation bits and 48 index bits.
+func NewIdSource[Id constraints.Integer]() *IdSource[Id] {
+	return NewIdSourceWithGenerationBits[Id](min(idBits[Id]()/4, 32))
+}
+
+// NewIdSourceWithGenerationBits creates an id source reserving generationBits bits of Id for
+// the generation. An index whose generation is exhausted is retired instead of being reused.
+// It panics if generationBits is negative, above 32, or leaves no bits for the index.
+func NewIdSourceWithGenerationBits[Id constraints.Integer](generationBits int) *IdSource[Id] {
+	bits := idBits[Id]()
+
+	if generationBits < 0 || generationBits > 32 || generationBits >= bits {
+		panic(fmt.Sprintf("spatial_hash: invalid id generation bits %d", generationBits))
+	}
+
+	return &IdSource[Id]{
+		indexBits:      bits - generationBits,
+		generationBits: generationBits,
+	}
+}
+
+// Next allocates an id, reusing a released index if one is available.
+// It panics if every index is in use or retired.
+func (s *IdSource[Id]) Next() Id {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.free); n > 0 {
+		index := s.free[n-1]
+		s.free = s.free[:n-1]
+
+		slot := &s.slots[index]
+		slot.generation++
+		slot.live = true
+
+		return s.pack(index, slot.generation)
+	}
+
+	index := len(s.slots)
+	if s.indexBits < 64 && uint64(index) >= 1<<s.indexBits {
+		panic("spatial_hash: id source exhausted")
+	}
+
+	s.slots = append(s.slots, idSlot{live: true})
+
+	return s.pack(index, 0)
+}
+
+// Release returns id to the source for reuse. It returns false, changing nothing, if id is
+// not live: already released, never issued, or stale from an earlier generation.
+func (s *IdSource[Id]) Release(id Id) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, generation := s.unpack(id)
+
+	if !s.alive(index, generation) {
+		return false
+	}
+
+	slot := &s.slots[index]
+	slot.live = false
+
+	if uint64(slot.generation) < 1<<s.generationBits-1 {
+		s.free = append(s.free, index)
+	}
+
+	return true
+}
+
+// Alive reports whether id was issued by the source and has not been released.
+func (s *IdSource[Id]) Alive(id Id) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index, generation := s.unpack(id)
+
+	return s.alive(index, generation)
+}
+
+// Index returns the index packed in id, which is below the number of ids ever allocated at
+// once, so it can address dense per-entity arrays.
+func (s *IdSource[Id]) Index(id Id) int {
+	index, _ := s.unpack(id)
+
+	return index
+}
+
+// Generation returns the generation packed in id.
+func (s *IdSource[Id]) Generation(id Id) uint32 {
+	_, generation := s.unpack(id)
+
+	return generation
+}
+
+func (s *IdSource[Id]) alive(index int, generation uint32) bool {
+	if index < 0 || index >= len(s.slots) {
+		return false
+	}
+
+	slot := s.slots[index]
+
+	return slot.live && slot.generation == generation
+}
+
+func (s *IdSource[Id]) pack(index int, generation uint32) Id {
+	return Id(uint64(generation)<<s.indexBits | uint64(index))
+}
+
+func (s *IdSource[Id]) unpack(id Id) (index int, generation uint32) {
+	v := uint64(id)
+
+	return int(v & (1<<s.indexBits - 1)), uint32(v >> s.indexBits)
+}
+
+// SetIdValidator makes Put and PutBounded panic when valid returns false for the id of the
+// node being put. Pass the Alive method of an IdSource to catch nodes put with an id that was
+// released, whose index may already be reissued to another node. A nil valid disables the check.
+//
+// valid must not call methods of the spatial hash.
+// SetIdValidator must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetIdValidator(valid func(id Id) bool) {
+	sh.validId = valid
+}
+
+// checkId panics if the id validator rejects the id of n.
+func (sh *SpatialHash[Id, N]) checkId(n Node[Id, N]) {
+	if sh.validId != nil && !sh.validId(n.GetId()) {
+		panic(fmt.Sprintf("spatial_hash: node put with invalid id %v", n.GetId()))
+	}
+}
@@ -0,0 +1,131 @@
+package spatial_hash
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestIdSource(t *testing.T) {
+	ids := NewIdSource[int64]()
+
+	a := ids.Next()
+	b := ids.Next()
+
+	if a == b {
+		t.Fatal("Expected distinct ids")
+	}
+
+	if !ids.Release(a) {
+		t.Fatal("Expected a live id to be released")
+	}
+
+	if ids.Release(a) {
+		t.Error("Expected a double release to be reported")
+	}
+
+	c := ids.Next()
+
+	if ids.Index(c) != ids.Index(a) {
+		t.Errorf("Expected index %d to be recycled, got %d", ids.Index(a), ids.Index(c))
+	}
+
+	if c == a || ids.Generation(c) != ids.Generation(a)+1 {
+		t.Errorf("Expected the recycled id to have the next generation, got %d", ids.Generation(c))
+	}
+
+	if ids.Alive(a) {
+		t.Error("Expected the stale id not to be alive")
+	}
+
+	if !ids.Alive(b) || !ids.Alive(c) {
+		t.Error("Expected issued ids to be alive")
+	}
+
+	if ids.Alive(1 << 40) {
+		t.Error("Expected a never issued id not to be alive")
+	}
+}
+
+func TestIdSourceRetiresExhaustedGenerations(t *testing.T) {
+	ids := NewIdSourceWithGenerationBits[uint8](1)
+
+	a := ids.Next()
+	ids.Release(a)
+
+	b := ids.Next() // Generation 1, the last one
+	ids.Release(b)
+
+	if c := ids.Next(); ids.Index(c) == ids.Index(a) {
+		t.Errorf("Expected index %d to be retired, got it back", ids.Index(a))
+	}
+}
+
+func TestIdSourceConcurrent(t *testing.T) {
+	ids := NewIdSource[int64]()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]bool)
+		wg   sync.WaitGroup
+	)
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 1000 {
+				id := ids.Next()
+
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("Id %d issued twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+
+				if i%2 == 0 {
+					ids.Release(id)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestSpatialHashIdValidator(t *testing.T) {
+	ids := NewIdSource[int64]()
+
+	sh := NewSpatialHash[int64, float64](10)
+	sh.SetIdValidator(ids.Alive)
+
+	id := ids.Next()
+
+	sh.Put(&IntIdPoint{id: id})
+
+	ids.Release(id)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Put with a released id to panic")
+		}
+	}()
+
+	sh.Put(&IntIdPoint{id: id})
+}
+
+// IntIdPoint is a node with an int64 id, for testing id validation.
+type IntIdPoint struct {
+	id   int64
+	x, y float64
+}
+
+func (n *IntIdPoint) GetId() int64 { return n.id }
+
+func (n *IntIdPoint) GetX() float64 { return n.x }
+func (n *IntIdPoint) GetY() float64 { return n.y }
+
+func (n *IntIdPoint) SetOldPos(x, y float64)        {}
+func (n *IntIdPoint) GetOldPos() (float64, float64) { return n.x, n.y }
@@ -0,0 +1,23 @@
+package spatial_hash
+
+// SpatialIndex is the set of operations shared by spatial index backends.
+// Code that only needs these operations, such as simulations or benchmarks,
+// can accept a SpatialIndex and run against any backend.
+type SpatialIndex[Id comparable, N Number] interface {
+	// Put adds a node to the index.
+	Put(n Node[Id, N])
+	// Remove removes a node from the index.
+	Remove(n Node[Id, N])
+	// Update updates a node's position in the index.
+	Update(n Node[Id, N])
+
+	// Search searches all nodes within the radius.
+	Search(x, y, radius N) NodeSlice[Id, N]
+	// QueryRect queries all nodes within the rectangular area centered on a point.
+	QueryRect(x, y, width, height N) NodeSlice[Id, N]
+
+	// Reset clears all nodes from the index.
+	Reset()
+}
+
+var _ SpatialIndex[int, float64] = (*SpatialHash[int, float64])(nil) // *SpatialHash must implement SpatialIndex
@@ -0,0 +1,169 @@
+package spatial_hash
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// stepStart is the position of a node at the start of a step.
+type stepStart[N Number] struct {
+	x, y N
+	step uint64
+}
+
+// interpolation tracks where nodes were at the start of the current step, for SetInterpolation.
+type interpolation[Id comparable, N Number] struct {
+	step atomic.Uint64
+
+	// starts holds the position at the start of its step of every node updated since.
+	starts *xsync.Map[Id, stepStart[N]]
+
+	// reach holds the bits of the float64 furthest distance a node has moved during the step.
+	reach atomic.Uint64
+}
+
+// SetInterpolation enables SearchInterpolated, which queries the positions fixed-timestep
+// renderers draw nodes at, between their positions at the start and at the end of the step.
+// Update then records the position every node had at the start of the step, as delimited
+// by BeginStep.
+//
+// SetInterpolation must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetInterpolation(enabled bool) {
+	if enabled {
+		sh.interp = &interpolation[Id, N]{starts: xsync.NewMap[Id, stepStart[N]]()}
+	} else {
+		sh.interp = nil
+	}
+}
+
+// BeginStep starts a new simulation step: nodes updated from now on are interpolated from
+// the position they have now, and nodes that are not are drawn at their current position.
+// It also slides the window of SetFlowWindow by one step, and forgets the speeds of
+// SetExtrapolation older than the previous step. Call it once per step, before moving nodes.
+func (sh *SpatialHash[Id, N]) BeginStep() {
+	if sh.flow != nil {
+		sh.flow.advance()
+	}
+
+	if sh.maxSpeed != nil {
+		sh.maxSpeed.advance()
+	}
+
+	if sh.interp == nil {
+		return
+	}
+
+	sh.interp.step.Add(1)
+	sh.interp.reach.Store(0)
+
+	// Positions recorded in earlier steps are never read again
+	step := sh.interp.step.Load()
+
+	sh.interp.starts.Range(func(id Id, s stepStart[N]) bool {
+		if s.step != step {
+			sh.interp.starts.Delete(id)
+		}
+
+		return true
+	})
+}
+
+// recordStart records the position n had at the start of the step, before it is updated.
+func (sh *SpatialHash[Id, N]) recordStart(n Node[Id, N]) {
+	ip := sh.interp
+	step := ip.step.Load()
+
+	oldX, oldY := n.GetOldPos()
+
+	start, _ := ip.starts.Compute(n.GetId(), func(s stepStart[N], loaded bool) (stepStart[N], xsync.ComputeOp) {
+		// A node updated twice in a step keeps its first start
+		if loaded && s.step == step {
+			return s, xsync.CancelOp
+		}
+
+		return stepStart[N]{oldX, oldY, step}, xsync.UpdateOp
+	})
+
+	moved := math.Sqrt(distanceSq(float64(n.GetX()-start.x), float64(n.GetY()-start.y)))
+
+	for {
+		bits := ip.reach.Load()
+
+		if moved <= math.Float64frombits(bits) || ip.reach.CompareAndSwap(bits, math.Float64bits(moved)) {
+			return
+		}
+	}
+}
+
+// InterpolatedPos returns the position of n drawn at fraction alpha of the current step, from
+// its position at the start of the step (alpha 0) to its current position (alpha 1).
+func (sh *SpatialHash[Id, N]) InterpolatedPos(n Node[Id, N], alpha float64) (x, y float64) {
+	x, y = float64(n.GetX()), float64(n.GetY())
+
+	if sh.interp == nil {
+		return x, y
+	}
+
+	start, ok := sh.interp.starts.Load(n.GetId())
+	if !ok || start.step != sh.interp.step.Load() {
+		return x, y
+	}
+
+	alpha = min(max(alpha, 0), 1)
+
+	sx, sy := float64(start.x), float64(start.y)
+
+	return sx + (x-sx)*alpha, sy + (y-sy)*alpha
+}
+
+// SearchInterpolated returns the nodes within radius of (x, y) at their interpolated positions,
+// as InterpolatedPos returns them for alpha. Matching what a fixed-timestep renderer draws avoids
+// picking or highlighting nodes that appear elsewhere on screen. Bounded nodes are returned if
+// their interpolated bounding box intersects the circle. Without SetInterpolation, it is Search.
+func (sh *SpatialHash[Id, N]) SearchInterpolated(alpha float64, x, y, radius N) NodeSlice[Id, N] {
+	if sh.interp == nil {
+		return sh.Search(x, y, radius)
+	}
+
+	if !(radius >= 0) {
+		return nil
+	}
+
+	// Interpolated positions lie at most reach away from current ones
+	reach := math.Float64frombits(sh.interp.reach.Load())
+
+	return sh.searchDisplaced(x, y, radius, reach, func(n Node[Id, N]) (float64, float64) {
+		return sh.InterpolatedPos(n, alpha)
+	})
+}
+
+// searchDisplaced returns the nodes within radius of (x, y) at the positions pos returns for
+// them, which must lie at most reach away from their indexed positions.
+func (sh *SpatialHash[Id, N]) searchDisplaced(x, y, radius N, reach float64, pos func(n Node[Id, N]) (float64, float64)) NodeSlice[Id, N] {
+	fx, fy, fr := float64(sh.Quantize(x)), float64(sh.Quantize(y)), float64(radius)
+
+	var nodes NodeSlice[Id, N]
+
+	sh.SearchFunc(x, y, radius+N(math.Ceil(reach)), func(n Node[Id, N]) bool {
+		nx, ny := pos(n)
+
+		dx, dy := math.Abs(nx-fx), math.Abs(ny-fy)
+
+		if b, ok := n.(BoundedNode[Id, N]); ok {
+			if _, bounded := sh.bounded.Load(n.GetId()); bounded {
+				dx = max(dx-max(float64(b.GetHalfWidth()), 0), 0)
+				dy = max(dy-max(float64(b.GetHalfHeight()), 0), 0)
+			}
+		}
+
+		if dx*dx+dy*dy <= fr*fr {
+			nodes = append(nodes, n)
+		}
+
+		return true
+	})
+
+	return nodes
+}
@@ -0,0 +1,91 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSpatialHashSearchInterpolated(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetInterpolation(true)
+
+	moving := newPoint(0, 0, 0)
+	still := newPoint(1, 50, 20)
+	sh.Put(moving)
+	sh.Put(still)
+
+	sh.BeginStep()
+
+	moving.x = 100
+	sh.Update(moving)
+
+	if found := sh.SearchInterpolated(0.5, 50, 0, 5); len(found) != 1 || found[0] != TestingNode(moving) {
+		t.Errorf("Expected the moving node halfway, got %v", found)
+	}
+
+	if found := sh.Search(50, 0, 5); len(found) != 0 {
+		t.Errorf("Expected Search to use current positions, got %v", found)
+	}
+
+	if found := sh.SearchInterpolated(0.5, 50, 20, 1); len(found) != 1 || found[0] != TestingNode(still) {
+		t.Errorf("Expected the node not updated at its position, got %v", found)
+	}
+
+	// In the next step, the node did not move and is drawn where it is
+	sh.BeginStep()
+
+	if x, _ := sh.InterpolatedPos(moving, 0); x != 100 {
+		t.Errorf("Expected the node at its current position in a new step, got %v", x)
+	}
+}
+
+func TestSpatialHashSearchInterpolatedRandom(t *testing.T) {
+	sh := NewSpatialHash[int, float64](20)
+	sh.SetInterpolation(true)
+
+	nodes := CreateTestNodes(300, 500, 500)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	for range 5 {
+		sh.BeginStep()
+
+		starts := make(map[int][2]float64)
+
+		// Some nodes move far in a step, some do not move at all
+		for _, n := range nodes[:200] {
+			starts[n.id] = [2]float64{n.x, n.y}
+
+			n.x += 60*rand.Float64() - 30
+			n.y += 60*rand.Float64() - 30
+
+			sh.Update(n)
+		}
+
+		for range 20 {
+			alpha := rand.Float64()
+			x, y, radius := 500*rand.Float64(), 500*rand.Float64(), 10+30*rand.Float64()
+
+			expected := 0
+
+			for _, n := range nodes {
+				nx, ny := n.x, n.y
+
+				if s, ok := starts[n.id]; ok {
+					nx, ny = s[0]+(n.x-s[0])*alpha, s[1]+(n.y-s[1])*alpha
+				}
+
+				if math.Hypot(nx-x, ny-y) <= radius {
+					expected++
+				}
+			}
+
+			if found := len(sh.SearchInterpolated(alpha, x, y, radius)); found != expected {
+				t.Fatalf("Expected %d nodes, got %d", expected, found)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package spatial_hash
+
+import "fmt"
+
+// LODBand is a level of detail, used for the cells up to Distance from the observer that no
+// nearer band covers.
+type LODBand[N Number] struct {
+	// Distance is how far from the observer the band reaches.
+	Distance N
+	// BlockSize is the side, in cells, of the blocks the band aggregates cells into; 1 reports
+	// cells individually.
+	BlockSize int
+}
+
+// LODBlock is a block of cells aggregated at one level of detail.
+type LODBlock[N Number] struct {
+	// Band is the index of the band the block was aggregated at.
+	Band int
+	// Cells is the range of cells of the block.
+	Cells CellRect
+
+	// Count is the number of nodes in the block, and CentroidX, CentroidY their mean position.
+	// For integer coordinates, the centroid is truncated.
+	Count                int
+	CentroidX, CentroidY N
+}
+
+// LOD returns the nodes around the observer at (x, y) aggregated at decreasing resolution, as
+// rendering and simulation levels of detail: near cells individually, farther cells as ever
+// larger blocks with their node count and centroid, for impostors or coarse simulation.
+//
+// bands must be sorted by increasing Distance, each BlockSize a multiple of the previous one.
+// Blocks are aligned to multiples of their size, and each is split into the blocks of the
+// previous band if any of its cells lies within that band's Distance, so that blocks never
+// overlap and cells next to a band's edge may get finer detail than it. Blocks of the last band
+// with no cell within its Distance, and blocks holding no nodes, are left out. Bounded nodes are
+// counted at their center.
+//
+// It panics if bands are not sorted, or block sizes do not nest.
+func (sh *SpatialHash[Id, N]) LOD(x, y N, bands []LODBand[N]) []LODBlock[N] {
+	for i, band := range bands {
+		if band.BlockSize < 1 || i > 0 && (band.Distance < bands[i-1].Distance || band.BlockSize%bands[i-1].BlockSize != 0) {
+			panic(fmt.Sprintf("spatial_hash: invalid level of detail band %d: %+v", i, band))
+		}
+	}
+
+	if len(bands) == 0 {
+		return nil
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	last := len(bands) - 1
+	reach, size := bands[last].Distance, bands[last].BlockSize
+
+	minX, minY, maxX, maxY := sh.clampCellRange(sh.cellCoord(x-reach), sh.cellCoord(y-reach), sh.cellCoord(x+reach), sh.cellCoord(y+reach))
+
+	var blocks []LODBlock[N]
+
+	for by := floorDiv(minY, size); by <= floorDiv(maxY, size); by++ {
+		for bx := floorDiv(minX, size); bx <= floorDiv(maxX, size); bx++ {
+			block := CellRect{bx * size, by * size, bx*size + size - 1, by*size + size - 1}
+
+			if sh.withinBand(block, bands[last], x, y) {
+				blocks = sh.lodBlock(blocks, bands, last, block, x, y)
+			}
+		}
+	}
+
+	return blocks
+}
+
+// lodBlock appends block, aggregated at band i, to blocks, or the blocks of the previous band
+// it splits into if any of its cells lies within that band.
+func (sh *SpatialHash[Id, N]) lodBlock(blocks []LODBlock[N], bands []LODBand[N], i int, block CellRect, x, y N) []LODBlock[N] {
+	if i > 0 && sh.withinBand(block, bands[i-1], x, y) {
+		size := bands[i-1].BlockSize
+
+		for cy := block.MinY; cy <= block.MaxY; cy += size {
+			for cx := block.MinX; cx <= block.MaxX; cx += size {
+				blocks = sh.lodBlock(blocks, bands, i-1, CellRect{cx, cy, cx + size - 1, cy + size - 1}, x, y)
+			}
+		}
+
+		return blocks
+	}
+
+	b := LODBlock[N]{Band: i, Cells: block}
+
+	var sumX, sumY float64
+
+	for cy := block.MinY; cy <= block.MaxY; cy++ {
+		for cx := block.MinX; cx <= block.MaxX; cx++ {
+			bucket, ok := sh.cellBucket(cx, cy)
+			if !ok {
+				continue
+			}
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				b.Count++
+				sumX += float64(sh.Quantize(n.GetX()))
+				sumY += float64(sh.Quantize(n.GetY()))
+
+				return true
+			})
+		}
+	}
+
+	if b.Count == 0 {
+		return blocks
+	}
+
+	b.CentroidX, b.CentroidY = N(sumX/float64(b.Count)), N(sumY/float64(b.Count))
+
+	return append(blocks, b)
+}
+
+// withinBand reports whether any point of the cells of block lies within the distance of band
+// from (x, y).
+func (sh *SpatialHash[Id, N]) withinBand(block CellRect, band LODBand[N], x, y N) bool {
+	cellSize, fx, fy := float64(sh.cellSize), float64(x), float64(y)
+
+	return Circle[N]{Radius: band.Distance}.Classify(
+		float64(block.MinX)*cellSize-fx, float64(block.MinY)*cellSize-fy,
+		float64(block.MaxX+1)*cellSize-fx, float64(block.MaxY+1)*cellSize-fy,
+	) != CoverageOutside
+}
+
+// floorDiv returns a divided by b, rounded towards negative infinity, for b > 0.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+
+	return q
+}
@@ -0,0 +1,103 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpatialHashLOD(t *testing.T) {
+	nodes := CreateTestNodes(5000, 2000, 2000)
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	bands := []LODBand[float64]{{Distance: 100, BlockSize: 1}, {Distance: 400, BlockSize: 4}, {Distance: 900, BlockSize: 16}}
+
+	x, y := 1010.0, 990.0
+
+	blocks := sh.LOD(x, y, bands)
+
+	covered := make(map[[2]int]int)
+	total, cells := 0, 0
+
+	for _, b := range blocks {
+		if size := bands[b.Band].BlockSize; b.Cells.Width() != size || b.Cells.Height() != size {
+			t.Fatalf("Expected a block of %d cells in band %d, got %v", size, b.Band, b.Cells)
+		}
+
+		if b.Count == 0 {
+			t.Fatalf("Expected no empty blocks, got %v", b.Cells)
+		}
+
+		for cy := b.Cells.MinY; cy <= b.Cells.MaxY; cy++ {
+			for cx := b.Cells.MinX; cx <= b.Cells.MaxX; cx++ {
+				covered[[2]int{cx, cy}] = b.Band + 1
+			}
+		}
+
+		total += b.Count
+		cells += b.Cells.Len()
+	}
+
+	if len(covered) != cells {
+		t.Fatal("Expected blocks not to overlap")
+	}
+
+	counted := 0
+
+	for _, n := range nodes {
+		cx, cy := sh.CellOf(n.x, n.y)
+		band := covered[[2]int{cx, cy}]
+
+		if band > 0 {
+			counted++
+		}
+
+		d := math.Hypot(n.x-x, n.y-y)
+
+		switch {
+		case d <= 80 && band != 1:
+			t.Fatalf("Expected node %d at distance %v in band 0, got %d", n.id, d, band-1)
+		case d <= 880 && band == 0:
+			t.Fatalf("Expected node %d at distance %v in a block", n.id, d)
+		case d > 900+16*20*math.Sqrt2 && band != 0: // Beyond any block with a cell within reach
+			t.Fatalf("Expected node %d at distance %v in no block", n.id, d)
+		}
+	}
+
+	if total != counted {
+		t.Errorf("Expected %d nodes counted, got %d", counted, total)
+	}
+}
+
+func TestSpatialHashLODCentroid(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	sh.Put(newPoint(0, 100, 100))
+	sh.Put(newPoint(1, 110, 120))
+
+	blocks := sh.LOD(0, 0, []LODBand[float64]{{Distance: 10, BlockSize: 1}, {Distance: 500, BlockSize: 8}})
+
+	if len(blocks) != 1 {
+		t.Fatalf("Expected 1 block, got %d", len(blocks))
+	}
+
+	b := blocks[0]
+
+	if b.Band != 1 || b.Cells != (CellRect{8, 8, 15, 15}) || b.Count != 2 || b.CentroidX != 105 || b.CentroidY != 110 {
+		t.Errorf("Expected 2 nodes around (105, 110) in cells 8 to 15, got %+v", b)
+	}
+}
+
+func TestSpatialHashLODInvalidBands(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for block sizes that do not nest")
+		}
+	}()
+
+	NewSpatialHash[int, float64](10).LOD(0, 0, []LODBand[float64]{{Distance: 10, BlockSize: 2}, {Distance: 20, BlockSize: 3}})
+}
@@ -0,0 +1,177 @@
+package spatial_hash
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"slices"
+)
+
+// Nearest returns the node closest to (x, y) within maxRadius.
+// ok is false if no node lies within maxRadius.
+func (sh *SpatialHash[Id, N]) Nearest(x, y, maxRadius N) (n Node[Id, N], ok bool) {
+	nodes := sh.KNearest(x, y, 1, maxRadius)
+	if len(nodes) == 0 {
+		return nil, false
+	}
+
+	return nodes[0], true
+}
+
+// KNearest returns the k nodes closest to (x, y) within maxRadius, sorted by ascending
+// distance, or fewer if fewer lie within maxRadius. Bounded nodes are measured from their center.
+//
+// Cells are visited in rings expanding outwards from the cell containing (x, y), stopping as
+// soon as no further ring can hold a node closer than the k-th best found, so a dense
+// neighborhood is answered without scanning the whole of maxRadius.
+func (sh *SpatialHash[Id, N]) KNearest(x, y N, k int, maxRadius N) NodeSlice[Id, N] {
+	if k <= 0 || !(maxRadius >= 0) {
+		return nil
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	cx, cy := sh.cellCoord(x), sh.cellCoord(y)
+
+	cellSize := float64(sh.cellSize)
+
+	// Cells further than this cannot hold nodes within maxRadius. It is capped so that
+	// an unlimited maxRadius does not overflow; the bucket scan below takes over long before.
+	reach := int(min(math.Ceil(float64(maxRadius+sh.hysteresis)/cellSize), 1<<30))
+
+	minX, minY, maxX, maxY := sh.clampCellRange(cx-reach, cy-reach, cx+reach, cy+reach)
+	fx, fy := float64(x), float64(y)
+
+	maxRadiusSq := float64(maxRadius) * float64(maxRadius)
+
+	best := make(nearestQueue[Id, N], 0, min(k, 64))
+
+	offer := func(n Node[Id, N]) {
+		dx := float64(sh.Quantize(n.GetX()) - x)
+		dy := float64(sh.Quantize(n.GetY()) - y)
+
+		distSq := distanceSq(dx, dy)
+		if distSq > maxRadiusSq {
+			return
+		}
+
+		if len(best) < k {
+			heap.Push(&best, nearestCandidate[Id, N]{n, distSq})
+		} else if distSq < best[0].distSq {
+			best[0] = nearestCandidate[Id, N]{n, distSq}
+
+			heap.Fix(&best, 0)
+		}
+	}
+
+	visit := func(xx, yy int) {
+		if xx < minX || xx > maxX || yy < minY || yy > maxY {
+			return
+		}
+
+		if bucket, ok := sh.cellBucket(xx, yy); ok {
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				offer(n)
+
+				return true
+			})
+		}
+	}
+
+	// Once a ring has more cells than there are buckets, scanning the buckets is cheaper
+	bucketCount := sh.buckets.Size()
+
+	maxRing := max(cx-minX, maxX-cx, cy-minY, maxY-cy)
+
+	for r := 0; r <= maxRing; r++ {
+		if r > 0 {
+			// Every cell of ring r lies outside the rings before it, so its nodes are at least
+			// this far away, less the hysteresis margin they may be kept outside their cell
+			bound := min(
+				fx-float64(cx-r+1)*cellSize, float64(cx+r)*cellSize-fx,
+				fy-float64(cy-r+1)*cellSize, float64(cy+r)*cellSize-fy,
+			)
+
+			bound = max(bound-float64(sh.hysteresis), 0)
+
+			if bound*bound > maxRadiusSq || (len(best) == k && bound*bound > best[0].distSq) {
+				break
+			}
+		}
+
+		if 8*r > bucketCount {
+			sh.buckets.Range(func(c cell, b *bucket[Id, N]) bool {
+				// Skip the buckets of the rings already visited, and outside the cells searched,
+				// by their cell rather than the position of their nodes, which may have moved
+				// without an update or be kept outside the cell by hysteresis
+				if max(abs(c.x-cx), abs(c.y-cy)) < r || c.x < minX || c.x > maxX || c.y < minY || c.y > maxY {
+					return true
+				}
+
+				b.ForEach(func(_ Id, n Node[Id, N]) bool {
+					offer(n)
+
+					return true
+				})
+
+				return true
+			})
+
+			break
+		}
+
+		if r == 0 {
+			visit(cx, cy)
+
+			continue
+		}
+
+		for xx := cx - r; xx <= cx+r; xx++ {
+			visit(xx, cy-r)
+			visit(xx, cy+r)
+		}
+
+		for yy := cy - r + 1; yy <= cy+r-1; yy++ {
+			visit(cx-r, yy)
+			visit(cx+r, yy)
+		}
+	}
+
+	slices.SortStableFunc(best, func(a, b nearestCandidate[Id, N]) int {
+		return cmp.Compare(a.distSq, b.distSq)
+	})
+
+	nodes := make(NodeSlice[Id, N], len(best))
+
+	for i, c := range best {
+		nodes[i] = c.node
+	}
+
+	return nodes
+}
+
+// nearestCandidate is a node considered by KNearest.
+type nearestCandidate[Id comparable, N Number] struct {
+	node   Node[Id, N]
+	distSq float64
+}
+
+// nearestQueue is a max-heap of candidates ordered by distance, keeping the farthest on top.
+type nearestQueue[Id comparable, N Number] []nearestCandidate[Id, N]
+
+func (q nearestQueue[Id, N]) Len() int           { return len(q) }
+func (q nearestQueue[Id, N]) Less(i, j int) bool { return q[i].distSq > q[j].distSq }
+func (q nearestQueue[Id, N]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *nearestQueue[Id, N]) Push(x any) { *q = append(*q, x.(nearestCandidate[Id, N])) }
+
+func (q *nearestQueue[Id, N]) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return c
+}
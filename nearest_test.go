@@ -0,0 +1,139 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// naiveKNearest returns the distances of the k nodes closest to (x, y) within maxRadius, ascending.
+func naiveKNearest(nodes []*Point, x, y float64, k int, maxRadius float64) []float64 {
+	var dists []float64
+
+	for _, n := range nodes {
+		if d := math.Hypot(n.x-x, n.y-y); d <= maxRadius {
+			dists = append(dists, d)
+		}
+	}
+
+	slices.Sort(dists)
+
+	return dists[:min(k, len(dists))]
+}
+
+// checkKNearest compares KNearest against naiveKNearest.
+func checkKNearest(t *testing.T, sh *SpatialHash[int, float64], nodes []*Point, x, y float64, k int, maxRadius float64) {
+	t.Helper()
+
+	expected := naiveKNearest(nodes, x, y, k, maxRadius)
+
+	found := sh.KNearest(x, y, k, maxRadius)
+
+	if len(found) != len(expected) {
+		t.Fatalf("KNearest(%v, %v, %d, %v): expected %d nodes, got %d", x, y, k, maxRadius, len(expected), len(found))
+	}
+
+	for i, n := range found {
+		if d := math.Hypot(n.GetX()-x, n.GetY()-y); math.Abs(d-expected[i]) > 1e-9 {
+			t.Fatalf("KNearest(%v, %v, %d, %v): expected distance %v at %d, got %v", x, y, k, maxRadius, expected[i], i, d)
+		}
+	}
+}
+
+func TestSpatialHashKNearest(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nodeCount int
+		cellSize  float64
+		areaSize  float64
+	}{
+		{"Small World", 100, 20, 200},
+		{"Dense Population", 10000, 100, 1000},
+		{"Sparse Population", 1000, 50, 2000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := CreateTestNodes(tc.nodeCount, tc.areaSize, tc.areaSize)
+
+			// Center the world on the origin, so half the coordinates are negative
+			for _, n := range nodes {
+				n.x, n.y = n.x-tc.areaSize/2, n.y-tc.areaSize/2
+			}
+
+			sh := NewSpatialHash[int, float64](tc.cellSize)
+
+			for _, n := range nodes {
+				sh.Put(n)
+			}
+
+			half := tc.areaSize / 2
+
+			for range 300 {
+				x, y := tc.areaSize*rand.Float64()-half, tc.areaSize*rand.Float64()-half
+
+				checkKNearest(t, sh, nodes, x, y, 1, tc.areaSize)
+				checkKNearest(t, sh, nodes, x, y, 5, tc.areaSize/10)
+				checkKNearest(t, sh, nodes, x, y, 20, math.Inf(1))
+
+				// Query points exactly on cell boundaries
+				bx := math.Floor(x/tc.cellSize) * tc.cellSize
+				by := math.Floor(y/tc.cellSize) * tc.cellSize
+
+				checkKNearest(t, sh, nodes, bx, by, 5, tc.areaSize)
+				checkKNearest(t, sh, nodes, bx, y, 3, tc.cellSize)
+			}
+
+			// Far outside the populated area
+			checkKNearest(t, sh, nodes, -10*tc.areaSize, 3*tc.areaSize, 3, math.Inf(1))
+		})
+	}
+}
+
+func TestSpatialHashNearest(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// The node in the query's own cell is further than the one just across the boundary
+	same := newPoint(1, -9.9, -9.9)
+	adjacent := newPoint(2, 0.5, -5)
+
+	sh.Put(same)
+	sh.Put(adjacent)
+
+	n, ok := sh.Nearest(-0.5, -5, 100)
+	if !ok || n != TestingNode(adjacent) {
+		t.Errorf("Expected the node in the adjacent cell, got %v", n)
+	}
+
+	if _, ok := sh.Nearest(50, 50, 10); ok {
+		t.Error("Expected no node within maxRadius")
+	}
+
+	if found := sh.KNearest(0, 0, 5, 1000); len(found) != 2 || found[0] != TestingNode(adjacent) || found[1] != TestingNode(same) {
+		t.Errorf("Expected both nodes sorted by distance, got %d nodes", len(found))
+	}
+
+	if found := sh.KNearest(0, 0, 0, 1000); len(found) != 0 {
+		t.Errorf("Expected no nodes for k = 0, got %d", len(found))
+	}
+}
+
+func TestSpatialHashKNearestMovedWithoutUpdate(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// Few buckets, so that the rings soon give way to a scan of every bucket
+	p, q := newPoint(1, 5, 5), newPoint(2, 200, 200)
+
+	sh.Put(p)
+	sh.Put(q)
+
+	// Still stored in the center cell, which the first ring visits, but positioned in an outer ring
+	p.x = 55
+
+	result := sh.KNearest(5, 5, 3, 1000)
+
+	if len(result) != 2 || result[0] != TestingNode(p) || result[1] != TestingNode(q) {
+		t.Errorf("Expected each node once, nearest first, got %v", result)
+	}
+}
@@ -0,0 +1,104 @@
+package spatial_hash
+
+import "github.com/puzpuzpuz/xsync/v4"
+
+// moveTracker tracks the nodes in a spatial hash by id, and the ones reported as moved.
+type moveTracker[Id comparable, N Number] struct {
+	// nodes holds every node in the spatial hash.
+	nodes *xsync.Map[Id, Node[Id, N]]
+
+	// moved holds the ids reported with NotifyMoved since the last UpdateMoved.
+	moved *xsync.Map[Id, struct{}]
+}
+
+// newMoveTracker creates an empty move tracker.
+func newMoveTracker[Id comparable, N Number]() *moveTracker[Id, N] {
+	return &moveTracker[Id, N]{
+		nodes: xsync.NewMap[Id, Node[Id, N]](),
+		moved: xsync.NewMap[Id, struct{}](),
+	}
+}
+
+// Add starts tracking n.
+func (mt *moveTracker[Id, N]) Add(n Node[Id, N]) {
+	mt.nodes.Store(n.GetId(), n)
+}
+
+// Remove stops tracking the node with the given id, dropping any pending notification.
+func (mt *moveTracker[Id, N]) Remove(id Id) {
+	mt.nodes.Delete(id)
+	mt.moved.Delete(id)
+}
+
+// Clear stops tracking all nodes.
+func (mt *moveTracker[Id, N]) Clear() {
+	mt.nodes.Clear()
+	mt.moved.Clear()
+}
+
+// SetMoveNotifications enables or disables NotifyMoved and UpdateMoved, which let nodes report
+// their own movement so that only the nodes that moved are updated each tick.
+// Tracking nodes by id costs a little on every Put and Remove.
+//
+// SetMoveNotifications must be called before any node is put.
+func (sh *SpatialHash[Id, N]) SetMoveNotifications(enabled bool) {
+	if enabled {
+		sh.tracker = newMoveTracker[Id, N]()
+	} else {
+		sh.tracker = nil
+	}
+}
+
+// NotifyMoved reports that the node with the given id has moved. The node is updated by the
+// next UpdateMoved, however many times it is reported in between. It returns false if the
+// node is not in the spatial hash, or if move notifications are not enabled.
+func (sh *SpatialHash[Id, N]) NotifyMoved(id Id) bool {
+	if sh.tracker == nil {
+		return false
+	}
+
+	if _, ok := sh.tracker.nodes.Load(id); !ok {
+		return false
+	}
+
+	sh.tracker.moved.Store(id, struct{}{})
+
+	return true
+}
+
+// UpdateMoved updates every node reported with NotifyMoved since the previous call, as Update
+// or UpdateBounded would, and returns the number of updated nodes. Nodes reported while it
+// runs are updated either now or by the next call.
+func (sh *SpatialHash[Id, N]) UpdateMoved() int {
+	if sh.tracker == nil {
+		return 0
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	updated := 0
+
+	sh.tracker.moved.Range(func(id Id, _ struct{}) bool {
+		if _, ok := sh.tracker.moved.LoadAndDelete(id); !ok {
+			return true
+		}
+
+		n, ok := sh.tracker.nodes.Load(id)
+		if !ok {
+			return true
+		}
+
+		if _, bounded := sh.bounded.Load(id); bounded {
+			sh.updateBounded(n.(BoundedNode[Id, N]))
+		} else {
+			sh.update(n)
+		}
+
+		updated++
+
+		return true
+	})
+
+	return updated
+}
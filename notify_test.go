@@ -0,0 +1,87 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashNotifyMoved(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetMoveNotifications(true)
+
+	nodes := CreateTestNodes(100, 1000, 1000)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	// Only a few nodes move, some of them reported more than once
+	for _, n := range nodes[:10] {
+		n.x, n.y = n.x+25, n.y-25
+
+		if !sh.NotifyMoved(n.id) {
+			t.Fatalf("Expected node %d to be tracked", n.id)
+		}
+
+		sh.NotifyMoved(n.id)
+	}
+
+	if updated := sh.UpdateMoved(); updated != 10 {
+		t.Errorf("Expected 10 updated nodes, got %d", updated)
+	}
+
+	if updated := sh.UpdateMoved(); updated != 0 {
+		t.Errorf("Expected no pending nodes after UpdateMoved, got %d", updated)
+	}
+
+	for _, n := range nodes {
+		if len(sh.AtPosition(n.x, n.y)) != 1 {
+			t.Fatalf("Expected node %d at its current position", n.id)
+		}
+	}
+
+	sh.Remove(nodes[0])
+
+	if sh.NotifyMoved(nodes[0].id) {
+		t.Error("Expected a removed node not to be tracked")
+	}
+
+	// Bounded nodes are updated with their bounds
+	b := newBoxPoint(1000, 500, 500, 30, 30)
+	sh.PutBounded(b)
+
+	b.x = 600
+
+	sh.NotifyMoved(b.id)
+	sh.UpdateMoved()
+
+	hasBox := func(found NodeSlice[int, float64]) bool {
+		for _, n := range found {
+			if n == TestingNode(b) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !hasBox(sh.Search(575, 500, 1)) {
+		t.Error("Expected the moved box to be found through its bounds")
+	}
+
+	if hasBox(sh.Search(500, 500, 1)) {
+		t.Error("Expected the moved box gone from its old cells")
+	}
+}
+
+func TestSpatialHashNotifyMovedDisabled(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	n := newPoint(1, 5, 5)
+	sh.Put(n)
+
+	if sh.NotifyMoved(n.id) {
+		t.Error("Expected NotifyMoved to report false without move notifications")
+	}
+
+	if updated := sh.UpdateMoved(); updated != 0 {
+		t.Errorf("Expected 0 updated nodes, got %d", updated)
+	}
+}
@@ -0,0 +1,93 @@
+package spatial_hash
+
+import "math/bits"
+
+// CellBitset is a boolean raster over a range of cells, one bit per cell.
+type CellBitset struct {
+	// Cells is the range of cells covered by the bitset.
+	Cells CellRect
+
+	words []uint64
+}
+
+// NewCellBitset creates a bitset over cells with every bit cleared.
+func NewCellBitset(cells CellRect) *CellBitset {
+	return &CellBitset{
+		Cells: cells,
+
+		words: make([]uint64, (cells.Len()+63)/64),
+	}
+}
+
+// Has reports whether the bit of cell (cx, cy) is set.
+// Cells outside the range are never set.
+func (b *CellBitset) Has(cx, cy int) bool {
+	if !b.Cells.Contains(cx, cy) {
+		return false
+	}
+
+	i := b.Cells.Index(cx, cy)
+
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Set sets the bit of cell (cx, cy), which must lie inside the range.
+func (b *CellBitset) Set(cx, cy int) {
+	i := b.Cells.Index(cx, cy)
+
+	b.words[i/64] |= 1 << (i % 64)
+}
+
+// Count returns the number of set bits.
+func (b *CellBitset) Count() int {
+	count := 0
+
+	for _, w := range b.words {
+		count += bits.OnesCount64(w)
+	}
+
+	return count
+}
+
+// Words returns the underlying bits, in row-major cell order, 64 cells per word.
+// The slice is shared with the bitset.
+func (b *CellBitset) Words() []uint64 {
+	return b.words
+}
+
+// OccupancyBits returns a bitset over the cells overlapped by r, with the bit of every
+// cell containing at least one node set. If filter is non-nil, only nodes for which it
+// returns true count as occupying their cell.
+//
+// filter must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) OccupancyBits(r Rect[N], filter func(n Node[Id, N]) bool) *CellBitset {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	cells := sh.CellRectOf(r)
+
+	occupancy := NewCellBitset(cells)
+
+	for cy := cells.MinY; cy <= cells.MaxY; cy++ {
+		for cx := cells.MinX; cx <= cells.MaxX; cx++ {
+			bucket, ok := sh.cellBucket(cx, cy)
+			if !ok {
+				continue
+			}
+
+			occupied := false
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				occupied = filter == nil || filter(n)
+
+				return !occupied
+			})
+
+			if occupied {
+				occupancy.Set(cx, cy)
+			}
+		}
+	}
+
+	return occupancy
+}
@@ -0,0 +1,40 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashOccupancyBits(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	sh.Put(newPoint(1, 5, 5))    // Cell (0, 0)
+	sh.Put(newPoint(2, -5, 15))  // Cell (-1, 1)
+	sh.Put(newPoint(3, 25, 25))  // Cell (2, 2)
+	sh.Put(newPoint(4, 100, 10)) // Outside the rect
+
+	occupancy := sh.OccupancyBits(Rect[float64]{MinX: -10, MinY: 0, MaxX: 29, MaxY: 29}, nil)
+
+	if cells := occupancy.Cells; cells != (CellRect{-1, 0, 2, 2}) {
+		t.Fatalf("Unexpected cell range %+v", cells)
+	}
+
+	if count := occupancy.Count(); count != 3 {
+		t.Errorf("Expected 3 occupied cells, got %d", count)
+	}
+
+	for _, cell := range [][2]int{{0, 0}, {-1, 1}, {2, 2}} {
+		if !occupancy.Has(cell[0], cell[1]) {
+			t.Errorf("Expected cell %v to be occupied", cell)
+		}
+	}
+
+	if occupancy.Has(1, 1) || occupancy.Has(10, 1) {
+		t.Error("Expected cells (1, 1) and (10, 1) to be unoccupied")
+	}
+
+	filtered := sh.OccupancyBits(Rect[float64]{MinX: -10, MinY: 0, MaxX: 29, MaxY: 29}, func(n TestingNode) bool {
+		return n.GetId() != 1
+	})
+
+	if filtered.Has(0, 0) || filtered.Count() != 2 {
+		t.Errorf("Expected the filtered node's cell to be unoccupied, got %d occupied cells", filtered.Count())
+	}
+}
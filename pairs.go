@@ -0,0 +1,101 @@
+package spatial_hash
+
+import "math"
+
+// pairEntry is a node gathered by a pairwise sweep.
+type pairEntry[Id comparable, N Number] struct {
+	node Node[Id, N]
+	x, y N
+
+	// asleep is whether the node was sleeping when gathered.
+	asleep bool
+
+	// factor accumulates the crowding factor of the node.
+	factor float64
+}
+
+// sweepPairs gathers every node, then calls visit for every pair of nodes whose cells are close
+// enough to hold nodes within radius of each other, stopping early if visit returns false.
+// Every pair of cells within reach is visited once, and so is every pair of nodes.
+// It returns the gathered nodes.
+func (sh *SpatialHash[Id, N]) sweepPairs(radius N, visit func(a, b *pairEntry[Id, N]) bool) []pairEntry[Id, N] {
+	var entries []pairEntry[Id, N]
+
+	cells := make(map[cell][]int)
+
+	sh.buckets.Range(func(_ cell, b *bucket[Id, N]) bool {
+		b.ForEach(func(id Id, n Node[Id, N]) bool {
+			x, y := sh.Quantize(n.GetX()), sh.Quantize(n.GetY())
+			c := cell{sh.cellCoord(x), sh.cellCoord(y)}
+
+			cells[c] = append(cells[c], len(entries))
+			entries = append(entries, pairEntry[Id, N]{node: n, x: x, y: y, asleep: sh.IsSleeping(id)})
+
+			return true
+		})
+
+		return true
+	})
+
+	// Cells further than this apart cannot hold nodes within radius
+	reach := int(math.Ceil(float64(radius) / float64(sh.cellSize)))
+
+	for c, members := range cells {
+		// Pairs within the cell
+		for k, i := range members {
+			for _, j := range members[k+1:] {
+				if !visit(&entries[i], &entries[j]) {
+					return entries
+				}
+			}
+		}
+
+		// Pairs with the cells in the forward half of the neighborhood
+		for dy := 0; dy <= reach; dy++ {
+			for dx := -reach; dx <= reach; dx++ {
+				if dy == 0 && dx <= 0 {
+					continue
+				}
+
+				others, ok := cells[cell{c.x + dx, c.y + dy}]
+				if !ok {
+					continue
+				}
+
+				for _, i := range members {
+					for _, j := range others {
+						if !visit(&entries[i], &entries[j]) {
+							return entries
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return entries
+}
+
+// ForEachPair calls fn once for every pair of nodes within radius of each other, in no
+// particular order, stopping early if fn returns false. Pairs of two sleeping nodes are
+// skipped, as neither can have moved into the other.
+//
+// fn must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) ForEachPair(radius N, fn func(a, b Node[Id, N]) bool) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	radiusSq := float64(radius) * float64(radius)
+
+	sh.sweepPairs(radius, func(a, b *pairEntry[Id, N]) bool {
+		if a.asleep && b.asleep {
+			return true
+		}
+
+		if distanceSq(float64(a.x-b.x), float64(a.y-b.y)) > radiusSq {
+			return true
+		}
+
+		return fn(a.node, b.node)
+	})
+}
@@ -0,0 +1,62 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpatialHashForEachPair(t *testing.T) {
+	const radius = 25
+
+	nodes := CreateTestNodes(1500, 500, 500)
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	expected := 0
+
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			if math.Hypot(a.x-b.x, a.y-b.y) <= radius {
+				expected++
+			}
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+
+	sh.ForEachPair(radius, func(a, b TestingNode) bool {
+		key := [2]int{min(a.GetId(), b.GetId()), max(a.GetId(), b.GetId())}
+
+		if seen[key] {
+			t.Fatalf("Pair %v visited twice", key)
+		}
+
+		seen[key] = true
+
+		if d := math.Hypot(a.GetX()-b.GetX(), a.GetY()-b.GetY()); d > radius {
+			t.Fatalf("Pair %v is %v apart, beyond the radius", key, d)
+		}
+
+		return true
+	})
+
+	if len(seen) != expected {
+		t.Errorf("Expected %d pairs, got %d", expected, len(seen))
+	}
+
+	calls := 0
+
+	sh.ForEachPair(radius, func(a, b TestingNode) bool {
+		calls++
+
+		return false
+	})
+
+	if calls != 1 {
+		t.Errorf("Expected ForEachPair to stop after 1 call, got %d", calls)
+	}
+}
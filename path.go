@@ -0,0 +1,101 @@
+package spatial_hash
+
+// Vec2 is a 2D point or vector.
+type Vec2[N Number] struct {
+	X, Y N
+}
+
+// pathCandidate is a node cached by NearestAlongPath, with its quantized position.
+type pathCandidate[Id comparable, N Number] struct {
+	node Node[Id, N]
+	x, y N
+}
+
+// NearestAlongPath returns, for each point of a path, the nearest node within radius of it,
+// or nil if there is none. The result has one entry per point. Ties go to the node found
+// first, scanning the cells row by row.
+//
+// Consecutive points usually search overlapping cells, so the nodes of each cell are loaded
+// once while the cell stays within reach of the path, instead of once per point.
+func (sh *SpatialHash[Id, N]) NearestAlongPath(points []Vec2[N], radius N) NodeSlice[Id, N] {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	result := make(NodeSlice[Id, N], len(points))
+
+	if !(radius >= 0) {
+		return result
+	}
+
+	radiusSq := radius * radius
+
+	// Nodes of the cells in window, the cells searched for the previous point
+	cache := make(map[cell][]pathCandidate[Id, N])
+
+	window := CellRect{0, 0, -1, -1}
+
+	for i, p := range points {
+		x, y := sh.Quantize(p.X), sh.Quantize(p.Y)
+
+		var next CellRect
+		next.MinX, next.MinY, next.MaxX, next.MaxY = sh.queryCells(x, y, radius, radius)
+
+		// Drop cells the path has left
+		for c := range cache {
+			if !next.Contains(c.x, c.y) {
+				delete(cache, c)
+			}
+		}
+
+		// Load cells the path has entered
+		for cy := next.MinY; cy <= next.MaxY; cy++ {
+			for cx := next.MinX; cx <= next.MaxX; cx++ {
+				if window.Contains(cx, cy) {
+					continue
+				}
+
+				bucket, ok := sh.cellBucket(cx, cy)
+				if !ok {
+					continue
+				}
+
+				var candidates []pathCandidate[Id, N]
+
+				bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+					candidates = append(candidates, pathCandidate[Id, N]{n, sh.Quantize(n.GetX()), sh.Quantize(n.GetY())})
+
+					return true
+				})
+
+				cache[cell{cx, cy}] = candidates
+			}
+		}
+
+		window = next
+
+		var (
+			best       Node[Id, N]
+			bestDistSq N
+		)
+
+		// Cells are scanned row by row rather than in map order, so that a tie between
+		// cells always goes to the same one
+		for cy := next.MinY; cy <= next.MaxY; cy++ {
+			for cx := next.MinX; cx <= next.MaxX; cx++ {
+				for _, c := range cache[cell{cx, cy}] {
+					distSq := distanceSq(c.x-x, c.y-y)
+
+					if distSq > radiusSq || (best != nil && distSq >= bestDistSq) {
+						continue
+					}
+
+					best, bestDistSq = c.node, distSq
+				}
+			}
+		}
+
+		result[i] = best
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+package spatial_hash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpatialHashNearestAlongPath(t *testing.T) {
+	const radius = 40
+
+	nodes := CreateTestNodes(3000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](25)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	// A spiral path sampled every few units
+	var path []Vec2[float64]
+
+	for i := range 500 {
+		angle := float64(i) / 20
+
+		path = append(path, Vec2[float64]{500 + float64(i)*math.Cos(angle), 500 + float64(i)*math.Sin(angle)})
+	}
+
+	result := sh.NearestAlongPath(path, radius)
+
+	if len(result) != len(path) {
+		t.Fatalf("Expected %d results, got %d", len(path), len(result))
+	}
+
+	for i, p := range path {
+		bestDist := math.Inf(1)
+
+		for _, n := range nodes {
+			if d := math.Hypot(n.x-p.X, n.y-p.Y); d <= radius && d < bestDist {
+				bestDist = d
+			}
+		}
+
+		if result[i] == nil {
+			if !math.IsInf(bestDist, 1) {
+				t.Fatalf("Point %d: expected a node at distance %v, got none", i, bestDist)
+			}
+
+			continue
+		}
+
+		if d := math.Hypot(result[i].GetX()-p.X, result[i].GetY()-p.Y); d != bestDist {
+			t.Fatalf("Point %d: expected nearest distance %v, got %v", i, bestDist, d)
+		}
+	}
+}
+
+func TestSpatialHashNearestAlongPathTies(t *testing.T) {
+	for range 50 {
+		sh := NewSpatialHash[int, float64](10)
+
+		// Equidistant from the path point, in cells (0, 0), (1, 0) and (0, 1)
+		sh.Put(newPoint(0, 5, 5))
+		sh.Put(newPoint(1, 15, 5))
+		sh.Put(newPoint(2, 5, 15))
+
+		got := sh.NearestAlongPath([]Vec2[float64]{{10, 10}}, 20)
+
+		if got[0] == nil || got[0].GetId() != 0 {
+			t.Fatalf("Expected the node of the first cell to win the tie, got %v", got[0])
+		}
+	}
+}
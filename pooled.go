@@ -0,0 +1,211 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// PoolCheck selects how misuse of the slices returned by SearchOwned is reported.
+type PoolCheck int
+
+const (
+	// PoolCheckOff reports nothing: released slices go straight back to the pool.
+	PoolCheckOff PoolCheck = iota
+	// PoolCheckError makes ReleaseResults return misuse as an error, and records use after
+	// release for PoolError.
+	PoolCheckError
+	// PoolCheckPanic panics on misuse, at the call that misuses the slice.
+	PoolCheckPanic
+)
+
+// ownedSlice records where a slice returned by SearchOwned was acquired and released.
+type ownedSlice struct {
+	acquired, released string
+}
+
+// poolChecker tracks the slices returned by SearchOwned, for SetPoolCheck.
+type poolChecker[Id comparable, N Number] struct {
+	mode PoolCheck
+
+	mu sync.Mutex
+	// owned holds every slice returned by SearchOwned, keyed by the address of its backing array.
+	owned map[uintptr]*ownedSlice
+	// err is the first use after release, in PoolCheckError mode.
+	err error
+}
+
+// releasedNode fills released slices, reporting any use of them.
+type releasedNode[Id comparable, N Number] struct {
+	pc    *poolChecker[Id, N]
+	slice *ownedSlice
+}
+
+func (r releasedNode[Id, N]) use() {
+	r.pc.report(fmt.Errorf("%w: acquired at %s, released at %s", ErrUseAfterRelease, r.slice.acquired, r.slice.released))
+}
+
+func (r releasedNode[Id, N]) GetId() Id {
+	r.use()
+
+	var id Id
+
+	return id
+}
+
+func (r releasedNode[Id, N]) GetX() N {
+	r.use()
+
+	return 0
+}
+
+func (r releasedNode[Id, N]) GetY() N {
+	r.use()
+
+	return 0
+}
+
+func (r releasedNode[Id, N]) SetOldPos(x, y N) {
+	r.use()
+}
+
+func (r releasedNode[Id, N]) GetOldPos() (N, N) {
+	r.use()
+
+	return 0, 0
+}
+
+// report panics with err in PoolCheckPanic mode, or records it for PoolError otherwise.
+func (pc *poolChecker[Id, N]) report(err error) {
+	if pc.mode == PoolCheckPanic {
+		panic(err)
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.err == nil {
+		pc.err = err
+	}
+}
+
+// callSite returns the file and line of the caller skip frames above its own caller.
+func callSite(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// sliceAddr returns the address of the backing array of s.
+func sliceAddr[Id comparable, N Number](s NodeSlice[Id, N]) uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(s)))
+}
+
+// SetPoolCheck sets how misuse of the slices returned by SearchOwned is reported. Pool misuse
+// otherwise silently corrupts results: a slice released twice is handed out to two callers,
+// and a slice used after release reads another query's nodes. With PoolCheckError or
+// PoolCheckPanic, every slice is allocated and remembered, and released slices are filled
+// with nodes reporting any use instead of being reused, so diagnostics name where the slice
+// was acquired and released. These modes are meant for tests and debugging.
+//
+// SetPoolCheck must be called before SearchOwned is used.
+func (sh *SpatialHash[Id, N]) SetPoolCheck(mode PoolCheck) {
+	if mode == PoolCheckOff {
+		sh.poolCheck = nil
+
+		return
+	}
+
+	sh.poolCheck = &poolChecker[Id, N]{mode: mode, owned: make(map[uintptr]*ownedSlice)}
+}
+
+// PoolError returns the first use after release recorded in PoolCheckError mode and clears it,
+// or nil if there was none.
+func (sh *SpatialHash[Id, N]) PoolError() error {
+	pc := sh.poolCheck
+	if pc == nil {
+		return nil
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	err := pc.err
+	pc.err = nil
+
+	return err
+}
+
+// SearchOwned returns the nodes Search would return in a slice taken from the internal pool,
+// which saves Search's copy. The caller owns the slice until it passes it to ReleaseResults,
+// and must not use it afterwards.
+func (sh *SpatialHash[Id, N]) SearchOwned(x, y, radius N) NodeSlice[Id, N] {
+	pc := sh.poolCheck
+	if pc == nil {
+		return sh.SearchAppend(sh.nodePool.Get()[:0], x, y, radius)
+	}
+
+	nodes := sh.SearchAppend(make(NodeSlice[Id, N], 0, 64), x, y, radius)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// An address freed and reused by a new slice starts a new record
+	pc.owned[sliceAddr(nodes)] = &ownedSlice{acquired: callSite(1)}
+
+	return nodes
+}
+
+// ReleaseResults returns a slice returned by SearchOwned to the pool. It must be passed the
+// slice as SearchOwned returned it, or resliced from its start.
+// With SetPoolCheck, releasing a slice twice or a slice SearchOwned did not return is reported:
+// ReleaseResults returns ErrDoubleRelease or ErrForeignRelease in PoolCheckError mode, and
+// panics with them in PoolCheckPanic mode. It returns nil otherwise.
+func (sh *SpatialHash[Id, N]) ReleaseResults(nodes NodeSlice[Id, N]) error {
+	pc := sh.poolCheck
+	if pc == nil {
+		sh.nodePool.Put(nodes)
+
+		return nil
+	}
+
+	site := callSite(1)
+
+	pc.mu.Lock()
+
+	owned, ok := pc.owned[sliceAddr(nodes)]
+
+	var err error
+
+	switch {
+	case !ok:
+		err = fmt.Errorf("%w: released at %s", ErrForeignRelease, site)
+
+	case owned.released != "":
+		err = fmt.Errorf("%w: acquired at %s, first released at %s, released again at %s", ErrDoubleRelease, owned.acquired, owned.released, site)
+
+	default:
+		owned.released = site
+
+		// Released slices are never reused, so that any later read hits a released node
+		poison := releasedNode[Id, N]{pc, owned}
+
+		all := nodes[:cap(nodes)]
+
+		for i := range all {
+			all[i] = poison
+		}
+	}
+
+	pc.mu.Unlock()
+
+	if err != nil && pc.mode == PoolCheckPanic {
+		panic(err)
+	}
+
+	return err
+}
@@ -0,0 +1,92 @@
+package spatial_hash
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSpatialHashSearchOwned(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	nodes := CreateTestNodes(200, 100, 100)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	for range 3 {
+		owned := sh.SearchOwned(50, 50, 20)
+
+		if expected := len(sh.Search(50, 50, 20)); len(owned) != expected {
+			t.Errorf("Expected %d nodes, got %d", expected, len(owned))
+		}
+
+		if err := sh.ReleaseResults(owned); err != nil {
+			t.Errorf("Expected no error without checks, got %v", err)
+		}
+	}
+}
+
+func TestSpatialHashPoolCheckError(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetPoolCheck(PoolCheckError)
+
+	sh.Put(newPoint(0, 5, 5))
+
+	owned := sh.SearchOwned(5, 5, 1)
+	if len(owned) != 1 {
+		t.Fatalf("Expected 1 node, got %d", len(owned))
+	}
+
+	if err := sh.ReleaseResults(owned); err != nil {
+		t.Errorf("Expected no error on first release, got %v", err)
+	}
+
+	if err := sh.ReleaseResults(owned); !errors.Is(err, ErrDoubleRelease) {
+		t.Errorf("Expected ErrDoubleRelease, got %v", err)
+	}
+
+	if err := sh.ReleaseResults(make(NodeSlice[int, float64], 1)); !errors.Is(err, ErrForeignRelease) {
+		t.Errorf("Expected ErrForeignRelease, got %v", err)
+	}
+
+	if err := sh.PoolError(); err != nil {
+		t.Errorf("Expected no use after release yet, got %v", err)
+	}
+
+	owned[0].GetX()
+
+	if err := sh.PoolError(); !errors.Is(err, ErrUseAfterRelease) {
+		t.Errorf("Expected ErrUseAfterRelease, got %v", err)
+	}
+
+	if err := sh.PoolError(); err != nil {
+		t.Errorf("Expected PoolError to clear the error, got %v", err)
+	}
+}
+
+func TestSpatialHashPoolCheckPanic(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetPoolCheck(PoolCheckPanic)
+
+	sh.Put(newPoint(0, 5, 5))
+
+	owned := sh.SearchOwned(5, 5, 1)
+	sh.ReleaseResults(owned)
+
+	expectPanic := func(target error, fn func()) {
+		t.Helper()
+
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, target) {
+				t.Errorf("Expected a panic with %v, got %v", target, err)
+			}
+		}()
+
+		fn()
+	}
+
+	expectPanic(ErrUseAfterRelease, func() { owned[0].GetId() })
+	expectPanic(ErrDoubleRelease, func() { sh.ReleaseResults(owned) })
+}
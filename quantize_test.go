@@ -0,0 +1,27 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashQuantization(t *testing.T) {
+	sh := NewSpatialHash[int, float64](1)
+
+	sh.SetQuantization(1.0 / 256)
+
+	// Snaps up across the cell boundary at 1
+	node := newPoint(1, 0.999, 10.001)
+
+	sh.Put(node)
+
+	if result := sh.QueryRect(1.5, 10.5, 0, 0); len(result) != 1 {
+		t.Errorf("Expected quantized node in cell (1, 10), got %d nodes", len(result))
+	}
+
+	// Distances are compared between quantized positions
+	if result := sh.Search(1, 10, 0); len(result) != 1 {
+		t.Errorf("Expected quantized node at (1, 10), got %d nodes", len(result))
+	}
+
+	if q := sh.Quantize(0.5 + 1.0/1024); q != 0.5 {
+		t.Errorf("Expected 0.5, got %v", q)
+	}
+}
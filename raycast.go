@@ -0,0 +1,77 @@
+package spatial_hash
+
+import "math"
+
+// TraverseCells calls fn for every cell the segment from (x0, y0) to (x1, y1) passes through,
+// in order from the start, stopping early if fn returns false. It reports whether traversal
+// reached the end of the segment.
+//
+// Where the segment passes exactly through a cell corner, only one of the two cells beside
+// the corner is visited.
+func (sh *SpatialHash[Id, N]) TraverseCells(x0, y0, x1, y1 N, fn func(cx, cy int) bool) bool {
+	x0, y0 = sh.Quantize(x0), sh.Quantize(y0)
+	x1, y1 = sh.Quantize(x1), sh.Quantize(y1)
+
+	cx, cy := sh.CellOf(x0, y0)
+	endX, endY := sh.CellOf(x1, y1)
+
+	if !fn(cx, cy) {
+		return false
+	}
+
+	cellSize := float64(sh.cellSize)
+
+	// Distance along the segment, as a fraction of its length, to the next cell boundary on
+	// each axis, and between boundaries
+	axis := func(from, to float64, c int) (step int, tMax, tDelta float64) {
+		d := to - from
+
+		switch {
+		case d > 0:
+			return 1, (float64(c+1)*cellSize - from) / d, cellSize / d
+
+		case d < 0:
+			return -1, (float64(c)*cellSize - from) / d, -cellSize / d
+
+		default:
+			return 0, math.Inf(1), math.Inf(1)
+		}
+	}
+
+	stepX, tMaxX, tDeltaX := axis(float64(x0), float64(x1), cx)
+	stepY, tMaxY, tDeltaY := axis(float64(y0), float64(y1), cy)
+
+	steps := abs(endX-cx) + abs(endY-cy)
+
+	for range steps {
+		if tMaxX < tMaxY {
+			cx += stepX
+			tMaxX += tDeltaX
+		} else {
+			cy += stepY
+			tMaxY += tDeltaY
+		}
+
+		if !fn(cx, cy) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LineOfSight reports whether the segment from (x0, y0) to (x1, y1) passes through no cell
+// marked with SetCellBlocked.
+func (sh *SpatialHash[Id, N]) LineOfSight(x0, y0, x1, y1 N) bool {
+	return sh.TraverseCells(x0, y0, x1, y1, func(cx, cy int) bool {
+		return !sh.IsCellBlocked(cx, cy)
+	})
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,114 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// RebaseOrigin moves the origin of the spatial hash to (dx, dy), for floating-origin worlds
+// that periodically recenter to keep float coordinates precise. Every cell-keyed state, such
+// as buckets, blocked cells, bounding boxes, hysteresis placement, flow counts and the world
+// bounds, is shifted wholesale, so nodes keep their buckets and nothing is removed or put
+// again: no hooks are called and no cell events are delivered.
+//
+// The caller must first shift the position and old position of every node by (-dx, -dy).
+// dx and dy must be whole multiples of the cell size, so that every node stays in the shifted
+// cell; RebaseOrigin panics otherwise. With float coordinates, pick a shift that is exactly
+// representable, such as a power of two or a whole number of cells of such a size.
+//
+// RebaseOrigin waits for in-flight operations and holds off new ones, as Drain does. Cursors,
+// retained queries and subscriptions are not shifted, and should be created again.
+func (sh *SpatialHash[Id, N]) RebaseOrigin(dx, dy N) {
+	kx, ky := sh.wholeCells(dx), sh.wholeCells(dy)
+	if kx == 0 && ky == 0 {
+		return
+	}
+
+	sh.drainMu.Lock()
+	defer sh.drainMu.Unlock()
+
+	shift := func(c cell) cell { return cell{c.x - kx, c.y - ky} }
+
+	rekey(sh.buckets, shift)
+	rekey(sh.blocked, shift)
+
+	sh.bounded.Range(func(id Id, st boundedState) bool {
+		st.span = CellRect{st.span.MinX - kx, st.span.MinY - ky, st.span.MaxX - kx, st.span.MaxY - ky}
+		st.homeX, st.homeY = st.homeX-kx, st.homeY-ky
+
+		sh.bounded.Store(id, st)
+
+		return true
+	})
+
+	if sh.placed != nil {
+		sh.placed.Range(func(id Id, c cell) bool {
+			sh.placed.Store(id, shift(c))
+
+			return true
+		})
+	}
+
+	if sh.flow != nil {
+		rekey(sh.flow.cells, shift)
+	}
+
+	if sh.exact != nil {
+		sh.exact.positions.Range(func(id Id, pos position[N]) bool {
+			sh.exact.positions.Store(id, position[N]{pos.x - dx, pos.y - dy})
+
+			return true
+		})
+
+		rekey(sh.exact.nodes, func(pos position[N]) position[N] { return position[N]{pos.x - dx, pos.y - dy} })
+	}
+
+	if sh.interp != nil {
+		sh.interp.starts.Range(func(id Id, start stepStart[N]) bool {
+			start.x, start.y = start.x-dx, start.y-dy
+
+			sh.interp.starts.Store(id, start)
+
+			return true
+		})
+	}
+
+	if sh.world != nil {
+		sh.SetWorldBounds(Rect[N]{sh.world.MinX - dx, sh.world.MinY - dy, sh.world.MaxX - dx, sh.world.MaxY - dy})
+	}
+}
+
+// wholeCells returns how many cells d spans, panicking if it is not a whole number of them.
+func (sh *SpatialHash[Id, N]) wholeCells(d N) int {
+	k := math.Round(float64(d) / float64(sh.cellSize))
+
+	if math.IsInf(k, 0) || N(k)*sh.cellSize != d {
+		panic(fmt.Sprintf("spatial_hash: origin shift %v is not a multiple of the cell size %v", d, sh.cellSize))
+	}
+
+	return int(k)
+}
+
+// rekey moves every value of m to the key f returns for its key. The new keys must be distinct.
+func rekey[K comparable, V any](m *xsync.Map[K, V], f func(key K) K) {
+	type entry struct {
+		key   K
+		value V
+	}
+
+	var entries []entry
+
+	m.Range(func(key K, value V) bool {
+		entries = append(entries, entry{key, value})
+
+		return true
+	})
+
+	m.Clear()
+
+	for _, e := range entries {
+		m.Store(f(e.key), e.value)
+	}
+}
@@ -0,0 +1,153 @@
+package spatial_hash
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestSpatialHashRebaseOrigin(t *testing.T) {
+	nodes := CreateTestNodes(2000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](16)
+	sh.SetExactIndex(true)
+	sh.SetFlowWindow(4)
+	sh.SetWorldBounds(Rect[float64]{0, 0, 1000, 1000})
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	box := newBoxPoint(2000, 500, 500, 40, 40)
+	sh.PutBounded(box)
+
+	sh.SetCellBlocked(10, 10, true)
+
+	cx, cy := sh.CellOf(nodes[1].x, nodes[1].y)
+	arrivals, _ := sh.CellFlow(cx, cy)
+
+	// Recenter on (512, 512)
+	const dx, dy = 512, 512
+
+	for _, n := range nodes {
+		n.x, n.y = n.x-dx, n.y-dy
+		n.oldX, n.oldY = n.x, n.y
+	}
+
+	box.x, box.y = box.x-dx, box.y-dy
+	box.oldX, box.oldY = box.x, box.y
+
+	sh.RebaseOrigin(dx, dy)
+
+	for range 200 {
+		x, y := 1000*rand.Float64()-dx, 1000*rand.Float64()-dy
+
+		got := slices.DeleteFunc(sh.Search(x, y, 60), func(n Node[int, float64]) bool { return n == box })
+
+		if want := len(NaiveSearch(nodes, x, y, 60)); len(got) != want {
+			t.Fatalf("Search at (%v, %v): expected %d nodes, got %d", x, y, want, len(got))
+		}
+	}
+
+	if got := sh.QueryRect(0, 0, 1, 1); !hasNode(got, box) {
+		t.Error("Expected the bounded node at the new origin")
+	}
+
+	if !sh.IsCellBlocked(10-32, 10-32) || sh.IsCellBlocked(10, 10) {
+		t.Error("Expected the blocked cell to be shifted")
+	}
+
+	if got, _ := sh.CellFlow(cx-32, cy-32); arrivals == 0 || got != arrivals {
+		t.Errorf("Expected %d arrivals in the shifted cell, got %d", arrivals, got)
+	}
+
+	if bounds, _ := sh.WorldBounds(); bounds != (Rect[float64]{-dx, -dy, 1000 - dx, 1000 - dy}) {
+		t.Errorf("Expected shifted world bounds, got %v", bounds)
+	}
+
+	n := nodes[0]
+
+	if got := sh.AtPosition(n.x, n.y); !hasNode(got, n) {
+		t.Error("Expected the exact index to be shifted")
+	}
+
+	// Nodes still move and are removed from their shifted cells
+	n.x, n.y = n.x+300, n.y+300
+	sh.Update(n)
+
+	if got := sh.Search(n.x, n.y, 0); !hasNode(got, n) {
+		t.Error("Expected the node at its new position")
+	}
+
+	sh.Remove(n)
+
+	if got := sh.Search(n.x, n.y, 0); hasNode(got, n) {
+		t.Error("Expected the node to be removed")
+	}
+}
+
+func TestSpatialHashRebaseOriginHysteresis(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetHysteresis(0.25)
+
+	p := newPoint(0, 9, 5)
+	sh.Put(p)
+
+	// Kept in cell (0, 0) by hysteresis
+	p.x = 11
+	sh.Update(p)
+
+	p.x, p.y = p.x-100, p.y+50
+	p.oldX, p.oldY = p.x, p.y
+
+	sh.RebaseOrigin(100, -50)
+
+	if got := sh.QueryRect(-95, 55, 0, 0); !hasNode(got, p) {
+		t.Error("Expected the node kept in the shifted cell")
+	}
+
+	p.x = -85
+	sh.Update(p)
+
+	if got := sh.QueryRect(-95, 55, 0, 0); hasNode(got, p) {
+		t.Error("Expected the node to migrate out of the shifted cell")
+	}
+}
+
+func TestSpatialHashRebaseOriginDistantCells(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	p, q := newPoint(0, 5, 655375), newPoint(1, 15, 15)
+
+	sh.Put(p)
+	sh.Put(q)
+
+	// Shifted into cells (0, 65536) and (1, 0), which used to share a bucket key
+	for _, n := range []*Point{p, q} {
+		n.y -= 10
+		n.oldY = n.y
+	}
+
+	sh.RebaseOrigin(0, 10)
+
+	for _, n := range []*Point{p, q} {
+		if got := sh.Search(n.x, n.y, 1); len(got) != 1 || !hasNode(got, n) {
+			t.Errorf("Expected node %d at its shifted position, got %d nodes", n.id, len(got))
+		}
+	}
+}
+
+func TestSpatialHashRebaseOriginInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a shift that is not a multiple of the cell size")
+		}
+	}()
+
+	NewSpatialHash[int, float64](10).RebaseOrigin(15, 0)
+}
+
+// hasNode reports whether nodes holds a node with the id of n.
+func hasNode(nodes NodeSlice[int, float64], n Node[int, float64]) bool {
+	return slices.ContainsFunc(nodes, func(other Node[int, float64]) bool { return other.GetId() == n.GetId() })
+}
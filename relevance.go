@@ -0,0 +1,151 @@
+package spatial_hash
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Candidate is a node within range of an observer, rated by a Scorer.
+type Candidate[Id comparable, N Number] struct {
+	Node Node[Id, N]
+
+	// Distance is the distance from the observer to the node, or to its bounding box for
+	// nodes put with PutBounded.
+	Distance float64
+
+	// Skipped is the number of consecutive refreshes the node was in range but left out of
+	// the observer's relevant set, or zero if it was in the set or out of range last time.
+	Skipped int
+}
+
+// Scorer rates how relevant a candidate is to observer. Higher scores are more relevant.
+type Scorer[Id comparable, N Number] func(observer Node[Id, N], c Candidate[Id, N]) float64
+
+// ScoreByDistance scores candidates from 1 at the observer down to 0 at radius and beyond.
+func ScoreByDistance[Id comparable, N Number](radius float64) Scorer[Id, N] {
+	return func(_ Node[Id, N], c Candidate[Id, N]) float64 {
+		return max(1-c.Distance/radius, 0)
+	}
+}
+
+// ScoreByImportance scores candidates by a fixed importance per node, such as a higher
+// importance for players than for props, as returned by importance.
+func ScoreByImportance[Id comparable, N Number](importance func(n Node[Id, N]) float64) Scorer[Id, N] {
+	return func(_ Node[Id, N], c Candidate[Id, N]) float64 {
+		return importance(c.Node)
+	}
+}
+
+// ScoreBySkipped scores candidates by perSkip for every refresh they were left out of the
+// relevant set, so that low-scoring nodes in range are eventually sent instead of starving.
+func ScoreBySkipped[Id comparable, N Number](perSkip float64) Scorer[Id, N] {
+	return func(_ Node[Id, N], c Candidate[Id, N]) float64 {
+		return perSkip * float64(c.Skipped)
+	}
+}
+
+// SumScores combines scorers by adding their scores.
+func SumScores[Id comparable, N Number](scorers ...Scorer[Id, N]) Scorer[Id, N] {
+	return func(observer Node[Id, N], c Candidate[Id, N]) float64 {
+		score := 0.0
+
+		for _, s := range scorers {
+			score += s(observer, c)
+		}
+
+		return score
+	}
+}
+
+// scoredCandidate is a candidate with its score.
+type scoredCandidate[Id comparable, N Number] struct {
+	Candidate[Id, N]
+
+	score float64
+}
+
+// relevance is the state of a query keeping only the most relevant nodes in range.
+type relevance[Id comparable, N Number] struct {
+	k     int
+	score Scorer[Id, N]
+
+	// skipped holds the Skipped count of every node left out by the last refresh,
+	// and spare is the map the next refresh fills.
+	skipped, spare map[Id]int
+
+	candidates []scoredCandidate[Id, N]
+}
+
+// RegisterRelevant adds a recurring search like Register, but keeps only the k nodes in range
+// scoring highest with score, most relevant first, so that each observer receives a bounded,
+// prioritized set instead of everything in range. Interning does not apply to such queries,
+// whose results are bounded by k.
+func (s *Scheduler[Id, N]) RegisterRelevant(observer Node[Id, N], radius N, k int, score Scorer[Id, N]) *RecurringQuery[Id, N] {
+	return s.register(observer, radius, &relevance[Id, N]{
+		k:     max(k, 0),
+		score: score,
+
+		skipped: make(map[Id]int),
+		spare:   make(map[Id]int),
+	})
+}
+
+// refreshRelevant searches again for the results of q, keeping the most relevant ones.
+func (s *Scheduler[Id, N]) refreshRelevant(q *RecurringQuery[Id, N]) {
+	sh, r := s.sh, q.relevance
+
+	id := q.observer.GetId()
+	x, y := sh.Quantize(q.observer.GetX()), sh.Quantize(q.observer.GetY())
+
+	r.candidates = r.candidates[:0]
+
+	sh.SearchFunc(x, y, q.radius, func(n Node[Id, N]) bool {
+		if n.GetId() == id {
+			return true
+		}
+
+		r.candidates = append(r.candidates, scoredCandidate[Id, N]{Candidate: Candidate[Id, N]{
+			Node: n,
+
+			Distance: math.Sqrt(float64(sh.nodeDistanceSq(n, x, y))),
+			Skipped:  r.skipped[n.GetId()],
+		}})
+
+		return true
+	})
+
+	for i := range r.candidates {
+		c := &r.candidates[i]
+
+		c.score = r.score(q.observer, c.Candidate)
+	}
+
+	// Ties go to the nearest node
+	slices.SortStableFunc(r.candidates, func(a, b scoredCandidate[Id, N]) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Distance, b.Distance)
+	})
+
+	// A new slice, so that readers of the previous one are not disturbed
+	results := make(NodeSlice[Id, N], 0, min(len(r.candidates), r.k))
+
+	clear(r.spare)
+
+	for i, c := range r.candidates {
+		if i < r.k {
+			results = append(results, c.Node)
+		} else {
+			r.spare[c.Node.GetId()] = c.Skipped + 1
+		}
+	}
+
+	q.results = results
+
+	r.skipped, r.spare = r.spare, r.skipped
+
+	clear(r.candidates)
+}
@@ -0,0 +1,81 @@
+package spatial_hash
+
+import "testing"
+
+func TestSchedulerRelevantByDistance(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	observer := newPoint(0, 0, 0)
+	sh.Put(observer)
+
+	for i := 1; i <= 20; i++ {
+		sh.Put(newPoint(i, float64(i), 0))
+	}
+
+	s := NewScheduler(sh, 1)
+
+	q := s.RegisterRelevant(observer, 15, 5, ScoreByDistance[int, float64](15))
+
+	results := q.Results()
+
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+
+	for i, n := range results {
+		if n.GetId() != i+1 {
+			t.Errorf("Expected node %d at rank %d, got %d", i+1, i, n.GetId())
+		}
+	}
+}
+
+func TestSchedulerRelevantSkipped(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	observer := newPoint(0, 0, 0)
+	sh.Put(observer)
+
+	vip := newPoint(1, 8, 0)
+	sh.Put(vip)
+
+	for i := 2; i <= 5; i++ {
+		sh.Put(newPoint(i, float64(i), 0))
+	}
+
+	importance := func(n Node[int, float64]) float64 {
+		if n.GetId() == vip.id {
+			return 100
+		}
+
+		return 0
+	}
+
+	s := NewScheduler(sh, 1)
+
+	q := s.RegisterRelevant(observer, 10, 2, SumScores(
+		ScoreByImportance(importance),
+		ScoreByDistance[int, float64](10),
+		ScoreBySkipped[int, float64](1),
+	))
+
+	seen := make(map[int]int)
+
+	for range 8 {
+		results := q.Results()
+
+		if len(results) != 2 || results[0].GetId() != vip.id {
+			t.Fatalf("Expected the important node first, got %v", results)
+		}
+
+		seen[results[1].GetId()]++
+
+		s.Tick()
+	}
+
+	// Nodes left out gain priority until they are sent, so none of them starves
+	for i := 2; i <= 5; i++ {
+		if seen[i] == 0 {
+			t.Errorf("Expected node %d to be sent at least once", i)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package spatial_hash
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// retainedCell is a cell a retained query depends on, with the state it was computed from.
+type retainedCell[Id comparable, N Number] struct {
+	key cell
+
+	// bucket is the bucket of the cell, or nil if it had none.
+	bucket  *bucket[Id, N]
+	version uint64
+}
+
+// Handle holds the results of a retained shape query, which stay valid until a cell the shape
+// covers changes or Invalidate is called. Handle is safe for concurrent use.
+type Handle[Id comparable, N Number] struct {
+	sh *SpatialHash[Id, N]
+
+	shape Shape[N]
+	x, y  N
+
+	mu sync.Mutex
+
+	cells   []retainedCell[Id, N]
+	results NodeSlice[Id, N]
+
+	invalidated atomic.Bool
+}
+
+// Retain runs a query for the nodes inside shape centered on (x, y), and keeps its results in a
+// handle. Reading them is free until a node is put, updated or removed in one of the cells the
+// shape covers, which Dirty detects by comparing per-cell versions instead of running the query.
+// Displays such as a "nearby players" panel then only recompute when something changed nearby.
+// Bounded nodes are treated as points at their center.
+func (sh *SpatialHash[Id, N]) Retain(shape Shape[N], x, y N) *Handle[Id, N] {
+	h := &Handle[Id, N]{
+		sh: sh,
+
+		shape: shape,
+		x:     x,
+		y:     y,
+	}
+
+	h.refresh()
+
+	return h
+}
+
+// Results returns the nodes inside the shape, running the query again first if the handle is dirty.
+// The slice must not be modified, and is replaced rather than reused when the query runs again.
+func (h *Handle[Id, N]) Results() NodeSlice[Id, N] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dirty() {
+		h.refresh()
+	}
+
+	return h.results
+}
+
+// Dirty reports whether a cell the shape covers has changed, or Invalidate was called, since
+// the results were computed. It costs one lookup per cell, however many nodes they hold.
+func (h *Handle[Id, N]) Dirty() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.dirty()
+}
+
+// Invalidate marks the results as outdated, so that the next Results runs the query again.
+// Use it for changes the spatial hash cannot see, such as a filter on node attributes.
+func (h *Handle[Id, N]) Invalidate() {
+	h.invalidated.Store(true)
+}
+
+func (h *Handle[Id, N]) dirty() bool {
+	if h.invalidated.Load() {
+		return true
+	}
+
+	for _, c := range h.cells {
+		b, _ := h.sh.buckets.Load(c.key)
+
+		if b != c.bucket || (b != nil && b.version.Load() != c.version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refresh runs the query again, recording the versions of the cells it covers first, so that a
+// change made while it runs makes the handle dirty.
+func (h *Handle[Id, N]) refresh() {
+	sh := h.sh
+
+	h.invalidated.Store(false)
+
+	t := sh.drainMu.RLock()
+
+	x, y := sh.Quantize(h.x), sh.Quantize(h.y)
+
+	halfWidth, halfHeight := h.shape.HalfExtents()
+
+	minX, minY, maxX, maxY := sh.queryCells(x, y, halfWidth, halfHeight)
+
+	fx, fy := float64(x), float64(y)
+
+	cells := make([]retainedCell[Id, N], 0, len(h.cells))
+
+	for yy := minY; yy <= maxY; yy++ {
+		for xx := minX; xx <= maxX; xx++ {
+			if h.shape.Classify(sh.cellBounds(xx, yy, fx, fy)) == CoverageOutside {
+				continue
+			}
+
+			c := retainedCell[Id, N]{key: cell{xx, yy}}
+
+			if b, ok := sh.buckets.Load(c.key); ok {
+				c.bucket, c.version = b, b.version.Load()
+			}
+
+			cells = append(cells, c)
+		}
+	}
+
+	sh.drainMu.RUnlock(t)
+
+	var results NodeSlice[Id, N]
+
+	sh.queryShape(h.shape, x, y, nil, func(n Node[Id, N]) bool {
+		results = append(results, n)
+
+		return true
+	})
+
+	h.cells, h.results = cells, results
+}
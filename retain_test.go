@@ -0,0 +1,67 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashRetain(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	near := newPoint(0, 52, 50)
+	far := newPoint(1, 500, 500)
+	sh.Put(near)
+	sh.Put(far)
+
+	h := sh.Retain(Circle[float64]{Radius: 15}, 50, 50)
+
+	if len(h.Results()) != 1 || h.Dirty() {
+		t.Fatalf("Expected one clean result, got %d", len(h.Results()))
+	}
+
+	// Changes far away do not affect the handle
+	far.x = 520
+	sh.Update(far)
+	sh.Put(newPoint(2, 300, 300))
+
+	if h.Dirty() {
+		t.Error("Expected changes outside the shape to leave the handle clean")
+	}
+
+	// Moving within a cell the shape covers does
+	near.x = 55
+	sh.Update(near)
+
+	if !h.Dirty() {
+		t.Error("Expected a move within a covered cell to make the handle dirty")
+	}
+
+	if len(h.Results()) != 1 || h.Dirty() {
+		t.Error("Expected Results to recompute and clean the handle")
+	}
+
+	// So does a node entering
+	arriving := newPoint(3, 45, 45)
+	sh.Put(arriving)
+
+	if !h.Dirty() || len(h.Results()) != 2 {
+		t.Errorf("Expected the arriving node in the results, got %d", len(h.Results()))
+	}
+
+	sh.Remove(arriving)
+
+	if !h.Dirty() || len(h.Results()) != 1 {
+		t.Errorf("Expected the removed node gone from the results, got %d", len(h.Results()))
+	}
+
+	h.Invalidate()
+
+	if !h.Dirty() {
+		t.Error("Expected Invalidate to make the handle dirty")
+	}
+
+	h.Results()
+
+	sh.Reset()
+
+	if !h.Dirty() || len(h.Results()) != 0 {
+		t.Error("Expected Reset to empty the results")
+	}
+}
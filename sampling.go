@@ -0,0 +1,256 @@
+package spatial_hash
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Sampling queries draw their randomness from a caller-provided *rand.Rand, or from an
+// unseeded generator if it is nil.
+//
+// Reproducibility: given generators in the same state, the same nodes at the same positions
+// and the same cell size, sampling queries return identical results, regardless of the order
+// nodes were put in, on every platform. Candidates are ordered by cell, then position, then Id
+// before sampling, and sampling uses no platform-dependent floating point.
+
+// samplingRand returns rng, or a randomly seeded generator if it is nil.
+func samplingRand(rng *rand.Rand) *rand.Rand {
+	if rng == nil {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	return rng
+}
+
+// compareIds orders ids of ordered types naturally, and other ids by their printed form.
+func compareIds[Id comparable](a, b Id) int {
+	switch a := any(a).(type) {
+	case int:
+		return cmp.Compare(a, any(b).(int))
+	case int64:
+		return cmp.Compare(a, any(b).(int64))
+	case int32:
+		return cmp.Compare(a, any(b).(int32))
+	case uint:
+		return cmp.Compare(a, any(b).(uint))
+	case uint64:
+		return cmp.Compare(a, any(b).(uint64))
+	case uint32:
+		return cmp.Compare(a, any(b).(uint32))
+	case string:
+		return cmp.Compare(a, any(b).(string))
+	default:
+		return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+// sortedCandidates returns the nodes in the cells from (minX, minY) to (maxX, maxY) for which
+// keep returns true, in the deterministic order sampling relies on.
+func (sh *SpatialHash[Id, N]) sortedCandidates(cells CellRect, keep func(n Node[Id, N]) bool) NodeSlice[Id, N] {
+	var candidates NodeSlice[Id, N]
+
+	cells = sh.clampCells(cells)
+
+	for cy := cells.MinY; cy <= cells.MaxY; cy++ {
+		for cx := cells.MinX; cx <= cells.MaxX; cx++ {
+			bucket, ok := sh.cellBucket(cx, cy)
+			if !ok {
+				continue
+			}
+
+			start := len(candidates)
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				if keep(n) {
+					candidates = append(candidates, n)
+				}
+
+				return true
+			})
+
+			slices.SortFunc(candidates[start:], func(a, b Node[Id, N]) int {
+				return cmp.Or(
+					cmp.Compare(a.GetY(), b.GetY()),
+					cmp.Compare(a.GetX(), b.GetX()),
+					compareIds(a.GetId(), b.GetId()),
+				)
+			})
+		}
+	}
+
+	return candidates
+}
+
+// RandomNodeInRadius returns a node chosen uniformly at random among the nodes within radius
+// of (x, y), drawing randomness from rng. ok is false if there is no such node.
+func (sh *SpatialHash[Id, N]) RandomNodeInRadius(rng *rand.Rand, x, y, radius N) (n Node[Id, N], ok bool) {
+	if !(radius >= 0) {
+		return nil, false
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	radiusSq := radius * radius
+
+	var cells CellRect
+	cells.MinX, cells.MinY, cells.MaxX, cells.MaxY = sh.queryCells(x, y, radius, radius)
+
+	candidates := sh.sortedCandidates(cells, func(n Node[Id, N]) bool {
+		return distanceSq(sh.Quantize(n.GetX())-x, sh.Quantize(n.GetY())-y) <= radiusSq
+	})
+
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	return candidates[samplingRand(rng).IntN(len(candidates))], true
+}
+
+// RandomNodeInRect returns a node chosen uniformly at random among the nodes inside rect,
+// drawing randomness from rng. ok is false if there is no such node.
+func (sh *SpatialHash[Id, N]) RandomNodeInRect(rng *rand.Rand, rect Rect[N]) (n Node[Id, N], ok bool) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	candidates := sh.sortedCandidates(sh.rectCells(rect), func(n Node[Id, N]) bool {
+		return rect.Contains(sh.Quantize(n.GetX()), sh.Quantize(n.GetY()))
+	})
+
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	return candidates[samplingRand(rng).IntN(len(candidates))], true
+}
+
+// PoissonDisk samples up to maxPoints random points inside rect, no two closer than minDistance,
+// and none closer than minDistance to a node in the spatial hash, drawing randomness from rng.
+// It uses Bridson's algorithm with 30 attempts per active point, so the points cover the free
+// space of rect evenly, which suits spawn point selection.
+func (sh *SpatialHash[Id, N]) PoissonDisk(rng *rand.Rand, rect Rect[N], minDistance N, maxPoints int) []Vec2[N] {
+	const attempts = 30
+
+	rng = samplingRand(rng)
+
+	if !(minDistance > 0) || maxPoints <= 0 {
+		return nil
+	}
+
+	minX, minY := float64(rect.MinX), float64(rect.MinY)
+	width, height := float64(rect.MaxX)-minX, float64(rect.MaxY)-minY
+
+	// Squared distances go through distanceSq and products are rounded explicitly, so that
+	// no multiply-add is fused and samples are the same on every platform
+	r := float64(minDistance)
+	rSq := float64(r * r)
+
+	// Background grid with one sample at most per cell, holding only the cells of samples so
+	// that its size does not depend on the area of rect
+	gridCell := r / math.Sqrt2
+
+	grid := make(map[[2]int]int)
+
+	var points [][2]float64
+
+	gridCellOf := func(p [2]float64) [2]int {
+		return [2]int{int((p[0] - minX) / gridCell), int((p[1] - minY) / gridCell)}
+	}
+
+	free := func(p [2]float64) bool {
+		if p[0] < minX || p[0] > minX+width || p[1] < minY || p[1] > minY+height {
+			return false
+		}
+
+		g := gridCellOf(p)
+
+		for y := g[1] - 2; y <= g[1]+2; y++ {
+			for x := g[0] - 2; x <= g[0]+2; x++ {
+				if i, ok := grid[[2]int{x, y}]; ok {
+					if distanceSq(points[i][0]-p[0], points[i][1]-p[1]) < rSq {
+						return false
+					}
+				}
+			}
+		}
+
+		nearby := false
+
+		var cells CellRect
+		cells.MinX, cells.MinY, cells.MaxX, cells.MaxY = sh.queryCells(N(p[0]), N(p[1]), N(r), N(r))
+
+		sh.forEachInCells(cells.MinX, cells.MinY, cells.MaxX, cells.MaxY, func(n Node[Id, N]) bool {
+			nearby = distanceSq(float64(sh.Quantize(n.GetX()))-p[0], float64(sh.Quantize(n.GetY()))-p[1]) < rSq
+
+			return !nearby
+		})
+
+		return !nearby
+	}
+
+	add := func(p [2]float64) {
+		grid[gridCellOf(p)] = len(points)
+		points = append(points, p)
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	// Seed with the first free random point
+	for range attempts {
+		if p := [2]float64{minX + float64(width*rng.Float64()), minY + float64(height*rng.Float64())}; free(p) {
+			add(p)
+
+			break
+		}
+	}
+
+	active := make([]int, len(points))
+
+	for i := range active {
+		active[i] = i
+	}
+
+	for len(active) > 0 && len(points) < maxPoints {
+		k := rng.IntN(len(active))
+		center := points[active[k]]
+
+		found := false
+
+		for range attempts {
+			// Rejection sampling of the annulus between r and 2r, avoiding trigonometry
+			dx, dy := float64(4*r*(rng.Float64()-0.5)), float64(4*r*(rng.Float64()-0.5))
+
+			if distSq := distanceSq(dx, dy); distSq < rSq || distSq > 4*rSq {
+				continue
+			}
+
+			if p := [2]float64{center[0] + dx, center[1] + dy}; free(p) {
+				active = append(active, len(points))
+				add(p)
+
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			active[k] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	result := make([]Vec2[N], len(points))
+
+	for i, p := range points {
+		result[i] = Vec2[N]{N(p[0]), N(p[1])}
+	}
+
+	return result
+}
@@ -0,0 +1,111 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// newSampledHashes returns two spatial hashes holding the same nodes, put in opposite orders.
+func newSampledHashes(nodes []*Point) (*SpatialHash[int, float64], *SpatialHash[int, float64]) {
+	a, b := NewSpatialHash[int, float64](25), NewSpatialHash[int, float64](25)
+
+	for i := range nodes {
+		a.Put(nodes[i])
+		b.Put(nodes[len(nodes)-1-i])
+	}
+
+	return a, b
+}
+
+func TestSpatialHashRandomNodeReproducible(t *testing.T) {
+	nodes := CreateTestNodes(2000, 500, 500)
+
+	// Co-located nodes are ordered by id
+	nodes = append(nodes, newPoint(len(nodes), 250, 250), newPoint(len(nodes)+1, 250, 250))
+
+	a, b := newSampledHashes(nodes)
+
+	rngA, rngB := rand.New(rand.NewPCG(42, 7)), rand.New(rand.NewPCG(42, 7))
+
+	for range 200 {
+		x, y := 500*rand.Float64(), 500*rand.Float64()
+
+		na, okA := a.RandomNodeInRadius(rngA, x, y, 40)
+		nb, okB := b.RandomNodeInRadius(rngB, x, y, 40)
+
+		if okA != okB || (okA && na.GetId() != nb.GetId()) {
+			t.Fatalf("Same seed sampled different nodes around (%v, %v)", x, y)
+		}
+
+		if okA && math.Hypot(na.GetX()-x, na.GetY()-y) > 40 {
+			t.Fatalf("Sampled node %d outside the radius", na.GetId())
+		}
+
+		rect := Rect[float64]{MinX: x - 30, MinY: y - 20, MaxX: x + 30, MaxY: y + 20}
+
+		na, okA = a.RandomNodeInRect(rngA, rect)
+		nb, okB = b.RandomNodeInRect(rngB, rect)
+
+		if okA != okB || (okA && na.GetId() != nb.GetId()) {
+			t.Fatalf("Same seed sampled different nodes in %+v", rect)
+		}
+
+		if okA && !rect.Contains(na.GetX(), na.GetY()) {
+			t.Fatalf("Sampled node %d outside the rect", na.GetId())
+		}
+	}
+}
+
+func TestSpatialHashPoissonDisk(t *testing.T) {
+	const minDistance = 20
+
+	nodes := CreateTestNodes(50, 500, 500)
+
+	a, b := newSampledHashes(nodes)
+
+	rect := Rect[float64]{MinX: 0, MinY: 0, MaxX: 500, MaxY: 500}
+
+	points := a.PoissonDisk(rand.New(rand.NewPCG(1, 2)), rect, minDistance, 1000)
+
+	if !slices.Equal(points, b.PoissonDisk(rand.New(rand.NewPCG(1, 2)), rect, minDistance, 1000)) {
+		t.Fatal("Same seed produced different samples")
+	}
+
+	// Roughly the number of disks that fit, minus the space taken by nodes
+	if len(points) < 200 {
+		t.Errorf("Expected the rect to be covered, got only %d points", len(points))
+	}
+
+	for i, p := range points {
+		if !rect.Contains(p.X, p.Y) {
+			t.Fatalf("Point %v outside the rect", p)
+		}
+
+		for _, q := range points[i+1:] {
+			if math.Hypot(p.X-q.X, p.Y-q.Y) < minDistance {
+				t.Fatalf("Points %v and %v closer than %v", p, q, minDistance)
+			}
+		}
+
+		for _, n := range nodes {
+			if math.Hypot(p.X-n.x, p.Y-n.y) < minDistance {
+				t.Fatalf("Point %v closer than %v to node %d", p, minDistance, n.id)
+			}
+		}
+	}
+}
+
+func TestSpatialHashPoissonDiskLargeRect(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// The background grid of a million units at a distance of 1 would hold a trillion cells
+	rect := Rect[float64]{MinX: 0, MinY: 0, MaxX: 1e6, MaxY: 1e6}
+
+	points := sh.PoissonDisk(rand.New(rand.NewPCG(1, 2)), rect, 1, 100)
+
+	if len(points) != 100 {
+		t.Errorf("Expected 100 points, got %d", len(points))
+	}
+}
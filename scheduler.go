@@ -0,0 +1,449 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RecurringQuery is a search around an observer, refreshed by the Scheduler it was registered
+// with. Its results are those of the last refresh, so they may be a few ticks stale.
+type RecurringQuery[Id comparable, N Number] struct {
+	observer Node[Id, N]
+	radius   N
+
+	// phase is the tick, modulo the period, at which the query is refreshed.
+	phase int
+	// index is the position of the query in its phase.
+	index int
+
+	results NodeSlice[Id, N]
+
+	// x and y are the quantized position of the observer at the last refresh, and cells the
+	// member lists of the cells searched then, used instead of results when interning.
+	x, y  N
+	cells []internedCell[Id, N]
+
+	// relevance keeps only the most relevant results, or is nil to keep all of them.
+	relevance *relevance[Id, N]
+
+	// refreshedAt is the scheduler tick of the last refresh.
+	refreshedAt int
+
+	scheduler *Scheduler[Id, N]
+	// registered is whether the query is still refreshed by its scheduler.
+	registered bool
+}
+
+// Scheduler refreshes recurring queries, such as the neighbor list of every agent, spread
+// evenly over a period of ticks instead of all at once, so that thousands of queries do not
+// all land on the same tick. Every query is refreshed once per period, which bounds how stale
+// its results can get. Scheduler is safe for concurrent use.
+type Scheduler[Id comparable, N Number] struct {
+	sh *SpatialHash[Id, N]
+
+	mu sync.Mutex
+
+	// phases holds the queries refreshed on each tick of the period.
+	phases [][]*RecurringQuery[Id, N]
+
+	tick int
+
+	// interning is whether queries share per-cell member lists instead of holding their results.
+	interning bool
+	// cells holds the member lists of the cells snapshotted during the current tick, by cell key.
+	cells map[cell]internedCell[Id, N]
+
+	// approximate is whether the tick being run refreshes plain searches from their candidate
+	// cells, as a degrading Ticker requests.
+	approximate bool
+}
+
+// internedEntry is a node in a snapshot of a cell, with its position at the time.
+type internedEntry[Id comparable, N Number] struct {
+	node Node[Id, N]
+
+	x, y N
+	// halfWidth and halfHeight are the half extents of bounded nodes, and zero for other nodes.
+	halfWidth, halfHeight N
+
+	spanning, home bool
+}
+
+// internedCell is a snapshot of the nodes in a cell, shared by every query searching the cell
+// during the same tick.
+type internedCell[Id comparable, N Number] []internedEntry[Id, N]
+
+// NewScheduler creates a scheduler refreshing queries on sh once every period ticks, so that
+// results are at most period-1 ticks old. A period of 1 refreshes every query on every tick.
+// It panics if period is less than 1.
+func NewScheduler[Id comparable, N Number](sh *SpatialHash[Id, N], period int) *Scheduler[Id, N] {
+	if period < 1 {
+		panic(fmt.Sprintf("spatial_hash: invalid scheduler period %d", period))
+	}
+
+	return &Scheduler[Id, N]{
+		sh:     sh,
+		phases: make([][]*RecurringQuery[Id, N], period),
+	}
+}
+
+// SetInterning makes queries refreshed during the same tick share one snapshot of the nodes in
+// every cell they search, instead of each holding a list of its results. A query then only
+// holds references to the snapshots of its cells, and filters them by distance as its results
+// are read. In crowds, where many observers see the same neighbors, this bounds the memory
+// used by queries by the number of cells rather than by observers times neighbors, at the
+// cost of filtering on every read.
+//
+// SetInterning must be called before any query is registered.
+func (s *Scheduler[Id, N]) SetInterning(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.interning = enabled
+
+	if enabled {
+		s.cells = make(map[cell]internedCell[Id, N])
+	} else {
+		s.cells = nil
+	}
+}
+
+// Register adds a recurring search for the nodes within radius of observer, excluding observer
+// itself, and refreshes it right away. The query is refreshed on the least busy tick of the period.
+func (s *Scheduler[Id, N]) Register(observer Node[Id, N], radius N) *RecurringQuery[Id, N] {
+	return s.register(observer, radius, nil)
+}
+
+func (s *Scheduler[Id, N]) register(observer Node[Id, N], radius N, r *relevance[Id, N]) *RecurringQuery[Id, N] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	phase := 0
+
+	for i, queries := range s.phases {
+		if len(queries) < len(s.phases[phase]) {
+			phase = i
+		}
+	}
+
+	q := &RecurringQuery[Id, N]{
+		observer: observer,
+		radius:   radius,
+
+		phase: phase,
+		index: len(s.phases[phase]),
+
+		relevance: r,
+
+		scheduler:  s,
+		registered: true,
+	}
+
+	s.phases[phase] = append(s.phases[phase], q)
+
+	s.refresh(q)
+
+	return q
+}
+
+// Unregister stops refreshing q. It returns false if q is not registered with s.
+func (s *Scheduler[Id, N]) Unregister(q *RecurringQuery[Id, N]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q.scheduler != s || !q.registered {
+		return false
+	}
+
+	queries := s.phases[q.phase]
+
+	last := queries[len(queries)-1]
+	last.index = q.index
+	queries[q.index] = last
+
+	queries[len(queries)-1] = nil
+	s.phases[q.phase] = queries[:len(queries)-1]
+
+	q.registered = false
+
+	return true
+}
+
+// Len returns the number of registered queries.
+func (s *Scheduler[Id, N]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+
+	for _, queries := range s.phases {
+		n += len(queries)
+	}
+
+	return n
+}
+
+// Tick advances the scheduler by one tick, refreshing the queries due on it, and returns the
+// number of refreshed queries. Call it once per tick, after the nodes have been updated.
+func (s *Scheduler[Id, N]) Tick() int {
+	refreshed, _ := s.advance(false)
+
+	return refreshed
+}
+
+// advance is Tick, refreshing plain searches from their candidate cells if approximate, without
+// per-node distance checks. It returns the number of refreshed queries, and how many of them
+// were approximated.
+func (s *Scheduler[Id, N]) advance(approximate bool) (refreshed, approximated int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tick++
+
+	// Snapshots are only shared within a tick, as nodes move between ticks
+	clear(s.cells)
+
+	queries := s.phases[s.tick%len(s.phases)]
+
+	s.approximate = approximate
+
+	for _, q := range queries {
+		if approximate && q.relevance == nil && !s.interning {
+			approximated++
+		}
+
+		s.refresh(q)
+	}
+
+	s.approximate = false
+
+	return len(queries), approximated
+}
+
+// due returns the number of queries the next tick refreshes.
+func (s *Scheduler[Id, N]) due() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.phases[(s.tick+1)%len(s.phases)])
+}
+
+// refresh searches again for the results of q.
+func (s *Scheduler[Id, N]) refresh(q *RecurringQuery[Id, N]) {
+	q.refreshedAt = s.tick
+
+	if q.relevance != nil {
+		s.refreshRelevant(q)
+
+		return
+	}
+
+	if s.interning {
+		s.intern(q)
+
+		return
+	}
+
+	id := q.observer.GetId()
+
+	// A new slice, so that readers of the previous one are not disturbed
+	results := make(NodeSlice[Id, N], 0, len(q.results))
+
+	if s.approximate {
+		s.sh.CandidatesFunc(Circle[N]{Radius: q.radius}, q.observer.GetX(), q.observer.GetY(), func(n Node[Id, N], _ bool) bool {
+			if n.GetId() != id {
+				results = append(results, n)
+			}
+
+			return true
+		})
+	} else {
+		s.sh.SearchFunc(q.observer.GetX(), q.observer.GetY(), q.radius, func(n Node[Id, N]) bool {
+			if n.GetId() != id {
+				results = append(results, n)
+			}
+
+			return true
+		})
+	}
+
+	q.results = results
+}
+
+// intern points q to the snapshots of the cells within its radius, taking the missing ones.
+func (s *Scheduler[Id, N]) intern(q *RecurringQuery[Id, N]) {
+	sh := s.sh
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y, radius := sh.Quantize(q.observer.GetX()), sh.Quantize(q.observer.GetY()), q.radius
+
+	q.x, q.y = x, y
+
+	// A new slice, so that readers of the previous one are not disturbed
+	cells := make([]internedCell[Id, N], 0, len(q.cells))
+
+	if radius >= 0 {
+		minX, minY, maxX, maxY := sh.queryCells(x, y, radius, radius)
+
+		circle := Circle[N]{radius}
+
+		fx, fy := float64(x), float64(y)
+
+		for yy := minY; yy <= maxY; yy++ {
+			for xx := minX; xx <= maxX; xx++ {
+				coverage := circle.Classify(sh.cellBounds(xx, yy, fx, fy))
+
+				if coverage == CoverageOutside && radius > 0 {
+					continue
+				}
+
+				key := cell{xx, yy}
+
+				cell, ok := s.cells[key]
+				if !ok {
+					cell = s.snapshot(key)
+					s.cells[key] = cell
+				}
+
+				if len(cell) > 0 {
+					cells = append(cells, cell)
+				}
+			}
+		}
+	}
+
+	q.cells = cells
+}
+
+// snapshot takes a snapshot of the nodes in the cell with the given key.
+func (s *Scheduler[Id, N]) snapshot(key cell) internedCell[Id, N] {
+	sh := s.sh
+
+	b, ok := sh.buckets.Load(key)
+	if !ok {
+		return nil
+	}
+
+	var cell internedCell[Id, N]
+
+	b.forEachEntry(func(e bucketEntry[Id, N]) bool {
+		entry := internedEntry[Id, N]{
+			node: e.node,
+
+			x: sh.Quantize(e.node.GetX()),
+			y: sh.Quantize(e.node.GetY()),
+
+			spanning: e.spanning,
+			home:     e.home,
+		}
+
+		if e.spanning {
+			bn := e.node.(BoundedNode[Id, N])
+
+			entry.halfWidth, entry.halfHeight = max(bn.GetHalfWidth(), 0), max(bn.GetHalfHeight(), 0)
+		}
+
+		cell = append(cell, entry)
+
+		return true
+	})
+
+	return cell
+}
+
+// Observer returns the node the query searches around.
+func (q *RecurringQuery[Id, N]) Observer() Node[Id, N] {
+	return q.observer
+}
+
+// Results returns the nodes found by the last refresh. Each refresh builds a new slice, so
+// the returned one is never modified and may be kept. With interning, Results builds a new
+// slice on every call: prefer ForEach.
+func (q *RecurringQuery[Id, N]) Results() NodeSlice[Id, N] {
+	q.scheduler.mu.Lock()
+	interning, results := q.scheduler.interning && q.relevance == nil, q.results
+	q.scheduler.mu.Unlock()
+
+	if !interning {
+		return results
+	}
+
+	results = nil
+
+	q.ForEach(func(n Node[Id, N]) bool {
+		results = append(results, n)
+
+		return true
+	})
+
+	return results
+}
+
+// ForEach calls fn for every node found by the last refresh, stopping early if fn returns false.
+func (q *RecurringQuery[Id, N]) ForEach(fn func(n Node[Id, N]) bool) {
+	s := q.scheduler
+
+	s.mu.Lock()
+	interning, results, cells := s.interning && q.relevance == nil, q.results, q.cells
+	x, y, radius := q.x, q.y, q.radius
+	s.mu.Unlock()
+
+	if !interning {
+		for _, n := range results {
+			if !fn(n) {
+				return
+			}
+		}
+
+		return
+	}
+
+	id := q.observer.GetId()
+
+	radiusSq := radius * radius
+
+	var seen spanSeen[Id]
+
+	for _, cell := range cells {
+		for _, e := range cell {
+			if !e.within(x, y, radius, radiusSq) || e.node.GetId() == id {
+				continue
+			}
+
+			if e.spanning && !seen.first(e.node.GetId()) {
+				continue
+			}
+
+			if !fn(e.node) {
+				return
+			}
+		}
+	}
+}
+
+// within reports whether e lies within radius of (x, y), as Search would find it.
+func (e *internedEntry[Id, N]) within(x, y, radius, radiusSq N) bool {
+	// A zero radius finds the nodes centered exactly on the point, like AtPosition
+	if radius == 0 {
+		return e.home && e.x == x && e.y == y
+	}
+
+	return distanceSq(boxGap(e.x-x, e.halfWidth), boxGap(e.y-y, e.halfHeight)) <= radiusSq
+}
+
+// Age returns the number of ticks since the query was last refreshed.
+// It returns -1 if the query is no longer registered.
+func (q *RecurringQuery[Id, N]) Age() int {
+	s := q.scheduler
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !q.registered {
+		return -1
+	}
+
+	return s.tick - q.refreshedAt
+}
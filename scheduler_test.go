@@ -0,0 +1,254 @@
+package spatial_hash
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSchedulerStaggersRefreshes(t *testing.T) {
+	sh := NewSpatialHash[int, float64](50)
+
+	nodes := CreateTestNodes(400, 1000, 1000)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	s := NewScheduler(sh, 4)
+
+	queries := make([]*RecurringQuery[int, float64], 100)
+
+	for i := range queries {
+		queries[i] = s.Register(nodes[i], 80)
+	}
+
+	for tick := range 8 {
+		// Move every node, so that stale results differ from fresh ones
+		for _, n := range nodes {
+			n.x, n.y = n.x+5, n.y-5
+
+			sh.Update(n)
+		}
+
+		if refreshed := s.Tick(); refreshed != 25 {
+			t.Fatalf("Tick %d: expected 25 refreshed queries, got %d", tick, refreshed)
+		}
+
+		fresh := 0
+
+		for _, q := range queries {
+			age := q.Age()
+
+			if age < 0 || age > 3 {
+				t.Fatalf("Expected an age within the period, got %d", age)
+			}
+
+			if age > 0 {
+				continue
+			}
+
+			fresh++
+
+			observer := q.Observer()
+
+			expected := 0
+
+			for _, n := range NaiveSearch(nodes, observer.GetX(), observer.GetY(), 80) {
+				if n.id != observer.GetId() {
+					expected++
+				}
+			}
+
+			if len(q.Results()) != expected {
+				t.Errorf("Expected %d results for a fresh query, got %d", expected, len(q.Results()))
+			}
+
+			for _, n := range q.Results() {
+				if n.GetId() == observer.GetId() {
+					t.Error("Expected the observer to be excluded from its results")
+				}
+			}
+		}
+
+		if fresh != 25 {
+			t.Errorf("Expected 25 fresh queries, got %d", fresh)
+		}
+	}
+}
+
+func TestSchedulerResultsDuringTick(t *testing.T) {
+	sh := NewSpatialHash[int, float64](50)
+
+	nodes := CreateTestNodes(200, 500, 500)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	s := NewScheduler(sh, 1)
+
+	q := s.Register(nodes[0], 100)
+	s.Tick()
+
+	kept := q.Results()
+	expected := len(kept)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for range 200 {
+			s.Tick()
+		}
+	}()
+
+	// Run with -race: readers must not see the slices refreshed by Tick
+	for range 200 {
+		for _, n := range q.Results() {
+			_ = n.GetId()
+		}
+
+		q.ForEach(func(n Node[int, float64]) bool { return n != nil })
+	}
+
+	wg.Wait()
+
+	if len(kept) != expected || (expected > 0 && kept[0] == nil) {
+		t.Error("Expected kept results to stay untouched by later refreshes")
+	}
+}
+
+func TestSchedulerUnregister(t *testing.T) {
+	sh := NewSpatialHash[int, float64](50)
+
+	nodes := CreateTestNodes(10, 100, 100)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	s := NewScheduler(sh, 2)
+
+	queries := make([]*RecurringQuery[int, float64], len(nodes))
+
+	for i, n := range nodes {
+		queries[i] = s.Register(n, 200)
+	}
+
+	// Results are available right after Register, without the observer
+	if found := len(queries[0].Results()); found != 9 {
+		t.Errorf("Expected 9 results right after Register, got %d", found)
+	}
+
+	if !s.Unregister(queries[3]) {
+		t.Fatal("Expected Unregister to succeed")
+	}
+
+	if s.Unregister(queries[3]) {
+		t.Error("Expected a second Unregister to fail")
+	}
+
+	if queries[3].Age() != -1 {
+		t.Errorf("Expected an unregistered query to have no age, got %d", queries[3].Age())
+	}
+
+	if s.Len() != 9 {
+		t.Errorf("Expected 9 registered queries, got %d", s.Len())
+	}
+
+	if refreshed := s.Tick() + s.Tick(); refreshed != 9 {
+		t.Errorf("Expected 9 refreshes over a period, got %d", refreshed)
+	}
+
+	// Registering fills the phase left short by the removal
+	s.Register(nodes[3], 200)
+
+	if a, b := s.Tick(), s.Tick(); a != 5 || b != 5 {
+		t.Errorf("Expected 5 refreshes per tick, got %d and %d", a, b)
+	}
+}
+
+func TestSchedulerInterning(t *testing.T) {
+	sh := NewSpatialHash[int, float64](25)
+
+	// A crowd in a small area, and a box spanning several cells
+	nodes := make([]*Point, 300)
+
+	for i := range nodes {
+		nodes[i] = newPoint(i, float64(i%20)*10+1, float64(i/20)*13+2)
+	}
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	sh.PutBounded(newBoxPoint(1000, 100, 100, 40, 40))
+
+	plain := NewScheduler(sh, 2)
+
+	interned := NewScheduler(sh, 2)
+	interned.SetInterning(true)
+
+	var plainQueries, internedQueries []*RecurringQuery[int, float64]
+
+	for _, n := range nodes[:50] {
+		plainQueries = append(plainQueries, plain.Register(n, 60))
+		internedQueries = append(internedQueries, interned.Register(n, 60))
+	}
+
+	for range 3 {
+		for _, n := range nodes {
+			n.oldX, n.oldY = n.x, n.y
+			n.x, n.y = n.x+3, n.y+3
+
+			sh.Update(n)
+		}
+
+		plain.Tick()
+		interned.Tick()
+
+		for i, q := range internedQueries {
+			expected := make(map[int]bool)
+
+			for _, n := range plainQueries[i].Results() {
+				expected[n.GetId()] = true
+			}
+
+			found := q.Results()
+
+			if len(found) != len(expected) {
+				t.Fatalf("Expected %d interned results, got %d", len(expected), len(found))
+			}
+
+			for _, n := range found {
+				if !expected[n.GetId()] {
+					t.Fatalf("Unexpected interned result %d", n.GetId())
+				}
+			}
+		}
+	}
+
+	// Queries refreshed on the same tick share the snapshots of their cells
+	a, b := internedQueries[0], internedQueries[2]
+
+	if a.Age() != b.Age() {
+		t.Fatal("Expected both queries to be refreshed on the same tick")
+	}
+
+	shared := 0
+
+	for _, ca := range a.cells {
+		for _, cb := range b.cells {
+			if &ca[0] == &cb[0] {
+				shared++
+			}
+		}
+	}
+
+	if shared == 0 {
+		t.Error("Expected overlapping queries to share cell snapshots")
+	}
+}
@@ -0,0 +1,278 @@
+package spatial_hash
+
+import "math"
+
+// Coverage describes how a region of offsets relates to a shape.
+type Coverage int
+
+const (
+	// CoverageOutside means no offset in the region lies inside the shape.
+	CoverageOutside Coverage = iota
+	// CoveragePartial means some offsets in the region may lie inside the shape.
+	CoveragePartial
+	// CoverageInside means every offset in the region lies inside the shape.
+	CoverageInside
+)
+
+// classifyMargin shrinks shapes by a relative amount when classifying regions as inside, and
+// grows them when classifying regions as outside, so that rounding in cell assignment or
+// per-node checks never makes a classification wrong for a node.
+const classifyMargin = 1e-9
+
+// Shape is a query region, described relative to its center.
+type Shape[N Number] interface {
+	// HalfExtents returns the half width and half height of the shape's bounding box.
+	HalfExtents() (halfWidth, halfHeight N)
+
+	// Contains reports whether the offset (dx, dy) from the center lies inside the shape.
+	Contains(dx, dy N) bool
+
+	// Classify returns how the region of offsets from (minDx, minDy) to (maxDx, maxDy)
+	// relates to the shape. Returning CoveragePartial is always correct, only slower.
+	Classify(minDx, minDy, maxDx, maxDy float64) Coverage
+}
+
+// Circle is a disk of the given radius.
+type Circle[N Number] struct {
+	Radius N
+}
+
+func (c Circle[N]) HalfExtents() (N, N) { return c.Radius, c.Radius }
+
+func (c Circle[N]) Contains(dx, dy N) bool {
+	return distanceSq(dx, dy) <= c.Radius*c.Radius
+}
+
+func (c Circle[N]) Classify(minDx, minDy, maxDx, maxDy float64) Coverage {
+	r := float64(c.Radius)
+
+	// Nearest point of the region to the center
+	nearX := min(max(0, minDx), maxDx)
+	nearY := min(max(0, minDy), maxDy)
+
+	if nearX*nearX+nearY*nearY > r*r*(1+classifyMargin) {
+		return CoverageOutside
+	}
+
+	// Farthest corner of the region from the center
+	farX := max(math.Abs(minDx), math.Abs(maxDx))
+	farY := max(math.Abs(minDy), math.Abs(maxDy))
+
+	if farX*farX+farY*farY <= r*r*(1-classifyMargin) {
+		return CoverageInside
+	}
+
+	return CoveragePartial
+}
+
+// Box is an axis-aligned rectangle of the given size, centered on the query center.
+type Box[N Number] struct {
+	Width, Height N
+}
+
+func (b Box[N]) HalfExtents() (N, N) { return b.Width / N(2), b.Height / N(2) }
+
+func (b Box[N]) Contains(dx, dy N) bool {
+	halfWidth, halfHeight := b.HalfExtents()
+
+	return dx >= -halfWidth && dx <= halfWidth && dy >= -halfHeight && dy <= halfHeight
+}
+
+func (b Box[N]) Classify(minDx, minDy, maxDx, maxDy float64) Coverage {
+	halfWidth, halfHeight := b.HalfExtents()
+	hw, hh := float64(halfWidth)*(1+classifyMargin), float64(halfHeight)*(1+classifyMargin)
+
+	if maxDx < -hw || minDx > hw || maxDy < -hh || minDy > hh {
+		return CoverageOutside
+	}
+
+	hw, hh = float64(halfWidth)*(1-classifyMargin), float64(halfHeight)*(1-classifyMargin)
+
+	if minDx >= -hw && maxDx <= hw && minDy >= -hh && maxDy <= hh {
+		return CoverageInside
+	}
+
+	return CoveragePartial
+}
+
+// Ellipse is an ellipse with radii RadiusX and RadiusY along its own axes,
+// rotated counterclockwise by Angle radians.
+type Ellipse[N Number] struct {
+	RadiusX, RadiusY N
+	Angle            N
+}
+
+// axes returns the sine and cosine of the ellipse's rotation.
+func (e Ellipse[N]) axes() (sin, cos float64) {
+	return math.Sincos(float64(e.Angle))
+}
+
+// unitDistSq returns the squared distance of offset (dx, dy) in units of the ellipse's radii,
+// which is at most 1 inside the ellipse.
+func (e Ellipse[N]) unitDistSq(dx, dy float64) float64 {
+	sin, cos := e.axes()
+
+	u := (dx*cos + dy*sin) / float64(e.RadiusX)
+	v := (-dx*sin + dy*cos) / float64(e.RadiusY)
+
+	return u*u + v*v
+}
+
+func (e Ellipse[N]) HalfExtents() (N, N) {
+	sin, cos := e.axes()
+	rx, ry := float64(e.RadiusX), float64(e.RadiusY)
+
+	halfWidth := math.Hypot(rx*cos, ry*sin) * (1 + classifyMargin)
+	halfHeight := math.Hypot(rx*sin, ry*cos) * (1 + classifyMargin)
+
+	// Round integer extents up, so that the bounding box never cuts the ellipse
+	if N(1)/2 == 0 {
+		halfWidth, halfHeight = math.Ceil(halfWidth), math.Ceil(halfHeight)
+	}
+
+	return N(halfWidth), N(halfHeight)
+}
+
+func (e Ellipse[N]) Contains(dx, dy N) bool {
+	return e.unitDistSq(float64(dx), float64(dy)) <= 1
+}
+
+func (e Ellipse[N]) Classify(minDx, minDy, maxDx, maxDy float64) Coverage {
+	halfWidth, halfHeight := e.HalfExtents()
+	hw, hh := float64(halfWidth), float64(halfHeight)
+
+	if maxDx < -hw || minDx > hw || maxDy < -hh || minDy > hh {
+		return CoverageOutside
+	}
+
+	// The ellipse is convex, so the region lies inside if all its corners do
+	for _, corner := range [4][2]float64{{minDx, minDy}, {maxDx, minDy}, {minDx, maxDy}, {maxDx, maxDy}} {
+		if e.unitDistSq(corner[0], corner[1]) > 1-classifyMargin {
+			return CoveragePartial
+		}
+	}
+
+	return CoverageInside
+}
+
+// CellOffset is a cell relative to the cell containing a query center.
+type CellOffset struct {
+	DX, DY int
+
+	// Inside is whether every node in the cell lies inside the shape,
+	// wherever the center is within its cell.
+	Inside bool
+}
+
+// CompiledShape is a shape with its cell offsets precomputed for one cell size.
+// It can be executed at any center with QueryCompiled, skipping the cell math a query
+// would otherwise redo every time.
+type CompiledShape[N Number] struct {
+	// Shape is the compiled shape.
+	Shape Shape[N]
+
+	// Offsets are the cells that may hold nodes inside the shape.
+	Offsets []CellOffset
+
+	cellSize   N
+	hysteresis N
+}
+
+// CompileShape precomputes the cells shape may cover relative to the cell containing its center,
+// classifying each as fully inside the shape or on its boundary, for any center within that cell.
+// The result is only valid for spatial hashes with the same cell size and hysteresis.
+func (sh *SpatialHash[Id, N]) CompileShape(shape Shape[N]) *CompiledShape[N] {
+	halfWidth, halfHeight := shape.HalfExtents()
+
+	cellSize, margin := float64(sh.cellSize), float64(sh.hysteresis)
+
+	// The center may sit anywhere in its cell, so reach one cell further than the extents,
+	// and nodes may be kept up to the hysteresis margin outside their cell
+	reachX := int(math.Ceil((float64(halfWidth)+margin)/cellSize)) + 1
+	reachY := int(math.Ceil((float64(halfHeight)+margin)/cellSize)) + 1
+
+	c := &CompiledShape[N]{
+		Shape: shape,
+
+		cellSize:   sh.cellSize,
+		hysteresis: sh.hysteresis,
+	}
+
+	for dy := -reachY; dy <= reachY; dy++ {
+		for dx := -reachX; dx <= reachX; dx++ {
+			// Offsets from a center in cell (0, 0) to points in cell (dx, dy)
+			coverage := shape.Classify(
+				float64(dx-1)*cellSize-margin, float64(dy-1)*cellSize-margin,
+				float64(dx+1)*cellSize+margin, float64(dy+1)*cellSize+margin,
+			)
+
+			if coverage != CoverageOutside {
+				c.Offsets = append(c.Offsets, CellOffset{DX: dx, DY: dy, Inside: coverage == CoverageInside})
+			}
+		}
+	}
+
+	return c
+}
+
+// QueryCompiled returns the nodes inside a compiled shape centered on (x, y).
+// Nodes in cells fully inside the shape are returned without per-node checks.
+//
+// It panics if the shape was compiled for a different cell size or hysteresis.
+func (sh *SpatialHash[Id, N]) QueryCompiled(c *CompiledShape[N], x, y N) NodeSlice[Id, N] {
+	if c.cellSize != sh.cellSize || c.hysteresis != sh.hysteresis {
+		panic("spatial_hash: compiled shape used with a different cell size or hysteresis")
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	cx, cy := sh.cellCoord(x), sh.cellCoord(y)
+
+	var nodes NodeSlice[Id, N]
+
+	for _, offset := range c.Offsets {
+		ox, oy := cx+offset.DX, cy+offset.DY
+
+		if sh.world != nil && !sh.worldCells.Contains(ox, oy) {
+			continue
+		}
+
+		bucket, ok := sh.cellBucket(ox, oy)
+		if !ok {
+			continue
+		}
+
+		bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+			if offset.Inside || c.Shape.Contains(sh.Quantize(n.GetX())-x, sh.Quantize(n.GetY())-y) {
+				nodes = append(nodes, n)
+			}
+
+			return true
+		})
+	}
+
+	return nodes
+}
+
+// QueryEllipse returns the nodes inside the ellipse centered on (x, y) with radii rx and ry
+// along its own axes, rotated counterclockwise by angle radians: vision cones elongated along
+// a facing, or isometric views where screen distance differs per axis. Bounded nodes are
+// treated as points at their center. Radii that are not positive return no nodes.
+func (sh *SpatialHash[Id, N]) QueryEllipse(x, y, rx, ry, angle N) NodeSlice[Id, N] {
+	if !(rx > 0 && ry > 0) {
+		return nil
+	}
+
+	var nodes NodeSlice[Id, N]
+
+	sh.queryShape(Ellipse[N]{RadiusX: rx, RadiusY: ry, Angle: angle}, x, y, nil, func(n Node[Id, N]) bool {
+		nodes = append(nodes, n)
+
+		return true
+	})
+
+	return nodes
+}
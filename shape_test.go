@@ -0,0 +1,131 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSpatialHashCompiledShape(t *testing.T) {
+	nodes := CreateTestNodes(5000, 1000, 1000)
+
+	for _, n := range nodes {
+		n.x, n.y = n.x-500, n.y-500
+	}
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	circle := sh.CompileShape(Circle[float64]{Radius: 75})
+	box := sh.CompileShape(Box[float64]{Width: 120, Height: 40})
+
+	inside := 0
+
+	for _, offset := range circle.Offsets {
+		if offset.Inside {
+			inside++
+		}
+	}
+
+	if inside == 0 {
+		t.Error("Expected some cells fully inside the circle")
+	}
+
+	for range 1000 {
+		x, y := 1000*rand.Float64()-500, 1000*rand.Float64()-500
+
+		if got, want := len(sh.QueryCompiled(circle, x, y)), len(NaiveSearch(nodes, x, y, 75)); got != want {
+			t.Fatalf("Circle at (%v, %v): expected %d nodes, got %d", x, y, want, got)
+		}
+
+		want := 0
+
+		for _, n := range nodes {
+			if math.Abs(n.x-x) <= 60 && math.Abs(n.y-y) <= 20 {
+				want++
+			}
+		}
+
+		if got := len(sh.QueryCompiled(box, x, y)); got != want {
+			t.Fatalf("Box at (%v, %v): expected %d nodes, got %d", x, y, want, got)
+		}
+	}
+}
+
+func TestCompiledShapeCellSizeMismatch(t *testing.T) {
+	c := NewSpatialHash[int, float64](10).CompileShape(Circle[float64]{Radius: 5})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for mismatched cell size")
+		}
+	}()
+
+	NewSpatialHash[int, float64](20).QueryCompiled(c, 0, 0)
+}
+
+// naiveEllipse counts the nodes inside the rotated ellipse by brute force.
+func naiveEllipse(nodes []*Point, x, y, rx, ry, angle float64) int {
+	count := 0
+
+	for _, n := range nodes {
+		dx, dy := n.x-x, n.y-y
+
+		u := dx*math.Cos(angle) + dy*math.Sin(angle)
+		v := -dx*math.Sin(angle) + dy*math.Cos(angle)
+
+		if (u/rx)*(u/rx)+(v/ry)*(v/ry) <= 1 {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestSpatialHashQueryEllipse(t *testing.T) {
+	nodes := CreateTestNodes(5000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](20)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	for range 500 {
+		x, y := 1000*rand.Float64(), 1000*rand.Float64()
+		rx, ry := 5+100*rand.Float64(), 5+30*rand.Float64()
+		angle := 2 * math.Pi * rand.Float64()
+
+		if got, want := len(sh.QueryEllipse(x, y, rx, ry, angle)), naiveEllipse(nodes, x, y, rx, ry, angle); got != want {
+			t.Fatalf("Ellipse at (%v, %v): expected %d nodes, got %d", x, y, want, got)
+		}
+
+		compiled := sh.CompileShape(Ellipse[float64]{RadiusX: rx, RadiusY: ry, Angle: angle})
+
+		if got, want := len(sh.QueryCompiled(compiled, x, y)), naiveEllipse(nodes, x, y, rx, ry, angle); got != want {
+			t.Fatalf("Compiled ellipse at (%v, %v): expected %d nodes, got %d", x, y, want, got)
+		}
+	}
+}
+
+func TestSpatialHashQueryEllipseRotation(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	sh.Put(newPoint(1, 90, 0))
+	sh.Put(newPoint(2, 0, 20))
+
+	if result := sh.QueryEllipse(0, 0, 100, 10, 0); len(result) != 1 || result[0].GetId() != 1 {
+		t.Errorf("Expected only the node along the major axis, got %d nodes", len(result))
+	}
+
+	if result := sh.QueryEllipse(0, 0, 100, 10, math.Pi/2); len(result) != 1 || result[0].GetId() != 2 {
+		t.Errorf("Expected only the node along the rotated major axis, got %d nodes", len(result))
+	}
+
+	if result := sh.QueryEllipse(0, 0, 0, 10, 0); len(result) != 0 {
+		t.Errorf("Expected no nodes for a zero radius, got %d", len(result))
+	}
+}
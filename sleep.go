@@ -0,0 +1,102 @@
+package spatial_hash
+
+import "github.com/puzpuzpuz/xsync/v4"
+
+// sleepTracker counts how long each node has gone without moving.
+type sleepTracker[Id comparable] struct {
+	// after is the number of idle UpdateAll calls after which a node falls asleep.
+	after int
+
+	// idle holds the number of consecutive UpdateAll calls each node has not moved for.
+	idle *xsync.Map[Id, int]
+}
+
+// SetSleepAfter makes nodes that have not moved for ticks consecutive UpdateAll calls fall
+// asleep. Sleeping nodes are skipped by UpdateAll, and ForEachPair skips pairs of two sleeping
+// nodes. A sleeping node wakes up when a node moves into or next to its cell, when it is
+// updated with Update, or when Wake is called. A ticks of zero or less disables sleeping.
+//
+// SetSleepAfter must be called before the spatial hash is used.
+func (sh *SpatialHash[Id, N]) SetSleepAfter(ticks int) {
+	if ticks > 0 {
+		sh.sleep = &sleepTracker[Id]{after: ticks, idle: xsync.NewMap[Id, int]()}
+	} else {
+		sh.sleep = nil
+	}
+}
+
+// IsSleeping reports whether the node with the given id is sleeping.
+func (sh *SpatialHash[Id, N]) IsSleeping(id Id) bool {
+	if sh.sleep == nil {
+		return false
+	}
+
+	idle, _ := sh.sleep.idle.Load(id)
+
+	return idle >= sh.sleep.after
+}
+
+// Wake wakes up the node with the given id, so that the next UpdateAll checks it again.
+func (sh *SpatialHash[Id, N]) Wake(id Id) {
+	if sh.sleep != nil {
+		sh.sleep.idle.Delete(id)
+	}
+}
+
+// UpdateAll updates every node that has moved since its last update, and returns the number of
+// updated nodes. A node has moved if its position differs from its old position. Nodes put with
+// PutBounded are updated as UpdateBounded would.
+//
+// With SetSleepAfter, UpdateAll also counts one tick of idleness for every node that has not
+// moved, skips sleeping nodes entirely, and wakes the nodes around every node that moved.
+func (sh *SpatialHash[Id, N]) UpdateAll() int {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	var moved NodeSlice[Id, N]
+
+	// Gather first, as updating nodes while iterating could visit them twice
+	sh.buckets.Range(func(_ cell, b *bucket[Id, N]) bool {
+		b.ForEach(func(id Id, n Node[Id, N]) bool {
+			if sh.IsSleeping(id) {
+				return true
+			}
+
+			oldX, oldY := n.GetOldPos()
+
+			if sh.Quantize(n.GetX()) != sh.Quantize(oldX) || sh.Quantize(n.GetY()) != sh.Quantize(oldY) {
+				moved = append(moved, n)
+			} else if sh.sleep != nil {
+				sh.sleep.idle.Compute(id, func(idle int, _ bool) (int, xsync.ComputeOp) {
+					return idle + 1, xsync.UpdateOp
+				})
+			}
+
+			return true
+		})
+
+		return true
+	})
+
+	for _, n := range moved {
+		if _, bounded := sh.bounded.Load(n.GetId()); bounded {
+			sh.updateBounded(n.(BoundedNode[Id, N]))
+		} else {
+			sh.update(n)
+		}
+	}
+
+	if sh.sleep != nil {
+		for _, n := range moved {
+			cx, cy := sh.CellOf(n.GetX(), n.GetY())
+
+			sh.forEachInCells(cx-1, cy-1, cx+1, cy+1, func(other Node[Id, N]) bool {
+				sh.sleep.idle.Delete(other.GetId())
+
+				return true
+			})
+		}
+	}
+
+	return len(moved)
+}
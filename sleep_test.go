@@ -0,0 +1,93 @@
+package spatial_hash
+
+import "testing"
+
+func TestSpatialHashSleep(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetSleepAfter(3)
+
+	resting := newPoint(1, 5, 5)
+	neighbor := newPoint(2, 8, 5)
+	mover := newPoint(3, 100, 100)
+
+	sh.Put(resting)
+	sh.Put(neighbor)
+	sh.Put(mover)
+
+	for range 3 {
+		mover.x++
+
+		if updated := sh.UpdateAll(); updated != 1 {
+			t.Fatalf("Expected 1 updated node, got %d", updated)
+		}
+	}
+
+	if !sh.IsSleeping(resting.id) || !sh.IsSleeping(neighbor.id) {
+		t.Fatal("Expected idle nodes to fall asleep")
+	}
+
+	if sh.IsSleeping(mover.id) {
+		t.Fatal("Expected the moving node to stay awake")
+	}
+
+	// Sleeping pairs are not generated
+	pairs := 0
+
+	sh.ForEachPair(10, func(a, b TestingNode) bool {
+		pairs++
+
+		return true
+	})
+
+	if pairs != 0 {
+		t.Errorf("Expected no pairs between sleeping nodes, got %d", pairs)
+	}
+
+	// A sleeping node moved behind the hash's back is skipped
+	resting.x = 6
+
+	if updated := sh.UpdateAll(); updated != 0 {
+		t.Errorf("Expected sleeping nodes to be skipped, got %d updated", updated)
+	}
+
+	// Activity next to them wakes them up
+	mover.x, mover.y = 15, 5
+
+	sh.UpdateAll()
+
+	if sh.IsSleeping(resting.id) || sh.IsSleeping(neighbor.id) {
+		t.Fatal("Expected nodes next to a moving node to wake up")
+	}
+
+	if updated := sh.UpdateAll(); updated != 1 {
+		t.Errorf("Expected the woken node to be updated, got %d updated", updated)
+	}
+
+	if len(sh.AtPosition(6, 5)) != 1 {
+		t.Error("Expected the woken node at its new position")
+	}
+
+	pairs = 0
+
+	sh.ForEachPair(10, func(a, b TestingNode) bool {
+		pairs++
+
+		return true
+	})
+
+	if pairs != 3 {
+		t.Errorf("Expected 3 pairs of awake nodes, got %d", pairs)
+	}
+
+	sh.Remove(neighbor)
+
+	for range 3 {
+		sh.UpdateAll()
+	}
+
+	sh.Wake(resting.id)
+
+	if sh.IsSleeping(resting.id) {
+		t.Error("Expected Wake to wake the node")
+	}
+}
@@ -1,257 +1,832 @@
-package spatial_hash
-
-import (
-	"math"
-
-	"github.com/colega/zeropool"
-	"golang.org/x/exp/constraints"
-
-	"github.com/puzpuzpuz/xsync/v4"
-)
-
-type Number interface {
-	constraints.Integer | constraints.Float
-}
-
-// Node represents an interface entity.
-type Node[Id comparable, N Number] interface {
-	// GetId returns the unique identifier of the node.
-	GetId() Id
-
-	// GetX returns the current X coordinate of the node.
-	GetX() N
-	// GetY returns the current Y coordinate of the node.
-	GetY() N
-
-	// SetOldPos stores the previous position of the node.
-	SetOldPos(x, y N)
-	// GetOldPos returns the previous X,Y coordinates of the node.
-	GetOldPos() (N, N)
-}
-
-// NodeSlice is slice of node.
-type NodeSlice[Id comparable, N Number] = []Node[Id, N]
-
-// ToNodeSlice converts slice of type that satisfies Node to NodeSlice.
-func ToNodeSlice[T Node[Id, N], Id comparable, N Number](entities []T) NodeSlice[Id, N] {
-	nodes := make(NodeSlice[Id, N], len(entities))
-
-	for i, e := range entities {
-		nodes[i] = e
-	}
-
-	return nodes
-}
-
-// SpatialHash provides a thread-safe 2D spatial hashing implementation.
-type SpatialHash[Id comparable, N Number] struct {
-	cellSize N
-	buckets  *xsync.Map[int, *bucket[Id, N]]
-
-	nodePool zeropool.Pool[NodeSlice[Id, N]]
-
-	// localizedRemove is whether Remove(n) should calculate key of node and delete only once
-	// from one bucket, instead of iterating all the buckets.
-	// This will improve performance but may cause node duplication (due to timing).
-	// If you not want to consider timing, set this option to true.
-	localizedRemove bool
-}
-
-// NewSpatialHashWithOptions creates a new spatial hash with configurable options.
-func NewSpatialHashWithOptions[Id comparable, N Number](cellSize N, localizedRemove bool) *SpatialHash[Id, N] {
-	return &SpatialHash[Id, N]{
-		cellSize: cellSize,
-		buckets:  xsync.NewMap[int, *bucket[Id, N]](),
-
-		// TODO: automatically calculate pool size from cell size
-		nodePool: zeropool.New(func() NodeSlice[Id, N] { return make(NodeSlice[Id, N], 64) }),
-
-		localizedRemove: localizedRemove,
-	}
-}
-
-func NewSpatialHash[Id comparable, N Number](cellSize N) *SpatialHash[Id, N] {
-	return NewSpatialHashWithOptions[Id](cellSize, true)
-}
-
-// bucket is a thread-safe set implementation for Node objects.
-type bucket[Id comparable, N Number] struct{ nodes *xsync.Map[Id, Node[Id, N]] }
-
-// newBucket creates a new node set.
-func newBucket[Id comparable, N Number]() *bucket[Id, N] {
-	return &bucket[Id, N]{xsync.NewMap[Id, Node[Id, N]]()}
-}
-
-// Add adds a node to the set.
-func (s *bucket[Id, N]) Add(n Node[Id, N]) {
-	s.nodes.Store(n.GetId(), n)
-}
-
-// Delete removes a node from the set.
-func (s *bucket[Id, N]) Delete(n Node[Id, N]) {
-	s.nodes.Delete(n.GetId())
-}
-
-// ForEach iterates over all nodes in the set.
-func (s *bucket[Id, N]) ForEach(f func(_ Id, n Node[Id, N]) bool) {
-	s.nodes.Range(f)
-}
-
-// pairPoint combines x,y coordinates into a single int key.
-func pairPoint(x, y int) int {
-	return (x << 16) ^ y
-}
-
-func (sh *SpatialHash[Id, N]) calculatePositionKey(x, y N) int {
-	cellSize := sh.cellSize
-
-	return pairPoint(
-		int(math.Floor(float64(x/cellSize))),
-		int(math.Floor(float64(y/cellSize))),
-	)
-}
-
-// Put adds a node to the spatial hash.
-func (sh *SpatialHash[Id, N]) Put(n Node[Id, N]) {
-	x, y := n.GetX(), n.GetY()
-	key := sh.calculatePositionKey(x, y)
-
-	// Get or create bucket
-	bucket, exists := sh.buckets.Load(key)
-	if !exists {
-		bucket = newBucket[Id, N]()
-
-		sh.buckets.Store(key, bucket)
-	}
-
-	bucket.Add(n)
-}
-
-// Remove removes a node from the spatial hash.
-func (sh *SpatialHash[Id, N]) Remove(n Node[Id, N]) {
-	if sh.localizedRemove {
-		x, y := n.GetX(), n.GetY()
-		key := sh.calculatePositionKey(x, y)
-
-		if bucket, ok := sh.buckets.Load(key); ok {
-			bucket.Delete(n)
-		}
-	} else {
-		sh.buckets.Range(func(_ int, s *bucket[Id, N]) bool {
-			s.Delete(n)
-
-			return true
-		})
-	}
-}
-
-// Update updates a node's position in the spatial hash.
-func (sh *SpatialHash[Id, N]) Update(n Node[Id, N]) {
-	x, y := n.GetX(), n.GetY()
-	oldX, oldY := n.GetOldPos()
-
-	key := sh.calculatePositionKey(x, y)
-	oldKey := sh.calculatePositionKey(oldX, oldY)
-
-	if oldKey != key { // Only update if cell is different from previous update
-		// Delete old node from bucket
-		if bucket, ok := sh.buckets.Load(oldKey); ok {
-			bucket.Delete(n)
-		}
-
-		bucket, ok := sh.buckets.Load(key)
-		if !ok {
-			bucket = newBucket[Id, N]()
-
-			sh.buckets.Store(key, bucket)
-		}
-
-		bucket.Add(n)
-	}
-
-	// Set old position for next update
-	n.SetOldPos(x, y)
-}
-
-// Search searches all nodes within the radius.
-func (sh *SpatialHash[Id, N]) Search(x, y, radius N) NodeSlice[Id, N] {
-	cellSize := sh.cellSize
-
-	radiusSq := radius * radius
-
-	minX := int(math.Floor(float64((x - radius) / cellSize)))
-	maxX := int(math.Floor(float64((x + radius) / cellSize)))
-	minY := int(math.Floor(float64((y - radius) / cellSize)))
-	maxY := int(math.Floor(float64((y + radius) / cellSize)))
-
-	result := sh.nodePool.Get()
-	nodes := result[:0]
-
-	for yy := minY; yy <= maxY; yy++ {
-		for xx := minX; xx <= maxX; xx++ {
-			key := pairPoint(xx, yy)
-
-			if bucket, ok := sh.buckets.Load(key); ok {
-				bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
-					dx := n.GetX() - x
-					dy := n.GetY() - y
-
-					if dx*dx+dy*dy <= radiusSq {
-						nodes = append(nodes, n)
-					}
-
-					return true
-				})
-			}
-		}
-	}
-
-	finalResult := make(NodeSlice[Id, N], len(nodes))
-	copy(finalResult, nodes)
-
-	sh.nodePool.Put(result)
-
-	return finalResult
-}
-
-// QueryRect queries all nodes within the specified rectangular area centered on a point.
-func (sh *SpatialHash[Id, N]) QueryRect(x, y, width, height N) NodeSlice[Id, N] {
-	cellSize := sh.cellSize
-
-	halfWidth := width / N(2)
-	halfHeight := height / N(2)
-
-	minX := int(math.Floor(float64((x - halfWidth) / cellSize)))
-	maxX := int(math.Floor(float64((x + halfWidth) / cellSize)))
-	minY := int(math.Floor(float64((y - halfHeight) / cellSize)))
-	maxY := int(math.Floor(float64((y + halfHeight) / cellSize)))
-
-	result := sh.nodePool.Get()
-	nodes := result[:0]
-
-	for yy := minY; yy <= maxY; yy++ {
-		for xx := minX; xx <= maxX; xx++ {
-			key := pairPoint(xx, yy)
-
-			if bucket, ok := sh.buckets.Load(key); ok {
-				bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
-					nodes = append(nodes, n)
-
-					return true
-				})
-			}
-		}
-	}
-
-	finalResult := make(NodeSlice[Id, N], len(nodes))
-	copy(finalResult, nodes)
-
-	sh.nodePool.Put(result)
-
-	return finalResult
-}
-
-// Reset clears all nodes from the spatial hash.
-func (sh *SpatialHash[Id, N]) Reset() {
-	sh.buckets.Clear()
-}
+package spatial_hash
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/colega/zeropool"
+	"golang.org/x/exp/constraints"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+type Number interface {
+	constraints.Integer | constraints.Float
+}
+
+// Node represents an interface entity.
+type Node[Id comparable, N Number] interface {
+	// GetId returns the unique identifier of the node.
+	GetId() Id
+
+	// GetX returns the current X coordinate of the node.
+	GetX() N
+	// GetY returns the current Y coordinate of the node.
+	GetY() N
+
+	// SetOldPos stores the previous position of the node.
+	SetOldPos(x, y N)
+	// GetOldPos returns the previous X,Y coordinates of the node.
+	GetOldPos() (N, N)
+}
+
+// NodeSlice is slice of node.
+type NodeSlice[Id comparable, N Number] = []Node[Id, N]
+
+// ToNodeSlice converts slice of type that satisfies Node to NodeSlice.
+func ToNodeSlice[T Node[Id, N], Id comparable, N Number](entities []T) NodeSlice[Id, N] {
+	nodes := make(NodeSlice[Id, N], len(entities))
+
+	for i, e := range entities {
+		nodes[i] = e
+	}
+
+	return nodes
+}
+
+// SpatialHash provides a thread-safe 2D spatial hashing implementation.
+type SpatialHash[Id comparable, N Number] struct {
+	cellSize N
+	buckets  *xsync.Map[cell, *bucket[Id, N]]
+
+	nodePool zeropool.Pool[NodeSlice[Id, N]]
+
+	// localizedRemove is whether Remove(n) should calculate key of node and delete only once
+	// from one bucket, instead of iterating all the buckets.
+	// This will improve performance but may cause node duplication (due to timing).
+	// If you not want to consider timing, set this option to true.
+	localizedRemove bool
+
+	// integral is whether N is an integer type, which needs floored integer division for cell math.
+	integral bool
+
+	// quantum is the step coordinates are snapped to before use, or zero to use them as is.
+	quantum N
+
+	// blocked holds the cells marked as impassable.
+	blocked *xsync.Map[cell, struct{}]
+
+	// world holds the bounds set with SetWorldBounds, or is nil if unbounded.
+	world *Rect[N]
+	// worldCells is the range of cells covered by world.
+	worldCells CellRect
+
+	// bounded holds the state of every node put with PutBounded.
+	bounded *xsync.Map[Id, boundedState]
+
+	// exact indexes nodes by exact position, or is nil if disabled.
+	exact *exactIndex[Id, N]
+
+	// tracker tracks nodes for NotifyMoved, or is nil if disabled.
+	tracker *moveTracker[Id, N]
+
+	// sleep tracks idle nodes for SetSleepAfter, or is nil if disabled.
+	sleep *sleepTracker[Id]
+
+	// validId checks the id of every node put, or is nil if disabled.
+	validId func(id Id) bool
+
+	// hysteresis is how far beyond the boundary of its cell a node may move before Update
+	// migrates it, or zero if disabled.
+	hysteresis N
+	// placed holds the cell every node is stored in when hysteresis is enabled, or is nil.
+	placed *xsync.Map[Id, cell]
+
+	// authority validates positions on update, or is nil if disabled.
+	authority *authority[Id, N]
+
+	// interp records positions at the start of the step for SetInterpolation, or is nil if disabled.
+	interp *interpolation[Id, N]
+
+	// maxSpeed tracks the fastest speed of the nodes put or updated recently, for
+	// SetExtrapolation, or is nil if disabled.
+	maxSpeed *speedTracker
+
+	// hooks are called around every mutation, or is nil if none are set.
+	hooks *hookState[Id, N]
+
+	// flow counts arrivals and departures per cell for SetFlowWindow, or is nil if disabled.
+	flow *flowTracker
+
+	// subs holds the subscriptions to cells, or nil if there are none.
+	subs atomic.Pointer[[]*CellSubscription[Id, N]]
+	// pausedBelow is the priority below which subscriptions are paused by a degrading Ticker,
+	// and droppedEvents counts the events they were not delivered.
+	pausedBelow   atomic.Int32
+	droppedEvents atomic.Int64
+
+	// poolCheck detects misuse of the slices SearchOwned returns, or is nil if disabled.
+	poolCheck *poolChecker[Id, N]
+
+	// drainMu is held shared by every operation and exclusively by Drain,
+	// so that Drain can wait for in-flight operations to return.
+	drainMu *xsync.RBMutex
+}
+
+// NewSpatialHashWithOptions creates a new spatial hash with configurable options.
+func NewSpatialHashWithOptions[Id comparable, N Number](cellSize N, localizedRemove bool) *SpatialHash[Id, N] {
+	one := N(1)
+
+	return &SpatialHash[Id, N]{
+		cellSize: cellSize,
+		integral: one/2 == 0,
+		buckets:  xsync.NewMap[cell, *bucket[Id, N]](),
+
+		// TODO: automatically calculate pool size from cell size
+		nodePool: zeropool.New(func() NodeSlice[Id, N] { return make(NodeSlice[Id, N], 64) }),
+
+		localizedRemove: localizedRemove,
+
+		blocked: xsync.NewMap[cell, struct{}](),
+
+		bounded: xsync.NewMap[Id, boundedState](),
+
+		drainMu: xsync.NewRBMutex(),
+	}
+}
+
+func NewSpatialHash[Id comparable, N Number](cellSize N) *SpatialHash[Id, N] {
+	return NewSpatialHashWithOptions[Id](cellSize, true)
+}
+
+// bucketEntry is a node stored in a bucket.
+type bucketEntry[Id comparable, N Number] struct {
+	node Node[Id, N]
+
+	// spanning is whether the node was put with PutBounded, and may be stored in other buckets too.
+	spanning bool
+	// home is whether the bucket is the one holding the node's center.
+	home bool
+}
+
+// bucket is a thread-safe set implementation for Node objects.
+type bucket[Id comparable, N Number] struct {
+	nodes *xsync.Map[Id, bucketEntry[Id, N]]
+
+	// version is bumped whenever a node is added to, deleted from or moved within the set.
+	version atomic.Uint64
+
+	// members is bumped whenever an entry is stored in or deleted from the set.
+	members atomic.Uint64
+	// snapshot holds the entries of the set as of a value of members, rebuilt by the first
+	// iteration after it changes, so that read-mostly sets are iterated as a plain slice.
+	snapshot atomic.Pointer[bucketSnapshot[Id, N]]
+}
+
+// bucketSnapshot is an immutable copy of the entries of a bucket.
+type bucketSnapshot[Id comparable, N Number] struct {
+	members uint64
+	entries []bucketEntry[Id, N]
+}
+
+// newBucket creates a new node set.
+func newBucket[Id comparable, N Number]() *bucket[Id, N] {
+	return &bucket[Id, N]{nodes: xsync.NewMap[Id, bucketEntry[Id, N]]()}
+}
+
+// Add adds a node to the set.
+func (s *bucket[Id, N]) Add(n Node[Id, N]) {
+	s.nodes.Store(n.GetId(), bucketEntry[Id, N]{node: n, home: true})
+	s.members.Add(1)
+	s.touch()
+}
+
+// addSpanning adds a bounded node to the set, home being whether the set holds its center.
+func (s *bucket[Id, N]) addSpanning(n Node[Id, N], home bool) {
+	s.nodes.Store(n.GetId(), bucketEntry[Id, N]{node: n, spanning: true, home: home})
+	s.members.Add(1)
+	s.touch()
+}
+
+// Delete removes a node from the set, reporting whether it was there.
+func (s *bucket[Id, N]) Delete(n Node[Id, N]) bool {
+	_, ok := s.nodes.LoadAndDelete(n.GetId())
+	if ok {
+		s.members.Add(1)
+	}
+
+	s.touch()
+
+	return ok
+}
+
+// touch records that the set changed.
+func (s *bucket[Id, N]) touch() {
+	s.version.Add(1)
+}
+
+// ForEach iterates over all nodes whose center lies in the set's cell,
+// so that every node is visited in exactly one bucket.
+func (s *bucket[Id, N]) ForEach(f func(_ Id, n Node[Id, N]) bool) {
+	for _, e := range s.entries() {
+		if e.home && !f(e.node.GetId(), e.node) {
+			return
+		}
+	}
+}
+
+// forEachEntry iterates over all entries in the set, including bounded nodes
+// whose center lies in another cell.
+func (s *bucket[Id, N]) forEachEntry(f func(e bucketEntry[Id, N]) bool) {
+	for _, e := range s.entries() {
+		if !f(e) {
+			return
+		}
+	}
+}
+
+// entries returns the entries of the set, from its snapshot if still current.
+func (s *bucket[Id, N]) entries() []bucketEntry[Id, N] {
+	members := s.members.Load()
+
+	if snap := s.snapshot.Load(); snap != nil && snap.members == members {
+		return snap.entries
+	}
+
+	entries := make([]bucketEntry[Id, N], 0, s.nodes.Size())
+
+	s.nodes.Range(func(_ Id, e bucketEntry[Id, N]) bool {
+		entries = append(entries, e)
+
+		return true
+	})
+
+	// A copy raced by a mutation may miss it, so it is only kept if there was none
+	if s.members.Load() == members {
+		s.snapshot.Store(&bucketSnapshot[Id, N]{members, entries})
+	}
+
+	return entries
+}
+
+// cellCoord returns the index of the cell containing coordinate v along one axis.
+func (sh *SpatialHash[Id, N]) cellCoord(v N) int {
+	cellSize := sh.cellSize
+
+	if sh.integral {
+		// Integer division truncates towards zero, floor it instead
+		q := v / cellSize
+		if q*cellSize > v {
+			q--
+		}
+
+		return int(q)
+	}
+
+	return int(math.Floor(float64(v / cellSize)))
+}
+
+// Put adds a node to the spatial hash.
+func (sh *SpatialHash[Id, N]) Put(n Node[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.checkId(n)
+
+	sh.hooked(OperationPut, n, func() { sh.put(n) })
+}
+
+// put stores n in the bucket of its position.
+func (sh *SpatialHash[Id, N]) put(n Node[Id, N]) {
+	x, y := sh.Quantize(n.GetX()), sh.Quantize(n.GetY())
+	c := cell{sh.cellCoord(x), sh.cellCoord(y)}
+
+	sh.loadOrCreateBucket(c).Add(n)
+	sh.place(n, c)
+	sh.cellEvent(CellAdded, c, n)
+
+	if sh.flow != nil {
+		sh.flow.count(c, 1, 0)
+	}
+
+	if sh.exact != nil {
+		sh.exact.Move(n, sh.exactPosition(n))
+	}
+
+	if sh.tracker != nil {
+		sh.tracker.Add(n)
+	}
+
+	if sh.maxSpeed != nil {
+		sh.trackSpeed(n)
+	}
+}
+
+// Remove removes a node from the spatial hash.
+// Nodes put with PutBounded must be removed with RemoveBounded instead.
+func (sh *SpatialHash[Id, N]) Remove(n Node[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.hooked(OperationRemove, n, func() { sh.remove(n) })
+}
+
+// remove deletes n from the bucket it is stored in, or from every bucket without localized remove.
+func (sh *SpatialHash[Id, N]) remove(n Node[Id, N]) {
+	c := sh.placedCell(n, n.GetX(), n.GetY())
+
+	deleted := false
+
+	if sh.localizedRemove {
+		if bucket, ok := sh.buckets.Load(c); ok {
+			deleted = bucket.Delete(n)
+		}
+	} else {
+		sh.buckets.Range(func(_ cell, s *bucket[Id, N]) bool {
+			if s.Delete(n) {
+				deleted = true
+			}
+
+			return true
+		})
+	}
+
+	if deleted {
+		sh.cellEvent(CellRemoved, c, n)
+	}
+
+	if sh.flow != nil {
+		sh.flow.count(c, 0, 1)
+	}
+
+	sh.forget(n.GetId())
+}
+
+// forget drops the node with the given id from the secondary indexes.
+func (sh *SpatialHash[Id, N]) forget(id Id) {
+	if sh.exact != nil {
+		sh.exact.Remove(id)
+	}
+
+	if sh.tracker != nil {
+		sh.tracker.Remove(id)
+	}
+
+	if sh.sleep != nil {
+		sh.sleep.idle.Delete(id)
+	}
+
+	if sh.placed != nil {
+		sh.placed.Delete(id)
+	}
+
+	if sh.interp != nil {
+		sh.interp.starts.Delete(id)
+	}
+}
+
+// forgetAll clears the bounded node states and the secondary indexes.
+func (sh *SpatialHash[Id, N]) forgetAll() {
+	sh.bounded.Clear()
+
+	if sh.exact != nil {
+		sh.exact.Clear()
+	}
+
+	if sh.tracker != nil {
+		sh.tracker.Clear()
+	}
+
+	if sh.sleep != nil {
+		sh.sleep.idle.Clear()
+	}
+
+	if sh.placed != nil {
+		sh.placed.Clear()
+	}
+
+	if sh.interp != nil {
+		sh.interp.starts.Clear()
+	}
+
+	if sh.maxSpeed != nil {
+		sh.maxSpeed.clear()
+	}
+
+	if sh.flow != nil {
+		sh.flow.cells.Clear()
+	}
+}
+
+// RemoveWhere removes every node for which pred returns true, in a single pass over the buckets.
+// It returns the number of removed nodes.
+//
+// pred must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) RemoveWhere(pred func(n Node[Id, N]) bool) int {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	removed := 0
+
+	sh.buckets.Range(func(c cell, b *bucket[Id, N]) bool {
+		b.ForEach(func(id Id, n Node[Id, N]) bool {
+			if !pred(n) {
+				return true
+			}
+
+			if _, ok := sh.bounded.Load(id); ok {
+				if sh.deleteBounded(n) {
+					removed++
+				}
+
+				return true
+			}
+
+			// The node is removed from the cell it is stored in, which may not be the cell of
+			// its position if it moved without an update, and only counted if no concurrent
+			// remove took it first
+			sh.hooked(OperationRemove, n, func() {
+				if !b.Delete(n) {
+					return
+				}
+
+				sh.cellEvent(CellRemoved, c, n)
+
+				if sh.flow != nil {
+					sh.flow.count(c, 0, 1)
+				}
+
+				sh.forget(id)
+
+				removed++
+			})
+
+			return true
+		})
+
+		return true
+	})
+
+	return removed
+}
+
+// Update updates a node's position in the spatial hash.
+func (sh *SpatialHash[Id, N]) Update(n Node[Id, N]) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	sh.update(n)
+}
+
+// update moves n to the bucket of its current position.
+func (sh *SpatialHash[Id, N]) update(n Node[Id, N]) {
+	sh.hooked(OperationUpdate, n, func() { sh.applyUpdate(n) })
+}
+
+// applyUpdate does the work of update, inside its hooks.
+func (sh *SpatialHash[Id, N]) applyUpdate(n Node[Id, N]) {
+	sh.correct(n)
+
+	x, y := n.GetX(), n.GetY()
+
+	if sh.placed != nil {
+		sh.migrate(n, x, y)
+	} else {
+		oldX, oldY := n.GetOldPos()
+
+		from, to := sh.cellAt(oldX, oldY), sh.cellAt(x, y)
+
+		if from != to { // Only update if cell is different from previous update
+			// Delete old node from bucket
+			if bucket, ok := sh.buckets.Load(from); ok {
+				bucket.Delete(n)
+			}
+
+			sh.loadOrCreateBucket(to).Add(n)
+
+			sh.cellEvent(CellRemoved, from, n)
+			sh.cellEvent(CellAdded, to, n)
+
+			if sh.flow != nil {
+				sh.flow.move(from, to)
+			}
+		}
+	}
+
+	// The node may have moved within its cell
+	if bucket, ok := sh.buckets.Load(sh.placedCell(n, x, y)); ok {
+		bucket.touch()
+	}
+
+	if sh.exact != nil {
+		sh.exact.Move(n, sh.exactPosition(n))
+	}
+
+	if sh.sleep != nil {
+		sh.sleep.idle.Delete(n.GetId())
+	}
+
+	if sh.interp != nil {
+		sh.recordStart(n)
+	}
+
+	if sh.maxSpeed != nil {
+		sh.trackSpeed(n)
+	}
+
+	// Set old position for next update
+	n.SetOldPos(x, y)
+}
+
+// Search searches all nodes within the radius.
+// Bounded nodes are returned if their bounding box intersects the circle.
+// A radius of zero returns the nodes exactly at (x, y), like AtPosition.
+// A negative or NaN radius returns no nodes.
+func (sh *SpatialHash[Id, N]) Search(x, y, radius N) NodeSlice[Id, N] {
+	if radius == 0 {
+		return sh.AtPosition(x, y)
+	}
+
+	if !(radius > 0) {
+		return nil
+	}
+
+	result := sh.nodePool.Get()
+	nodes := sh.SearchAppend(result[:0], x, y, radius)
+
+	finalResult := make(NodeSlice[Id, N], len(nodes))
+	copy(finalResult, nodes)
+
+	sh.nodePool.Put(nodes)
+
+	return finalResult
+}
+
+// SearchAppend appends the nodes Search would return to dst and returns the extended slice,
+// so that a caller reusing one buffer across queries allocates nothing.
+func (sh *SpatialHash[Id, N]) SearchAppend(dst NodeSlice[Id, N], x, y, radius N) NodeSlice[Id, N] {
+	sh.SearchFunc(x, y, radius, func(n Node[Id, N]) bool {
+		dst = append(dst, n)
+
+		return true
+	})
+
+	return dst
+}
+
+// SearchFunc calls fn for every node Search would return, without building a slice.
+// Iteration stops early if fn returns false.
+//
+// Other goroutines may put, update and remove nodes while fn runs, but fn itself
+// must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) SearchFunc(x, y, radius N, fn func(n Node[Id, N]) bool) {
+	if radius == 0 {
+		sh.atPositionFunc(x, y, fn)
+
+		return
+	}
+
+	if !(radius > 0) {
+		return
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	radiusSq := radius * radius
+
+	minX, minY, maxX, maxY := sh.queryCells(x, y, radius, radius)
+
+	circle := Circle[N]{radius}
+
+	var seen spanSeen[Id]
+
+	fx, fy := float64(x), float64(y)
+
+	completed := true
+
+	for yy := minY; yy <= maxY && completed; yy++ {
+		for xx := minX; xx <= maxX && completed; xx++ {
+			// Corner cells of the range may lie entirely outside the circle, and
+			// cells entirely inside it need no per-node distance checks
+			coverage := circle.Classify(sh.cellBounds(xx, yy, fx, fy))
+
+			if coverage == CoverageOutside {
+				continue
+			}
+
+			bucket, ok := sh.buckets.Load(cell{xx, yy})
+			if !ok {
+				continue
+			}
+
+			if coverage == CoverageInside {
+				bucket.forEachEntry(func(e bucketEntry[Id, N]) bool {
+					if !e.spanning || seen.first(e.node.GetId()) {
+						completed = fn(e.node)
+					}
+
+					return completed
+				})
+
+				continue
+			}
+
+			bucket.forEachEntry(func(e bucketEntry[Id, N]) bool {
+				n := e.node
+
+				if e.spanning {
+					if seen.first(n.GetId()) && sh.boundsInCircle(n, x, y, radiusSq) {
+						completed = fn(n)
+					}
+
+					return completed
+				}
+
+				dx := sh.Quantize(n.GetX()) - x
+				dy := sh.Quantize(n.GetY()) - y
+
+				if distanceSq(dx, dy) <= radiusSq {
+					completed = fn(n)
+				}
+
+				return completed
+			})
+		}
+	}
+}
+
+// QueryRect queries all nodes within the specified rectangular area centered on a point.
+// It returns every node in the cells the area overlaps, so a zero width or height returns
+// the nodes in the cells the degenerate area touches. A negative or NaN width or height
+// returns no nodes. Bounded nodes are returned if their bounding box intersects the area.
+//
+// QueryRect stops at the candidate tier of a query, see CandidatesFunc: its results are not
+// checked against the area. Use QueryShape with a Box for the nodes strictly inside it.
+func (sh *SpatialHash[Id, N]) QueryRect(x, y, width, height N) NodeSlice[Id, N] {
+	if !(width >= 0 && height >= 0) {
+		return nil
+	}
+
+	result := sh.nodePool.Get()
+	nodes := sh.QueryRectAppend(result[:0], x, y, width, height)
+
+	finalResult := make(NodeSlice[Id, N], len(nodes))
+	copy(finalResult, nodes)
+
+	sh.nodePool.Put(nodes)
+
+	return finalResult
+}
+
+// QueryRectAppend appends the nodes QueryRect would return to dst and returns the extended slice,
+// so that a caller reusing one buffer across queries allocates nothing.
+func (sh *SpatialHash[Id, N]) QueryRectAppend(dst NodeSlice[Id, N], x, y, width, height N) NodeSlice[Id, N] {
+	sh.QueryRectFunc(x, y, width, height, func(n Node[Id, N]) bool {
+		dst = append(dst, n)
+
+		return true
+	})
+
+	return dst
+}
+
+// QueryRectFunc calls fn for every node QueryRect would return, without building a slice.
+// Iteration stops early if fn returns false.
+//
+// Other goroutines may put, update and remove nodes while fn runs, but fn itself
+// must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) QueryRectFunc(x, y, width, height N, fn func(n Node[Id, N]) bool) {
+	if !(width >= 0 && height >= 0) {
+		return
+	}
+
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	halfWidth := width / N(2)
+	halfHeight := height / N(2)
+
+	minX := sh.cellCoord(x - halfWidth)
+	maxX := sh.cellCoord(x + halfWidth)
+	minY := sh.cellCoord(y - halfHeight)
+	maxY := sh.cellCoord(y + halfHeight)
+
+	minX, minY, maxX, maxY = sh.clampCellRange(minX, minY, maxX, maxY)
+
+	var seen spanSeen[Id]
+
+	completed := true
+
+	for yy := minY; yy <= maxY && completed; yy++ {
+		for xx := minX; xx <= maxX && completed; xx++ {
+			bucket, ok := sh.buckets.Load(cell{xx, yy})
+			if !ok {
+				continue
+			}
+
+			bucket.forEachEntry(func(e bucketEntry[Id, N]) bool {
+				if !e.spanning || (seen.first(e.node.GetId()) && sh.boundsInRect(e.node, x, y, halfWidth, halfHeight)) {
+					completed = fn(e.node)
+				}
+
+				return completed
+			})
+		}
+	}
+}
+
+// AtPosition returns the nodes located exactly at (x, y).
+func (sh *SpatialHash[Id, N]) AtPosition(x, y N) NodeSlice[Id, N] {
+	var nodes NodeSlice[Id, N]
+
+	sh.atPositionFunc(x, y, func(n Node[Id, N]) bool {
+		nodes = append(nodes, n)
+
+		return true
+	})
+
+	return nodes
+}
+
+// atPositionFunc calls fn for every node located exactly at (x, y), stopping early if fn returns false.
+func (sh *SpatialHash[Id, N]) atPositionFunc(x, y N, fn func(n Node[Id, N]) bool) {
+	t := sh.drainMu.RLock()
+	defer sh.drainMu.RUnlock(t)
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	// With hysteresis, the node may be kept in a neighboring cell
+	minX, minY := sh.cellCoord(x-sh.hysteresis), sh.cellCoord(y-sh.hysteresis)
+	maxX, maxY := sh.cellCoord(x+sh.hysteresis), sh.cellCoord(y+sh.hysteresis)
+
+	for yy := minY; yy <= maxY; yy++ {
+		for xx := minX; xx <= maxX; xx++ {
+			bucket, ok := sh.cellBucket(xx, yy)
+			if !ok {
+				continue
+			}
+
+			completed := true
+
+			bucket.ForEach(func(_ Id, n Node[Id, N]) bool {
+				if sh.Quantize(n.GetX()) == x && sh.Quantize(n.GetY()) == y {
+					completed = fn(n)
+				}
+
+				return completed
+			})
+
+			if !completed {
+				return
+			}
+		}
+	}
+}
+
+// Reset clears all nodes from the spatial hash.
+// It does not wait for concurrent operations, so a Put or Update racing with it
+// may survive the reset. Use ResetSafe if operations can be in flight.
+func (sh *SpatialHash[Id, N]) Reset() {
+	sh.hooked(OperationReset, nil, func() {
+		sh.cellsCleared()
+		sh.buckets.Clear()
+		sh.forgetAll()
+	})
+}
+
+// Drain blocks until every in-flight operation has returned, and holds off new
+// operations until the returned resume function is called.
+// Calling resume more than once is a no-op.
+//
+// Drain must not be called from within an operation of the same spatial hash, and callbacks
+// run by operations, such as the fn of SearchFunc or the pred of RemoveWhere, must not call
+// any of its methods, queries included: once Drain is waiting, every new operation waits for
+// it, while it waits for the operation running the callback.
+func (sh *SpatialHash[Id, N]) Drain() (resume func()) {
+	sh.drainMu.Lock()
+
+	return sync.OnceFunc(sh.drainMu.Unlock)
+}
+
+// ResetSafe clears all nodes from the spatial hash after draining it.
+// The old position of every removed node is set to its current position,
+// so that a node put back later is not migrated out of a cell it never entered.
+//
+// As with Drain, ResetSafe must not be called from within an operation of the same spatial
+// hash, and callbacks of operations in flight must not call its methods.
+func (sh *SpatialHash[Id, N]) ResetSafe() {
+	resume := sh.Drain()
+	defer resume()
+
+	sh.hooked(OperationReset, nil, func() {
+		sh.buckets.Range(func(_ cell, b *bucket[Id, N]) bool {
+			b.ForEach(func(_ Id, n Node[Id, N]) bool {
+				n.SetOldPos(n.GetX(), n.GetY())
+
+				return true
+			})
+
+			return true
+		})
+
+		sh.cellsCleared()
+		sh.buckets.Clear()
+		sh.forgetAll()
+	})
+}
@@ -1,227 +1,696 @@
-package spatial_hash
-
-import (
-	"fmt"
-	"math/rand/v2"
-	"testing"
-	"time"
-)
-
-type TestingNode = Node[int, float64]
-
-// Point is a basic implementation of the Node interface for testing.
-type Point struct {
-	id int
-
-	x, y       float64
-	oldX, oldY float64
-}
-
-func newPoint(id int, x, y float64) *Point {
-	return &Point{
-		id: id,
-
-		x: x,
-		y: y,
-
-		oldX: x,
-		oldY: y,
-	}
-}
-
-var _ TestingNode = (*Point)(nil) // *Point must implement TestingNode
-
-func (n *Point) GetId() int { return n.id }
-
-func (n *Point) GetX() float64 { return n.x }
-func (n *Point) GetY() float64 { return n.y }
-
-func (n *Point) SetOldPos(x, y float64) { n.oldX, n.oldY = x, y }
-func (n *Point) GetOldPos() (float64, float64) { return n.oldX, n.oldY }
-
-// CreateTestNodes creates a slice of test nodes with random positions.
-func CreateTestNodes(count int, maxX, maxY float64) []*Point {
-	nodes := make([]*Point, count)
-
-	for i := range count {
-		x, y := maxX*rand.Float64(), maxY*rand.Float64()
-
-		nodes[i] = newPoint(i, x, y)
-	}
-
-	return nodes
-}
-
-// NaiveSearch performs a brute-force search for nodes within radius.
-func NaiveSearch(nodes []*Point, x, y, radius float64) []*Point {
-	var result []*Point
-
-	radiusSq := radius * radius
-
-	for _, node := range nodes {
-		dx := node.GetX() - x
-		dy := node.GetY() - y
-
-		if dx*dx+dy*dy <= radiusSq {
-			result = append(result, node)
-		}
-	}
-
-	return result
-}
-
-type Position = [2]float64
-
-func TestSpatialHashPerformance(t *testing.T) {
-	testCases := []struct {
-		name      string
-		nodeCount int
-		radius    float64
-		cellSize  float64
-		areaSize  float64
-	}{
-		{
-			name:      "Small World (100 nodes, small radius)",
-			nodeCount: 100,
-			radius:    10,
-			cellSize:  20,
-			areaSize:  200,
-		},
-		{
-			name:      "Dense Population (10000 nodes, large radius)",
-			nodeCount: 10000,
-			radius:    100,
-			cellSize:  100,
-			areaSize:  1000,
-		},
-		{
-			name:      "Sparse Population (1000 nodes, small radius)",
-			nodeCount: 1000,
-			radius:    20,
-			cellSize:  50,
-			areaSize:  2000,
-		},
-		{
-			name:      "Large World (50000 nodes, medium radius)",
-			nodeCount: 50000,
-			radius:    50,
-			cellSize:  100,
-			areaSize:  5000,
-		},
-		{
-			name:      "Cell Size Impact (1000 nodes, very small cells)",
-			nodeCount: 1000,
-			radius:    30,
-			cellSize:  10,
-			areaSize:  500,
-		},
-		{
-			name:      "Cell Size Impact (1000 nodes, very large cells)",
-			nodeCount: 1000,
-			radius:    30,
-			cellSize:  200,
-			areaSize:  500,
-		},
-	}
-
-	const numSearch = 100000
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			nodes := CreateTestNodes(tc.nodeCount, tc.areaSize, tc.areaSize)
-
-			sh := NewSpatialHash[int](tc.cellSize)
-
-			// Add nodes to spatial hash
-			for _, n := range nodes {
-				sh.Put(n)
-			}
-
-			// Prepare random search positions
-			searchPositions := make([]Position, numSearch)
-
-			for i := range numSearch {
-				searchPositions[i] = Position{
-					tc.areaSize * rand.Float64(),
-					tc.areaSize * rand.Float64(),
-				}
-			}
-
-			// Test naive search
-			start := time.Now()
-
-			totalFoundNaive := 0
-
-			for _, pos := range searchPositions {
-				result := NaiveSearch(nodes, pos[0], pos[1], tc.radius)
-
-				totalFoundNaive += len(result)
-			}
-
-			naiveDuration := time.Since(start)
-
-			// Test spatial hash search
-			start = time.Now()
-
-			totalFoundHash := 0
-
-			for _, pos := range searchPositions {
-				result := sh.Search(pos[0], pos[1], tc.radius)
-
-				totalFoundHash += len(result)
-			}
-
-			hashDuration := time.Since(start)
-
-			// Calculate statistics
-			avgNodesFoundPerSearch := float64(totalFoundNaive) / float64(numSearch)
-			speedup := float64(naiveDuration) / float64(hashDuration)
-
-			fmt.Printf("Test Case: %s\n", tc.name)
-
-			fmt.Printf("Configuration: %d nodes, %.0f radius, %.0f cell size, %dx%d area\n",
-				tc.nodeCount, tc.radius, tc.cellSize, int(tc.areaSize), int(tc.areaSize))
-
-			fmt.Printf("Naive Search: %v (%.2f nodes/search)\n",
-				naiveDuration, avgNodesFoundPerSearch)
-
-			fmt.Printf("Spatial Hash: %v (%.2f nodes/search)\n",
-				hashDuration, float64(totalFoundHash)/float64(numSearch))
-
-			fmt.Printf("Speedup: %.2fx\n\n", speedup)
-
-			// Verify correctness
-			if totalFoundNaive != totalFoundHash {
-				t.Errorf("Result count mismatch: naive=%d, hash=%d",
-					totalFoundNaive, totalFoundHash)
-			}
-		})
-	}
-}
-
-func TestSpatialHashUpdate(t *testing.T) {
-	// Create a single test node
-	node := newPoint(1, 100, 100)
-
-	sh := NewSpatialHash[int, float64](100)
-
-	sh.Put(node)
-
-	// Update position
-	node.x = 300
-	node.y = 300
-
-	sh.Update(node)
-
-	// Search in old position (should find nothing)
-	result1 := sh.Search(100, 100, 50)
-	if len(result1) != 0 {
-		t.Errorf("Expected 0 nodes at old position, got %d", len(result1))
-	}
-
-	// Search in new position (should find the node)
-	result2 := sh.Search(300, 300, 50)
-	if len(result2) != 1 {
-		t.Errorf("Expected 1 node at new position, got %d", len(result2))
-	}
-}
+package spatial_hash
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestingNode = Node[int, float64]
+
+// Point is a basic implementation of the Node interface for testing.
+type Point struct {
+	id int
+
+	x, y       float64
+	oldX, oldY float64
+}
+
+func newPoint(id int, x, y float64) *Point {
+	return &Point{
+		id: id,
+
+		x: x,
+		y: y,
+
+		oldX: x,
+		oldY: y,
+	}
+}
+
+var _ TestingNode = (*Point)(nil) // *Point must implement TestingNode
+
+func (n *Point) GetId() int { return n.id }
+
+func (n *Point) GetX() float64 { return n.x }
+func (n *Point) GetY() float64 { return n.y }
+
+func (n *Point) SetOldPos(x, y float64) { n.oldX, n.oldY = x, y }
+func (n *Point) GetOldPos() (float64, float64) { return n.oldX, n.oldY }
+
+// CreateTestNodes creates a slice of test nodes with random positions.
+func CreateTestNodes(count int, maxX, maxY float64) []*Point {
+	nodes := make([]*Point, count)
+
+	for i := range count {
+		x, y := maxX*rand.Float64(), maxY*rand.Float64()
+
+		nodes[i] = newPoint(i, x, y)
+	}
+
+	return nodes
+}
+
+// NaiveSearch performs a brute-force search for nodes within radius.
+func NaiveSearch(nodes []*Point, x, y, radius float64) []*Point {
+	var result []*Point
+
+	radiusSq := radius * radius
+
+	for _, node := range nodes {
+		dx := node.GetX() - x
+		dy := node.GetY() - y
+
+		if dx*dx+dy*dy <= radiusSq {
+			result = append(result, node)
+		}
+	}
+
+	return result
+}
+
+type Position = [2]float64
+
+func TestSpatialHashPerformance(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nodeCount int
+		radius    float64
+		cellSize  float64
+		areaSize  float64
+	}{
+		{
+			name:      "Small World (100 nodes, small radius)",
+			nodeCount: 100,
+			radius:    10,
+			cellSize:  20,
+			areaSize:  200,
+		},
+		{
+			name:      "Dense Population (10000 nodes, large radius)",
+			nodeCount: 10000,
+			radius:    100,
+			cellSize:  100,
+			areaSize:  1000,
+		},
+		{
+			name:      "Sparse Population (1000 nodes, small radius)",
+			nodeCount: 1000,
+			radius:    20,
+			cellSize:  50,
+			areaSize:  2000,
+		},
+		{
+			name:      "Large World (50000 nodes, medium radius)",
+			nodeCount: 50000,
+			radius:    50,
+			cellSize:  100,
+			areaSize:  5000,
+		},
+		{
+			name:      "Cell Size Impact (1000 nodes, very small cells)",
+			nodeCount: 1000,
+			radius:    30,
+			cellSize:  10,
+			areaSize:  500,
+		},
+		{
+			name:      "Cell Size Impact (1000 nodes, very large cells)",
+			nodeCount: 1000,
+			radius:    30,
+			cellSize:  200,
+			areaSize:  500,
+		},
+	}
+
+	const numSearch = 100000
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := CreateTestNodes(tc.nodeCount, tc.areaSize, tc.areaSize)
+
+			sh := NewSpatialHash[int](tc.cellSize)
+
+			// Add nodes to spatial hash
+			for _, n := range nodes {
+				sh.Put(n)
+			}
+
+			// Prepare random search positions
+			searchPositions := make([]Position, numSearch)
+
+			for i := range numSearch {
+				searchPositions[i] = Position{
+					tc.areaSize * rand.Float64(),
+					tc.areaSize * rand.Float64(),
+				}
+			}
+
+			// Test naive search
+			start := time.Now()
+
+			totalFoundNaive := 0
+
+			for _, pos := range searchPositions {
+				result := NaiveSearch(nodes, pos[0], pos[1], tc.radius)
+
+				totalFoundNaive += len(result)
+			}
+
+			naiveDuration := time.Since(start)
+
+			// Test spatial hash search
+			start = time.Now()
+
+			totalFoundHash := 0
+
+			for _, pos := range searchPositions {
+				result := sh.Search(pos[0], pos[1], tc.radius)
+
+				totalFoundHash += len(result)
+			}
+
+			hashDuration := time.Since(start)
+
+			// Calculate statistics
+			avgNodesFoundPerSearch := float64(totalFoundNaive) / float64(numSearch)
+			speedup := float64(naiveDuration) / float64(hashDuration)
+
+			fmt.Printf("Test Case: %s\n", tc.name)
+
+			fmt.Printf("Configuration: %d nodes, %.0f radius, %.0f cell size, %dx%d area\n",
+				tc.nodeCount, tc.radius, tc.cellSize, int(tc.areaSize), int(tc.areaSize))
+
+			fmt.Printf("Naive Search: %v (%.2f nodes/search)\n",
+				naiveDuration, avgNodesFoundPerSearch)
+
+			fmt.Printf("Spatial Hash: %v (%.2f nodes/search)\n",
+				hashDuration, float64(totalFoundHash)/float64(numSearch))
+
+			fmt.Printf("Speedup: %.2fx\n\n", speedup)
+
+			// Verify correctness
+			if totalFoundNaive != totalFoundHash {
+				t.Errorf("Result count mismatch: naive=%d, hash=%d",
+					totalFoundNaive, totalFoundHash)
+			}
+		})
+	}
+}
+
+func TestSpatialHashUpdate(t *testing.T) {
+	// Create a single test node
+	node := newPoint(1, 100, 100)
+
+	sh := NewSpatialHash[int, float64](100)
+
+	sh.Put(node)
+
+	// Update position
+	node.x = 300
+	node.y = 300
+
+	sh.Update(node)
+
+	// Search in old position (should find nothing)
+	result1 := sh.Search(100, 100, 50)
+	if len(result1) != 0 {
+		t.Errorf("Expected 0 nodes at old position, got %d", len(result1))
+	}
+
+	// Search in new position (should find the node)
+	result2 := sh.Search(300, 300, 50)
+	if len(result2) != 1 {
+		t.Errorf("Expected 1 node at new position, got %d", len(result2))
+	}
+}
+
+func TestSpatialHashResetSafe(t *testing.T) {
+	nodes := CreateTestNodes(1000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](50)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	// Move nodes without updating, so their old positions become stale
+	for _, n := range nodes {
+		n.x, n.y = 1000*rand.Float64(), 1000*rand.Float64()
+	}
+
+	var wg, started sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	// Keep queries in flight while resetting
+	for range 4 {
+		wg.Add(1)
+		started.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sh.Search(1000*rand.Float64(), 1000*rand.Float64(), 100)
+			started.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+
+				default:
+					sh.Search(1000*rand.Float64(), 1000*rand.Float64(), 100)
+				}
+			}
+		}()
+	}
+
+	started.Wait()
+
+	sh.ResetSafe()
+
+	close(stop)
+	wg.Wait()
+
+	if result := sh.QueryRect(500, 500, 2000, 2000); len(result) != 0 {
+		t.Errorf("Expected 0 nodes after reset, got %d", len(result))
+	}
+
+	for _, n := range nodes {
+		if oldX, oldY := n.GetOldPos(); oldX != n.x || oldY != n.y {
+			t.Fatalf("Node %d old position not reset: (%v, %v) != (%v, %v)", n.id, oldX, oldY, n.x, n.y)
+		}
+	}
+}
+
+func TestSpatialHashDrain(t *testing.T) {
+	sh := NewSpatialHash[int, float64](100)
+
+	resume := sh.Drain()
+
+	done := make(chan struct{})
+
+	go func() {
+		sh.Put(newPoint(1, 10, 10))
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Put completed while drained")
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resume()
+	resume() // Must be a no-op
+
+	<-done
+
+	if result := sh.Search(10, 10, 1); len(result) != 1 {
+		t.Errorf("Expected 1 node after resume, got %d", len(result))
+	}
+}
+
+func TestSpatialHashDrainDuringCallback(t *testing.T) {
+	sh := NewSpatialHash[int, float64](100)
+	sh.Put(newPoint(1, 10, 10))
+
+	entered, release, drained := make(chan struct{}), make(chan struct{}), make(chan struct{})
+
+	var returned atomic.Bool
+
+	// A callback that does not call the spatial hash, running when Drain is called
+	go sh.SearchFunc(10, 10, 1, func(TestingNode) bool {
+		close(entered)
+		<-release
+
+		returned.Store(true)
+
+		return true
+	})
+
+	<-entered
+
+	go func() {
+		resume := sh.Drain()
+
+		if !returned.Load() {
+			t.Error("Drain returned before the callback")
+		}
+
+		resume()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a callback was running")
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-drained:
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drain deadlocked with a callback")
+	}
+
+	if result := sh.Search(10, 10, 1); len(result) != 1 {
+		t.Errorf("Expected 1 node after resume, got %d", len(result))
+	}
+}
+
+func TestSpatialHashRemoveWhere(t *testing.T) {
+	nodes := CreateTestNodes(1000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](50)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	isOdd := func(n TestingNode) bool { return n.GetId()%2 == 1 }
+
+	if removed := sh.RemoveWhere(isOdd); removed != 500 {
+		t.Errorf("Expected 500 removed nodes, got %d", removed)
+	}
+
+	result := sh.QueryRect(500, 500, 2000, 2000)
+	if len(result) != 500 {
+		t.Errorf("Expected 500 remaining nodes, got %d", len(result))
+	}
+
+	for _, n := range result {
+		if isOdd(n) {
+			t.Fatalf("Node %d should have been removed", n.GetId())
+		}
+	}
+}
+
+func TestSpatialHashRemoveWhereMoved(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetFlowWindow(4)
+
+	var events []CellEvent[int, float64]
+
+	sh.SubscribeCells(CellRect{0, 0, 2, 0}, func(e CellEvent[int, float64]) {
+		events = append(events, e)
+	})
+
+	p := newPoint(1, 5, 5)
+	sh.Put(p)
+
+	// Moved without an update, so still stored in cell (0, 0)
+	p.x = 25
+
+	if removed := sh.RemoveWhere(func(TestingNode) bool { return true }); removed != 1 {
+		t.Errorf("Expected 1 removed node, got %d", removed)
+	}
+
+	if last := events[len(events)-1]; last.Kind != CellRemoved || last.CellX != 0 {
+		t.Errorf("Expected the node removed from cell 0, got %v from cell %d", last.Kind, last.CellX)
+	}
+
+	if _, departures := sh.CellFlow(0, 0); departures != 1 {
+		t.Errorf("Expected 1 departure from cell 0, got %d", departures)
+	}
+
+	if _, departures := sh.CellFlow(2, 0); departures != 0 {
+		t.Errorf("Expected no departure from cell 2, got %d", departures)
+	}
+
+	if removed := sh.RemoveWhere(func(TestingNode) bool { return true }); removed != 0 {
+		t.Errorf("Expected nothing left to remove, got %d", removed)
+	}
+}
+
+func TestSpatialHashDegenerateQueries(t *testing.T) {
+	sh := NewSpatialHash[int, float64](100)
+
+	sh.Put(newPoint(1, 10, 10))
+	sh.Put(newPoint(2, 10, 10))
+	sh.Put(newPoint(3, 10, 10.000001))
+
+	if result := sh.Search(10, 10, 0); len(result) != 2 {
+		t.Errorf("Expected 2 co-located nodes for zero radius, got %d", len(result))
+	}
+
+	if result := sh.AtPosition(10, 10.000001); len(result) != 1 {
+		t.Errorf("Expected 1 node at exact position, got %d", len(result))
+	}
+
+	// The whole radius lies in one cell, which used to be scanned with the squared radius
+	if result := sh.Search(10, 10, -5); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for negative radius, got %d", len(result))
+	}
+
+	if result := sh.Search(10, 10, math.NaN()); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for NaN radius, got %d", len(result))
+	}
+
+	if result := sh.QueryRect(50, 50, 0, 0); len(result) != 3 {
+		t.Errorf("Expected 3 nodes in the cell of a zero-size rect, got %d", len(result))
+	}
+
+	if result := sh.QueryRect(50, 50, -1, 10); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for negative width, got %d", len(result))
+	}
+}
+
+func TestSpatialHashDistantCells(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// Cell (0, 65536) is far from cell (1, 0), but used to share its bucket
+	sh.Put(newPoint(1, 5, 655365))
+
+	if result := sh.Search(15, 5, 30); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for a search far from the node, got %d", len(result))
+	}
+
+	circle := Circle[float64]{Radius: 30}
+
+	if result := sh.QueryCompiled(sh.CompileShape(circle), 15, 5); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for a compiled query far from the node, got %d", len(result))
+	}
+
+	if result := sh.QueryShape(circle, 15, 5); len(result) != 0 {
+		t.Errorf("Expected 0 nodes for a shape query far from the node, got %d", len(result))
+	}
+
+	if result := sh.Search(5, 655365, 1); len(result) != 1 {
+		t.Errorf("Expected 1 node at its own position, got %d", len(result))
+	}
+}
+
+func TestSpatialHashSearchFunc(t *testing.T) {
+	nodes := CreateTestNodes(5000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](50)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	var buf NodeSlice[int, float64]
+
+	for range 200 {
+		x, y := 1000*rand.Float64(), 1000*rand.Float64()
+
+		expected := len(sh.Search(x, y, 75))
+
+		count := 0
+
+		sh.SearchFunc(x, y, 75, func(TestingNode) bool {
+			count++
+
+			return true
+		})
+
+		if count != expected {
+			t.Fatalf("Expected SearchFunc to visit %d nodes, got %d", expected, count)
+		}
+
+		buf = sh.SearchAppend(buf[:0], x, y, 75)
+
+		if len(buf) != expected {
+			t.Fatalf("Expected SearchAppend to append %d nodes, got %d", expected, len(buf))
+		}
+
+		buf = sh.QueryRectAppend(buf[:0], x, y, 80, 40)
+
+		if rect := sh.QueryRect(x, y, 80, 40); len(buf) != len(rect) {
+			t.Fatalf("Expected QueryRectAppend to append %d nodes, got %d", len(rect), len(buf))
+		}
+	}
+
+	// Find any one node within radius
+	calls := 0
+
+	sh.SearchFunc(500, 500, 200, func(TestingNode) bool {
+		calls++
+
+		return false
+	})
+
+	if calls != 1 {
+		t.Errorf("Expected SearchFunc to stop after 1 call, got %d", calls)
+	}
+
+	calls = 0
+
+	sh.QueryRectFunc(500, 500, 400, 400, func(TestingNode) bool {
+		calls++
+
+		return false
+	})
+
+	if calls != 1 {
+		t.Errorf("Expected QueryRectFunc to stop after 1 call, got %d", calls)
+	}
+
+	prefix := NodeSlice[int, float64]{nodes[0]}
+
+	if result := sh.SearchAppend(prefix, 500, 500, 75); result[0] != nodes[0] || len(result) != 1+len(sh.Search(500, 500, 75)) {
+		t.Error("Expected SearchAppend to keep the existing elements of dst")
+	}
+}
+
+// benchmarkHash returns a spatial hash filled with nodes for the query benchmarks.
+func benchmarkHash() *SpatialHash[int, float64] {
+	sh := NewSpatialHash[int, float64](50)
+
+	for _, n := range CreateTestNodes(10000, 1000, 1000) {
+		sh.Put(n)
+	}
+
+	return sh
+}
+
+func BenchmarkSearch(b *testing.B) {
+	sh := benchmarkHash()
+
+	b.ReportAllocs()
+
+	for i := 0; b.Loop(); i++ {
+		sh.Search(float64(i%1000), float64(i*7%1000), 60)
+	}
+}
+
+func BenchmarkSearchFunc(b *testing.B) {
+	sh := benchmarkHash()
+
+	count := 0
+
+	b.ReportAllocs()
+
+	for i := 0; b.Loop(); i++ {
+		sh.SearchFunc(float64(i%1000), float64(i*7%1000), 60, func(TestingNode) bool {
+			count++
+
+			return true
+		})
+	}
+}
+
+func BenchmarkSearchAppend(b *testing.B) {
+	sh := benchmarkHash()
+
+	var buf NodeSlice[int, float64]
+
+	b.ReportAllocs()
+
+	for i := 0; b.Loop(); i++ {
+		buf = sh.SearchAppend(buf[:0], float64(i%1000), float64(i*7%1000), 60)
+	}
+}
+
+func TestSpatialHashSearchAllocs(t *testing.T) {
+	nodes := CreateTestNodes(5000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](50)
+	sh.SetHysteresis(0.1)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	buf := make(NodeSlice[int, float64], 0, len(nodes))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sh.SearchFunc(500, 500, 75, func(TestingNode) bool { return true })
+
+		buf = sh.SearchAppend(buf[:0], 500, 500, 75)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected searches not to allocate, got %v allocations", allocs)
+	}
+}
+
+func TestBucketSnapshot(t *testing.T) {
+	b := newBucket[int, float64]()
+
+	p, q := newPoint(0, 1, 1), newPoint(1, 2, 2)
+	b.Add(p)
+
+	ids := func() []int {
+		var ids []int
+
+		b.ForEach(func(id int, _ TestingNode) bool {
+			ids = append(ids, id)
+
+			return true
+		})
+
+		slices.Sort(ids)
+
+		return ids
+	}
+
+	if got := ids(); !slices.Equal(got, []int{0}) {
+		t.Errorf("Expected [0], got %v", got)
+	}
+
+	snapshot := b.snapshot.Load()
+
+	// Moves within the cell keep the snapshot
+	b.touch()
+	ids()
+
+	if b.snapshot.Load() != snapshot {
+		t.Errorf("Expected the snapshot to be reused")
+	}
+
+	b.Add(q)
+
+	if got := ids(); !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("Expected [0, 1], got %v", got)
+	}
+
+	b.Delete(p)
+
+	if got := ids(); !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected [1], got %v", got)
+	}
+
+	// Deleting a node that is not there changes nothing
+	snapshot = b.snapshot.Load()
+
+	b.Delete(p)
+	ids()
+
+	if b.snapshot.Load() != snapshot {
+		t.Errorf("Expected the snapshot to be reused")
+	}
+}
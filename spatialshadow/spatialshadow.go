@@ -0,0 +1,199 @@
+// Package spatialshadow runs a candidate spatial index in the shadow of the one in use, so that
+// a live game can migrate to a new backend safely.
+//
+// Every mutation is applied to both indexes, while queries are answered by the primary one.
+// A sample of queries also runs against the candidate, and any difference between the two
+// results is reported as a Divergence. Once the candidate has run diverging nowhere for long
+// enough, it can replace the primary.
+package spatialshadow
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Query is the kind of a compared query.
+type Query string
+
+const (
+	// QuerySearch is a Search, with Radius set.
+	QuerySearch Query = "search"
+	// QueryRect is a QueryRect, with Width and Height set.
+	QueryRect Query = "rect"
+)
+
+// Divergence is a query for which the candidate returned other nodes than the primary.
+type Divergence[Id comparable, N spatial_hash.Number] struct {
+	Query Query
+
+	X, Y          N
+	Radius        N
+	Width, Height N
+
+	// Missing holds the ids the primary returned but the candidate did not.
+	Missing []Id
+	// Extra holds the ids the candidate returned but the primary did not.
+	Extra []Id
+}
+
+// Index wraps a primary and a candidate spatial index, mirroring mutations to both and comparing
+// a sample of queries. Index is safe for concurrent use; mutations are serialized, and sampled
+// queries run on both indexes between two mutations, so that they compare the same state.
+type Index[Id comparable, N spatial_hash.Number] struct {
+	primary, candidate spatial_hash.SpatialIndex[Id, N]
+
+	rate   float64
+	report func(d Divergence[Id, N])
+
+	mu sync.RWMutex
+
+	compared, diverged atomic.Uint64
+}
+
+var _ spatial_hash.SpatialIndex[int, float64] = (*Index[int, float64])(nil) // *Index must implement SpatialIndex
+
+// New wraps primary and candidate, which should be empty. A fraction rate of queries, from 0
+// to 1, is compared, and report is called with each divergence found. report may be nil, in
+// which case divergences are only counted.
+//
+// Backends may differ by design: SpatialHash returns the nodes of cells overlapping a
+// rectangle from QueryRect, so comparing it to an exact backend reports extra nodes there.
+func New[Id comparable, N spatial_hash.Number](primary, candidate spatial_hash.SpatialIndex[Id, N], rate float64, report func(d Divergence[Id, N])) *Index[Id, N] {
+	if !(rate >= 0 && rate <= 1) {
+		panic("spatialshadow: sample rate must be between 0 and 1")
+	}
+
+	return &Index[Id, N]{
+		primary:   primary,
+		candidate: candidate,
+
+		rate:   rate,
+		report: report,
+	}
+}
+
+func (ix *Index[Id, N]) Put(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.primary.Put(n)
+	ix.candidate.Put(n)
+}
+
+func (ix *Index[Id, N]) Remove(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.primary.Remove(n)
+	ix.candidate.Remove(n)
+}
+
+func (ix *Index[Id, N]) Update(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	// Updating stores the current position as the old one, which the candidate
+	// still needs to find where the node was
+	oldX, oldY := n.GetOldPos()
+
+	ix.primary.Update(n)
+
+	n.SetOldPos(oldX, oldY)
+
+	ix.candidate.Update(n)
+}
+
+func (ix *Index[Id, N]) Search(x, y, radius N) spatial_hash.NodeSlice[Id, N] {
+	if !ix.sampled() {
+		return ix.primary.Search(x, y, radius)
+	}
+
+	ix.mu.RLock()
+
+	want := ix.primary.Search(x, y, radius)
+	got := ix.candidate.Search(x, y, radius)
+
+	ix.mu.RUnlock()
+
+	ix.compare(Divergence[Id, N]{Query: QuerySearch, X: x, Y: y, Radius: radius}, want, got)
+
+	return want
+}
+
+func (ix *Index[Id, N]) QueryRect(x, y, width, height N) spatial_hash.NodeSlice[Id, N] {
+	if !ix.sampled() {
+		return ix.primary.QueryRect(x, y, width, height)
+	}
+
+	ix.mu.RLock()
+
+	want := ix.primary.QueryRect(x, y, width, height)
+	got := ix.candidate.QueryRect(x, y, width, height)
+
+	ix.mu.RUnlock()
+
+	ix.compare(Divergence[Id, N]{Query: QueryRect, X: x, Y: y, Width: width, Height: height}, want, got)
+
+	return want
+}
+
+func (ix *Index[Id, N]) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.primary.Reset()
+	ix.candidate.Reset()
+}
+
+// Compared returns how many queries were compared.
+func (ix *Index[Id, N]) Compared() uint64 {
+	return ix.compared.Load()
+}
+
+// Diverged returns how many compared queries diverged.
+func (ix *Index[Id, N]) Diverged() uint64 {
+	return ix.diverged.Load()
+}
+
+// sampled returns whether the next query is compared.
+func (ix *Index[Id, N]) sampled() bool {
+	return ix.rate > 0 && (ix.rate >= 1 || rand.Float64() < ix.rate)
+}
+
+// compare reports d if got holds other nodes than want, counted by id.
+func (ix *Index[Id, N]) compare(d Divergence[Id, N], want, got spatial_hash.NodeSlice[Id, N]) {
+	ix.compared.Add(1)
+
+	counts := make(map[Id]int, len(want))
+
+	for _, n := range want {
+		counts[n.GetId()]++
+	}
+
+	for _, n := range got {
+		counts[n.GetId()]--
+	}
+
+	for id, count := range counts {
+		for ; count > 0; count-- {
+			d.Missing = append(d.Missing, id)
+		}
+
+		for ; count < 0; count++ {
+			d.Extra = append(d.Extra, id)
+		}
+	}
+
+	if len(d.Missing) == 0 && len(d.Extra) == 0 {
+		return
+	}
+
+	ix.diverged.Add(1)
+
+	if ix.report != nil {
+		ix.report(d)
+	}
+}
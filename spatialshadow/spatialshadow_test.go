@@ -0,0 +1,107 @@
+package spatialshadow
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+	"github.com/youdie323323/go-spatial-hash/spatialtest"
+)
+
+// leakyIndex is a broken backend that never removes nodes.
+type leakyIndex struct {
+	*spatialtest.Naive[int, float64]
+}
+
+func (ix leakyIndex) Remove(n spatial_hash.Node[int, float64]) {}
+
+func TestShadowAgrees(t *testing.T) {
+	var divergences []Divergence[int, float64]
+
+	// The candidate finds moved nodes by their old position, which the primary overwrites
+	ix := New(spatialtest.NewNaive[int, float64](), spatial_hash.NewSpatialHash[int, float64](50), 1, func(d Divergence[int, float64]) {
+		divergences = append(divergences, d)
+	})
+
+	points := spatialtest.Uniform(rand.New(rand.NewPCG(1, 2)), 300, 1000)
+
+	for _, p := range points {
+		ix.Put(p)
+	}
+
+	for range 5 {
+		for _, p := range points {
+			p.MoveTo(p.X+40*rand.Float64()-20, p.Y+40*rand.Float64()-20)
+
+			ix.Update(p)
+		}
+
+		for range 50 {
+			ix.Search(1000*rand.Float64(), 1000*rand.Float64(), 100*rand.Float64())
+		}
+	}
+
+	for _, p := range points[:50] {
+		ix.Remove(p)
+	}
+
+	for range 50 {
+		ix.Search(1000*rand.Float64(), 1000*rand.Float64(), 100*rand.Float64())
+	}
+
+	if len(divergences) != 0 {
+		t.Errorf("Expected no divergences, got %v", divergences)
+	}
+
+	if ix.Compared() != 300 {
+		t.Errorf("Expected 300 compared queries, got %d", ix.Compared())
+	}
+}
+
+func TestShadowReportsDivergence(t *testing.T) {
+	var divergences []Divergence[int, float64]
+
+	ix := New(spatial_hash.NewSpatialHash[int, float64](50), leakyIndex{spatialtest.NewNaive[int, float64]()}, 1, func(d Divergence[int, float64]) {
+		divergences = append(divergences, d)
+	})
+
+	p := spatialtest.NewPoint(7, 100, 100)
+	ix.Put(p)
+	ix.Remove(p)
+
+	if found := ix.Search(100, 100, 10); len(found) != 0 {
+		t.Errorf("Expected the primary's results, got %d nodes", len(found))
+	}
+
+	if len(divergences) != 1 || ix.Diverged() != 1 {
+		t.Fatalf("Expected 1 divergence, got %d", len(divergences))
+	}
+
+	if d := divergences[0]; d.Query != QuerySearch || d.Radius != 10 || len(d.Missing) != 0 || len(d.Extra) != 1 || d.Extra[0] != 7 {
+		t.Errorf("Expected node 7 extra in the search, got %+v", d)
+	}
+}
+
+func TestShadowSampling(t *testing.T) {
+	ix := New(spatial_hash.NewSpatialHash[int, float64](50), spatialtest.NewNaive[int, float64](), 0, nil)
+
+	ix.Put(spatialtest.NewPoint(0, 10, 10))
+
+	for range 100 {
+		ix.Search(10, 10, 5)
+	}
+
+	if ix.Compared() != 0 {
+		t.Errorf("Expected no compared queries at rate 0, got %d", ix.Compared())
+	}
+
+	ix = New(spatial_hash.NewSpatialHash[int, float64](50), spatialtest.NewNaive[int, float64](), 0.5, nil)
+
+	for range 1000 {
+		ix.Search(10, 10, 5)
+	}
+
+	if c := ix.Compared(); c < 350 || c > 650 {
+		t.Errorf("Expected about half the queries compared, got %d", c)
+	}
+}
@@ -0,0 +1,34 @@
+package spatialsim
+
+import (
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Entity is a simulated node moved by the harness.
+type Entity struct {
+	id int
+
+	x, y       float64
+	oldX, oldY float64
+
+	// vx, vy is the velocity in units per tick.
+	vx, vy float64
+
+	// Orbit parameters, used only by the Orbit movement.
+	centerX, centerY float64
+	orbitRadius      float64
+	angle, angleStep float64
+}
+
+var _ spatial_hash.Node[int, float64] = (*Entity)(nil) // *Entity must implement Node
+
+func (e *Entity) GetId() int { return e.id }
+
+func (e *Entity) GetX() float64 { return e.x }
+func (e *Entity) GetY() float64 { return e.y }
+
+func (e *Entity) SetOldPos(x, y float64)        { e.oldX, e.oldY = x, y }
+func (e *Entity) GetOldPos() (float64, float64) { return e.oldX, e.oldY }
+
+// GetVelocity returns the velocity of the entity in units per tick.
+func (e *Entity) GetVelocity() (float64, float64) { return e.vx, e.vy }
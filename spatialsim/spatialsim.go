@@ -0,0 +1,399 @@
+// Package spatialsim drives a spatial index through a simulated game loop.
+//
+// Each tick moves every entity, updates it in the index, and runs a mix of
+// queries, timing the whole tick. The resulting tick-time percentiles are a
+// practical way to choose a cell size and backend for a workload, and with
+// verification enabled the harness doubles as a soak test.
+package spatialsim
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Index is the spatial index type driven by the harness.
+type Index = spatial_hash.SpatialIndex[int, float64]
+
+// Movement selects how entities move each tick.
+type Movement int
+
+const (
+	// RandomWalk jitters each entity's velocity by a random amount every tick.
+	RandomWalk Movement = iota
+	// Flocking steers entities with the classic separation, alignment and cohesion rules,
+	// using the index itself to find flock mates.
+	Flocking
+	// Orbit moves entities along circles around random centers.
+	Orbit
+)
+
+// String returns the name of the movement.
+func (m Movement) String() string {
+	switch m {
+	case RandomWalk:
+		return "random-walk"
+	case Flocking:
+		return "flocking"
+	case Orbit:
+		return "orbit"
+	default:
+		return fmt.Sprintf("Movement(%d)", int(m))
+	}
+}
+
+// QueryMix describes the queries run every tick.
+type QueryMix struct {
+	// PerTick is the number of queries run every tick.
+	PerTick int
+
+	// SearchWeight and RectWeight are the relative frequencies of Search and QueryRect.
+	// When both are zero, only Search is run.
+	SearchWeight, RectWeight int
+
+	// Radius is the Search radius.
+	Radius float64
+	// Width and Height are the QueryRect size.
+	Width, Height float64
+}
+
+// Config configures a simulation run.
+type Config struct {
+	// Entities is the number of simulated entities.
+	Entities int
+	// WorldSize is the side length of the square world, with the origin at one corner.
+	WorldSize float64
+
+	// Movement is the movement pattern of the entities.
+	Movement Movement
+	// Speed is the maximum distance an entity moves per tick.
+	Speed float64
+
+	// Ticks is the number of timed ticks.
+	Ticks int
+	// Warmup is the number of untimed ticks run before the timed ones.
+	Warmup int
+
+	// Queries is the query mix run every tick.
+	Queries QueryMix
+
+	// VerifyEvery, if positive, checks every VerifyEvery-th tick's Search results
+	// against a brute-force search and counts mismatches in the result.
+	VerifyEvery int
+
+	// Seed seeds the random source, making runs reproducible.
+	Seed uint64
+}
+
+// Result holds the measurements of a simulation run.
+type Result struct {
+	// TickTimes is the duration of every timed tick, in tick order.
+	TickTimes []time.Duration
+
+	// Queries is the number of queries run during timed ticks.
+	Queries int
+	// Found is the total number of nodes returned by those queries.
+	Found int
+
+	// Mismatches is the number of verified Search results that differed from brute force.
+	Mismatches int
+}
+
+// Percentile returns the p-th percentile (0 to 100) of the tick times, using the nearest-rank method.
+func (r *Result) Percentile(p float64) time.Duration {
+	if len(r.TickTimes) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(r.TickTimes)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// Mean returns the average tick time.
+func (r *Result) Mean() time.Duration {
+	if len(r.TickTimes) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+
+	for _, d := range r.TickTimes {
+		total += d
+	}
+
+	return total / time.Duration(len(r.TickTimes))
+}
+
+// sim is the state of a running simulation.
+type sim struct {
+	cfg   Config
+	index Index
+	rng   *rand.Rand
+
+	entities []*Entity
+}
+
+// Run populates index with entities, runs the configured ticks and returns the measurements.
+// The index should be empty; it is reset when the run ends.
+func Run(index Index, cfg Config) Result {
+	s := &sim{
+		cfg:   cfg,
+		index: index,
+		rng:   rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+	}
+
+	s.populate()
+	defer index.Reset()
+
+	for tick := range cfg.Warmup {
+		s.tick(tick, nil)
+	}
+
+	result := Result{TickTimes: make([]time.Duration, 0, cfg.Ticks)}
+
+	for tick := range cfg.Ticks {
+		start := time.Now()
+
+		s.tick(cfg.Warmup+tick, &result)
+
+		result.TickTimes = append(result.TickTimes, time.Since(start))
+	}
+
+	return result
+}
+
+// populate creates the entities and puts them into the index.
+func (s *sim) populate() {
+	size := s.cfg.WorldSize
+
+	s.entities = make([]*Entity, s.cfg.Entities)
+
+	for i := range s.entities {
+		x, y := size*s.rng.Float64(), size*s.rng.Float64()
+
+		e := &Entity{
+			id: i,
+
+			x: x,
+			y: y,
+
+			oldX: x,
+			oldY: y,
+		}
+
+		angle := 2 * math.Pi * s.rng.Float64()
+		e.vx, e.vy = s.cfg.Speed*math.Cos(angle), s.cfg.Speed*math.Sin(angle)
+
+		if s.cfg.Movement == Orbit {
+			e.orbitRadius = size / 20 * (0.5 + s.rng.Float64())
+			e.centerX, e.centerY = x, y
+			e.angle = angle
+			e.angleStep = s.cfg.Speed / e.orbitRadius
+
+			e.x = e.centerX + e.orbitRadius*math.Cos(e.angle)
+			e.y = e.centerY + e.orbitRadius*math.Sin(e.angle)
+			e.oldX, e.oldY = e.x, e.y
+		}
+
+		s.entities[i] = e
+
+		s.index.Put(e)
+	}
+}
+
+// tick runs one simulation tick, recording queries into result if it is non-nil.
+func (s *sim) tick(tick int, result *Result) {
+	for _, e := range s.entities {
+		e.SetOldPos(e.x, e.y)
+
+		switch s.cfg.Movement {
+		case RandomWalk:
+			s.walk(e)
+
+		case Flocking:
+			s.flock(e)
+
+		case Orbit:
+			s.orbit(e)
+		}
+
+		s.index.Update(e)
+	}
+
+	verify := result != nil && s.cfg.VerifyEvery > 0 && tick%s.cfg.VerifyEvery == 0
+
+	s.query(verify, result)
+}
+
+// walk moves e by a randomly jittered velocity.
+func (s *sim) walk(e *Entity) {
+	speed := s.cfg.Speed
+
+	e.vx += speed * (s.rng.Float64() - 0.5)
+	e.vy += speed * (s.rng.Float64() - 0.5)
+
+	s.limitSpeed(e)
+	s.advance(e)
+}
+
+// flockRadius returns the distance within which entities consider each other flock mates.
+func (s *sim) flockRadius() float64 {
+	return max(s.cfg.Speed*10, s.cfg.WorldSize/100)
+}
+
+// flock steers e towards its flock mates.
+func (s *sim) flock(e *Entity) {
+	radius := s.flockRadius()
+
+	var (
+		count          float64
+		sumX, sumY     float64
+		sumVX, sumVY   float64
+		sepX, sepY     float64
+		separationDist = radius / 3
+	)
+
+	for _, n := range s.index.Search(e.x, e.y, radius) {
+		other, ok := n.(*Entity)
+		if !ok || other == e {
+			continue
+		}
+
+		count++
+
+		sumX += other.x
+		sumY += other.y
+
+		sumVX += other.vx
+		sumVY += other.vy
+
+		if dx, dy := e.x-other.x, e.y-other.y; dx*dx+dy*dy < separationDist*separationDist {
+			sepX += dx
+			sepY += dy
+		}
+	}
+
+	if count > 0 {
+		// Cohesion
+		e.vx += (sumX/count - e.x) * 0.01
+		e.vy += (sumY/count - e.y) * 0.01
+
+		// Alignment
+		e.vx += (sumVX/count - e.vx) * 0.05
+		e.vy += (sumVY/count - e.vy) * 0.05
+
+		// Separation
+		e.vx += sepX * 0.05
+		e.vy += sepY * 0.05
+	}
+
+	s.limitSpeed(e)
+	s.advance(e)
+}
+
+// orbit moves e along its orbit.
+func (s *sim) orbit(e *Entity) {
+	e.angle += e.angleStep
+
+	x := e.centerX + e.orbitRadius*math.Cos(e.angle)
+	y := e.centerY + e.orbitRadius*math.Sin(e.angle)
+
+	e.vx, e.vy = x-e.x, y-e.y
+	e.x, e.y = x, y
+}
+
+// limitSpeed scales the velocity of e down to the configured speed.
+func (s *sim) limitSpeed(e *Entity) {
+	speed := math.Hypot(e.vx, e.vy)
+
+	if speed > s.cfg.Speed && speed > 0 {
+		scale := s.cfg.Speed / speed
+
+		e.vx *= scale
+		e.vy *= scale
+	}
+}
+
+// advance moves e by its velocity, bouncing off the world edges.
+func (s *sim) advance(e *Entity) {
+	size := s.cfg.WorldSize
+
+	e.x += e.vx
+	e.y += e.vy
+
+	if e.x < 0 || e.x > size {
+		e.vx = -e.vx
+		e.x = min(max(e.x, 0), size)
+	}
+
+	if e.y < 0 || e.y > size {
+		e.vy = -e.vy
+		e.y = min(max(e.y, 0), size)
+	}
+}
+
+// query runs the configured query mix.
+func (s *sim) query(verify bool, result *Result) {
+	mix := s.cfg.Queries
+	size := s.cfg.WorldSize
+
+	totalWeight := mix.SearchWeight + mix.RectWeight
+
+	for range mix.PerTick {
+		x, y := size*s.rng.Float64(), size*s.rng.Float64()
+
+		var found spatial_hash.NodeSlice[int, float64]
+
+		if totalWeight == 0 || s.rng.IntN(totalWeight) < mix.SearchWeight {
+			found = s.index.Search(x, y, mix.Radius)
+
+			if verify && !s.matchesBruteForce(found, x, y, mix.Radius) {
+				result.Mismatches++
+			}
+		} else {
+			found = s.index.QueryRect(x, y, mix.Width, mix.Height)
+		}
+
+		if result != nil {
+			result.Queries++
+			result.Found += len(found)
+		}
+	}
+}
+
+// matchesBruteForce reports whether found holds exactly the entities within radius of (x, y).
+func (s *sim) matchesBruteForce(found spatial_hash.NodeSlice[int, float64], x, y, radius float64) bool {
+	seen := make(map[int]struct{}, len(found))
+
+	for _, n := range found {
+		seen[n.GetId()] = struct{}{}
+	}
+
+	if len(seen) != len(found) {
+		return false
+	}
+
+	expected := 0
+
+	for _, e := range s.entities {
+		dx, dy := e.x-x, e.y-y
+
+		if dx*dx+dy*dy <= radius*radius {
+			if _, ok := seen[e.id]; !ok {
+				return false
+			}
+
+			expected++
+		}
+	}
+
+	return expected == len(found)
+}
@@ -0,0 +1,72 @@
+package spatialsim
+
+import (
+	"testing"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+func testConfig(movement Movement) Config {
+	return Config{
+		Entities:  2000,
+		WorldSize: 2000,
+
+		Movement: movement,
+		Speed:    5,
+
+		Ticks:  20,
+		Warmup: 5,
+
+		Queries: QueryMix{
+			PerTick: 100,
+
+			SearchWeight: 3,
+			RectWeight:   1,
+
+			Radius: 60,
+			Width:  100,
+			Height: 50,
+		},
+
+		VerifyEvery: 1,
+
+		Seed: 1,
+	}
+}
+
+func TestRunSoak(t *testing.T) {
+	for _, movement := range []Movement{RandomWalk, Flocking, Orbit} {
+		t.Run(movement.String(), func(t *testing.T) {
+			cfg := testConfig(movement)
+
+			result := Run(spatial_hash.NewSpatialHash[int, float64](50), cfg)
+
+			if len(result.TickTimes) != cfg.Ticks {
+				t.Errorf("Expected %d tick times, got %d", cfg.Ticks, len(result.TickTimes))
+			}
+
+			if result.Queries != cfg.Ticks*cfg.Queries.PerTick {
+				t.Errorf("Expected %d queries, got %d", cfg.Ticks*cfg.Queries.PerTick, result.Queries)
+			}
+
+			if result.Mismatches != 0 {
+				t.Errorf("Expected no mismatches against brute force, got %d", result.Mismatches)
+			}
+
+			if p50, p99 := result.Percentile(50), result.Percentile(99); p50 > p99 {
+				t.Errorf("p50 %v is greater than p99 %v", p50, p99)
+			}
+		})
+	}
+}
+
+func TestRunReproducible(t *testing.T) {
+	cfg := testConfig(RandomWalk)
+
+	first := Run(spatial_hash.NewSpatialHash[int, float64](50), cfg)
+	second := Run(spatial_hash.NewSpatialHash[int, float64](50), cfg)
+
+	if first.Found != second.Found {
+		t.Errorf("Same seed found %d and %d nodes", first.Found, second.Found)
+	}
+}
@@ -0,0 +1,173 @@
+package spatialtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Factory creates an empty index with the given cell size.
+type Factory func(cellSize float64) Index
+
+// conformanceCellSizes are the cell sizes every distribution is checked with.
+var conformanceCellSizes = []float64{1, 50}
+
+// RunConformance checks indexes created by newIndex against the Naive reference,
+// for every distribution returned by Distributions.
+//
+// Search results must match the reference exactly. QueryRect results must contain
+// every node the reference returns, and may contain more, since backends are allowed
+// to return whole cells. Neither may contain duplicates.
+func RunConformance(t *testing.T, newIndex Factory) {
+	t.Helper()
+
+	for _, cellSize := range conformanceCellSizes {
+		for _, dist := range Distributions() {
+			t.Run(fmt.Sprintf("%s/CellSize=%v", dist.Name, cellSize), func(t *testing.T) {
+				runConformance(t, newIndex(cellSize), dist, cellSize)
+			})
+		}
+	}
+}
+
+func runConformance(t *testing.T, index Index, dist Distribution, cellSize float64) {
+	const count = 500
+
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	ref := NewNaive[int, float64]()
+
+	nodes := dist.Generate(rng, count, cellSize)
+
+	for _, n := range nodes {
+		index.Put(n)
+		ref.Put(n)
+	}
+
+	checkQueries(t, "after put", index, ref, nodes, cellSize, rng)
+
+	// Move every node to a position drawn from the same distribution
+	targets := dist.Generate(rng, len(nodes), cellSize)
+
+	for i, n := range nodes {
+		n.MoveTo(targets[i].X, targets[i].Y)
+
+		index.Update(n)
+		ref.Update(n)
+	}
+
+	checkQueries(t, "after update", index, ref, nodes, cellSize, rng)
+
+	for i, n := range nodes {
+		if i%3 == 0 {
+			index.Remove(n)
+			ref.Remove(n)
+		}
+	}
+
+	checkQueries(t, "after remove", index, ref, nodes, cellSize, rng)
+
+	index.Reset()
+
+	// A bounded search around every former position, since an unbounded one would cover
+	// more cells than there are integers
+	for _, n := range nodes {
+		if result := index.Search(n.X, n.Y, cellSize); len(result) != 0 {
+			t.Fatalf("after reset: Search(%v, %v, %v) returned %d nodes, want 0", n.X, n.Y, cellSize, len(result))
+		}
+	}
+}
+
+// checkQueries compares queries around adversarial points between index and ref.
+func checkQueries(t *testing.T, stage string, index, ref Index, nodes []*Point, cellSize float64, rng *rand.Rand) {
+	t.Helper()
+
+	radii := []float64{0, cellSize / 2, cellSize, 3 * cellSize}
+
+	check := func(x, y float64, radius float64) {
+		want := ids(ref.Search(x, y, radius))
+
+		got, dup := idsUnique(index.Search(x, y, radius))
+		if dup {
+			t.Errorf("%s: Search(%v, %v, %v) returned duplicates", stage, x, y, radius)
+		}
+
+		if missing, extra := diff(want, got); len(missing) > 0 || len(extra) > 0 {
+			t.Errorf("%s: Search(%v, %v, %v): missing %v, extra %v", stage, x, y, radius, missing, extra)
+		}
+
+		want = ids(ref.QueryRect(x, y, 2*radius, radius))
+
+		got, dup = idsUnique(index.QueryRect(x, y, 2*radius, radius))
+		if dup {
+			t.Errorf("%s: QueryRect(%v, %v, %v, %v) returned duplicates", stage, x, y, 2*radius, radius)
+		}
+
+		if missing, _ := diff(want, got); len(missing) > 0 {
+			t.Errorf("%s: QueryRect(%v, %v, %v, %v): missing %v", stage, x, y, 2*radius, radius, missing)
+		}
+	}
+
+	for range 50 {
+		n := nodes[rng.IntN(len(nodes))]
+		other := nodes[rng.IntN(len(nodes))]
+
+		// Centered on a node, including a radius reaching exactly another nearby node
+		nodeRadii := radii
+
+		if exact := math.Hypot(other.X-n.X, other.Y-n.Y); exact <= 4*cellSize {
+			nodeRadii = append(nodeRadii, exact)
+		}
+
+		for _, radius := range nodeRadii {
+			check(n.X, n.Y, radius)
+		}
+
+		// Centered on the corner of the node's cell
+		cornerX := math.Floor(n.X/cellSize) * cellSize
+		cornerY := math.Floor(n.Y/cellSize) * cellSize
+
+		for _, radius := range radii {
+			check(cornerX, cornerY, radius)
+		}
+	}
+}
+
+func ids(nodes []Node) map[int]struct{} {
+	set, _ := idsUnique(nodes)
+
+	return set
+}
+
+// idsUnique returns the set of ids in nodes and whether any id appeared more than once.
+func idsUnique(nodes []Node) (set map[int]struct{}, dup bool) {
+	set = make(map[int]struct{}, len(nodes))
+
+	for _, n := range nodes {
+		if _, ok := set[n.GetId()]; ok {
+			dup = true
+		}
+
+		set[n.GetId()] = struct{}{}
+	}
+
+	return set, dup
+}
+
+// diff returns the ids in want but not in got, and the ids in got but not in want.
+func diff(want, got map[int]struct{}) (missing, extra []int) {
+	for id := range want {
+		if _, ok := got[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	for id := range got {
+		if _, ok := want[id]; !ok {
+			extra = append(extra, id)
+		}
+	}
+
+	return missing, extra
+}
@@ -0,0 +1,180 @@
+package spatialtest
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Distribution is a named node generator used by the conformance suite.
+type Distribution struct {
+	Name string
+
+	// Generate creates count nodes for an index with the given cell size.
+	Generate func(rng *rand.Rand, count int, cellSize float64) []*Point
+}
+
+// Distributions returns the uniform distribution followed by the pathological ones.
+func Distributions() []Distribution {
+	return []Distribution{
+		{"Uniform", func(rng *rand.Rand, count int, cellSize float64) []*Point {
+			return Uniform(rng, count, 20*cellSize)
+		}},
+		{"SingleCell", func(rng *rand.Rand, count int, cellSize float64) []*Point {
+			return SingleCell(rng, count, cellSize, -3, 2)
+		}},
+		{"OnBoundaries", func(rng *rand.Rand, count int, cellSize float64) []*Point {
+			return OnBoundaries(rng, count, cellSize, 5)
+		}},
+		{"Lattice", func(_ *rand.Rand, count int, cellSize float64) []*Point {
+			side := int(math.Ceil(math.Sqrt(float64(count))))
+
+			return Lattice(side, side, cellSize/2, -cellSize*float64(side)/4, -cellSize*float64(side)/4)
+		}},
+		{"Extreme", func(rng *rand.Rand, count int, cellSize float64) []*Point {
+			return Extreme(rng, count, cellSize)
+		}},
+		{"CollidingCells", func(rng *rand.Rand, count int, cellSize float64) []*Point {
+			return CollidingCells(rng, count, cellSize)
+		}},
+	}
+}
+
+// Uniform places count nodes uniformly in the square from the origin to (size, size).
+func Uniform(rng *rand.Rand, count int, size float64) []*Point {
+	nodes := make([]*Point, count)
+
+	for i := range nodes {
+		nodes[i] = NewPoint(i, size*rng.Float64(), size*rng.Float64())
+	}
+
+	return nodes
+}
+
+// SingleCell places count nodes inside the single cell (cellX, cellY).
+// Some nodes sit exactly on the cell's minimum corner and edges.
+func SingleCell(rng *rand.Rand, count int, cellSize float64, cellX, cellY int) []*Point {
+	minX, minY := float64(cellX)*cellSize, float64(cellY)*cellSize
+
+	nodes := make([]*Point, count)
+
+	for i := range nodes {
+		x, y := minX+cellSize*rng.Float64(), minY+cellSize*rng.Float64()
+
+		switch i % 4 {
+		case 0:
+			x, y = minX, minY
+
+		case 1:
+			x = minX
+
+		case 2:
+			y = minY
+		}
+
+		nodes[i] = NewPoint(i, x, y)
+	}
+
+	return nodes
+}
+
+// OnBoundaries places count nodes exactly on cell boundaries within cells cells of the origin,
+// alternating between vertical edges, horizontal edges and corners.
+func OnBoundaries(rng *rand.Rand, count int, cellSize float64, cells int) []*Point {
+	boundary := func() float64 {
+		return float64(rng.IntN(2*cells+1)-cells) * cellSize
+	}
+
+	anywhere := func() float64 {
+		return float64(cells) * cellSize * (2*rng.Float64() - 1)
+	}
+
+	nodes := make([]*Point, count)
+
+	for i := range nodes {
+		var x, y float64
+
+		switch i % 3 {
+		case 0:
+			x, y = boundary(), anywhere()
+
+		case 1:
+			x, y = anywhere(), boundary()
+
+		case 2:
+			x, y = boundary(), boundary()
+		}
+
+		nodes[i] = NewPoint(i, x, y)
+	}
+
+	return nodes
+}
+
+// Lattice places cols*rows nodes on a grid with the given spacing, starting at the origin point.
+// With a spacing that divides the cell size, many nodes sit exactly on cell boundaries.
+func Lattice(cols, rows int, spacing, originX, originY float64) []*Point {
+	nodes := make([]*Point, 0, cols*rows)
+
+	for row := range rows {
+		for col := range cols {
+			nodes = append(nodes, NewPoint(len(nodes), originX+float64(col)*spacing, originY+float64(row)*spacing))
+		}
+	}
+
+	return nodes
+}
+
+// Extreme places count nodes at extreme coordinates: far from the origin in every quadrant,
+// at cell indices beyond 16 bits, and at tiny magnitudes straddling zero.
+func Extreme(rng *rand.Rand, count int, cellSize float64) []*Point {
+	// Far enough that cell indices overflow 16 bits, near enough that float64 keeps sub-cell precision
+	far := cellSize * (1 << 24)
+
+	// Tiny, but large enough that squared distances do not underflow to zero
+	tiny := cellSize * 1e-12
+
+	nodes := make([]*Point, count)
+
+	for i := range nodes {
+		var x, y float64
+
+		switch i % 4 {
+		case 0:
+			x, y = far+cellSize*rng.Float64(), far+cellSize*rng.Float64()
+
+		case 1:
+			x, y = -far-cellSize*rng.Float64(), far+cellSize*rng.Float64()
+
+		case 2:
+			x, y = tiny*float64(rng.IntN(5)-2), tiny*float64(rng.IntN(5)-2)
+
+		case 3:
+			x, y = far*(2*rng.Float64()-1), far*(2*rng.Float64()-1)
+		}
+
+		nodes[i] = NewPoint(i, x, y)
+	}
+
+	return nodes
+}
+
+// CollidingCells places count nodes in pairs of distant cells, (cx, cy) near the origin and
+// (cx^1, cy+65536), which a hash packing cell coordinates into 16-bit fields maps to the same
+// key, such as (1, 0) and (0, 65536). Searches around either cell must not return the other.
+func CollidingCells(rng *rand.Rand, count int, cellSize float64) []*Point {
+	nodes := make([]*Point, count)
+
+	for i := range nodes {
+		cx, cy := rng.IntN(4), rng.IntN(4)
+
+		if i%2 == 1 {
+			cx, cy = cx^1, cy+1<<16
+		}
+
+		x, y := (float64(cx)+rng.Float64())*cellSize, (float64(cy)+rng.Float64())*cellSize
+
+		nodes[i] = NewPoint(i, x, y)
+	}
+
+	return nodes
+}
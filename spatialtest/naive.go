@@ -0,0 +1,93 @@
+package spatialtest
+
+import (
+	"sync"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Naive is a brute-force spatial index that scans every node on each query.
+// It is slow but obviously correct, which makes it the reference for other backends.
+//
+// Unlike SpatialHash, QueryRect returns only the nodes inside the rectangle.
+type Naive[Id comparable, N spatial_hash.Number] struct {
+	mu    sync.RWMutex
+	nodes map[Id]spatial_hash.Node[Id, N]
+}
+
+// NewNaive creates an empty brute-force index.
+func NewNaive[Id comparable, N spatial_hash.Number]() *Naive[Id, N] {
+	return &Naive[Id, N]{nodes: make(map[Id]spatial_hash.Node[Id, N])}
+}
+
+var _ spatial_hash.SpatialIndex[int, float64] = (*Naive[int, float64])(nil) // *Naive must implement SpatialIndex
+
+func (ix *Naive[Id, N]) Put(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.nodes[n.GetId()] = n
+}
+
+func (ix *Naive[Id, N]) Remove(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	delete(ix.nodes, n.GetId())
+}
+
+func (ix *Naive[Id, N]) Update(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.nodes[n.GetId()] = n
+
+	n.SetOldPos(n.GetX(), n.GetY())
+}
+
+func (ix *Naive[Id, N]) Search(x, y, radius N) spatial_hash.NodeSlice[Id, N] {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var result spatial_hash.NodeSlice[Id, N]
+
+	radiusSq := radius * radius
+
+	for _, n := range ix.nodes {
+		dx := n.GetX() - x
+		dy := n.GetY() - y
+
+		if dx*dx+dy*dy <= radiusSq {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+func (ix *Naive[Id, N]) QueryRect(x, y, width, height N) spatial_hash.NodeSlice[Id, N] {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var result spatial_hash.NodeSlice[Id, N]
+
+	halfWidth := width / N(2)
+	halfHeight := height / N(2)
+
+	for _, n := range ix.nodes {
+		nx, ny := n.GetX(), n.GetY()
+
+		if nx >= x-halfWidth && nx <= x+halfWidth && ny >= y-halfHeight && ny <= y+halfHeight {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+func (ix *Naive[Id, N]) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	clear(ix.nodes)
+}
@@ -0,0 +1,7 @@
+package spatialtest
+
+import "testing"
+
+func TestNaiveConformance(t *testing.T) {
+	RunConformance(t, func(float64) Index { return NewNaive[int, float64]() })
+}
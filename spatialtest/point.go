@@ -0,0 +1,51 @@
+// Package spatialtest provides utilities for testing spatial index backends:
+// a brute-force reference index, generators for uniform and pathological node
+// distributions, and a conformance suite checking a backend against the reference.
+package spatialtest
+
+import (
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Node is the node type used by the generators and the conformance suite.
+type Node = spatial_hash.Node[int, float64]
+
+// Index is the index type checked by the conformance suite.
+type Index = spatial_hash.SpatialIndex[int, float64]
+
+// Point is a basic implementation of the Node interface.
+type Point struct {
+	Id int
+
+	X, Y       float64
+	OldX, OldY float64
+}
+
+// NewPoint creates a point whose old position is its current position.
+func NewPoint(id int, x, y float64) *Point {
+	return &Point{
+		Id: id,
+
+		X: x,
+		Y: y,
+
+		OldX: x,
+		OldY: y,
+	}
+}
+
+var _ Node = (*Point)(nil) // *Point must implement Node
+
+func (p *Point) GetId() int { return p.Id }
+
+func (p *Point) GetX() float64 { return p.X }
+func (p *Point) GetY() float64 { return p.Y }
+
+func (p *Point) SetOldPos(x, y float64)        { p.OldX, p.OldY = x, y }
+func (p *Point) GetOldPos() (float64, float64) { return p.OldX, p.OldY }
+
+// MoveTo moves the point, remembering its current position as the old one.
+func (p *Point) MoveTo(x, y float64) {
+	p.OldX, p.OldY = p.X, p.Y
+	p.X, p.Y = x, y
+}
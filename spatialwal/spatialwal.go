@@ -0,0 +1,325 @@
+// Package spatialwal logs the mutations of a spatial index to a write-ahead log, so that a
+// crashed server can reconstruct the last known position of every node.
+//
+// The log holds one JSON record per line. Checkpoints write the whole state as a single
+// record, after which older records are no longer needed: Rotate starts a fresh log with a
+// checkpoint, so that logs stay short. Recover replays a log, tolerating a record torn by
+// the crash at its end.
+package spatialwal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+)
+
+// Op is the kind of a logged mutation.
+type Op string
+
+const (
+	// OpPut records a node put at a position.
+	OpPut Op = "put"
+	// OpUpdate records a node moved to a position.
+	OpUpdate Op = "update"
+	// OpRemove records a node removed.
+	OpRemove Op = "remove"
+	// OpReset records every node removed.
+	OpReset Op = "reset"
+	// OpCheckpoint records the whole state, replacing everything logged before.
+	OpCheckpoint Op = "checkpoint"
+)
+
+// Entry is the position of one node in a checkpoint.
+type Entry[Id comparable, N spatial_hash.Number] struct {
+	Id Id `json:"id"`
+	X  N  `json:"x"`
+	Y  N  `json:"y"`
+}
+
+// Record is one line of the log.
+type Record[Id comparable, N spatial_hash.Number] struct {
+	Op Op `json:"op"`
+
+	// Id, X and Y are set for put, update and remove records.
+	Id Id `json:"id,omitzero"`
+	X  N  `json:"x,omitzero"`
+	Y  N  `json:"y,omitzero"`
+
+	// Nodes is set for checkpoint records.
+	Nodes []Entry[Id, N] `json:"nodes,omitempty"`
+}
+
+// ErrCorrupt is returned by Recover for a log with a malformed record before its last line.
+var ErrCorrupt = errors.New("spatialwal: corrupt log")
+
+// Index wraps a spatial index, logging every mutation before applying it.
+// Queries are passed through unchanged. Index is safe for concurrent use; mutations are
+// serialized so that the log order matches the order they were applied in.
+//
+// A mutation whose record cannot be encoded, such as a node put or moved to a NaN or
+// infinite coordinate, is rejected: it is neither logged nor applied, and the error is
+// reported by Err and the next Flush.
+type Index[Id comparable, N spatial_hash.Number] struct {
+	index spatial_hash.SpatialIndex[Id, N]
+
+	mu sync.Mutex
+
+	w   *bufio.Writer
+	dst io.Writer
+
+	// positions holds the last known position of every node, written by checkpoints.
+	positions map[Id]spatial_hash.Vec2[N]
+
+	// err is the first write error, after which nothing more is logged.
+	err error
+	// rejected is the first encoding error since the last Flush.
+	rejected error
+}
+
+var _ spatial_hash.SpatialIndex[int, float64] = (*Index[int, float64])(nil) // *Index must implement SpatialIndex
+
+// New wraps index, logging its mutations to w. The index should be empty.
+// Records are buffered: call Flush to make sure they reach w.
+func New[Id comparable, N spatial_hash.Number](index spatial_hash.SpatialIndex[Id, N], w io.Writer) *Index[Id, N] {
+	ix := &Index[Id, N]{
+		index: index,
+
+		positions: make(map[Id]spatial_hash.Vec2[N]),
+	}
+
+	ix.setWriter(w)
+
+	return ix
+}
+
+// setWriter makes w the destination of the log.
+func (ix *Index[Id, N]) setWriter(w io.Writer) {
+	ix.dst = w
+	ix.w = bufio.NewWriter(w)
+}
+
+// log writes r, keeping the first write error. It reports false, keeping the first encoding
+// error, if r cannot be encoded, in which case its mutation must not be applied.
+func (ix *Index[Id, N]) log(r Record[Id, N]) bool {
+	data, err := json.Marshal(r)
+	if err != nil {
+		if ix.rejected == nil {
+			ix.rejected = fmt.Errorf("spatialwal: %s record for node %v rejected: %w", r.Op, r.Id, err)
+		}
+
+		return false
+	}
+
+	if ix.err == nil {
+		_, ix.err = ix.w.Write(append(data, '\n'))
+	}
+
+	return true
+}
+
+func (ix *Index[Id, N]) Put(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.log(Record[Id, N]{Op: OpPut, Id: n.GetId(), X: n.GetX(), Y: n.GetY()}) {
+		return
+	}
+
+	ix.positions[n.GetId()] = spatial_hash.Vec2[N]{X: n.GetX(), Y: n.GetY()}
+	ix.index.Put(n)
+}
+
+func (ix *Index[Id, N]) Remove(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.log(Record[Id, N]{Op: OpRemove, Id: n.GetId()}) {
+		return
+	}
+
+	delete(ix.positions, n.GetId())
+	ix.index.Remove(n)
+}
+
+func (ix *Index[Id, N]) Update(n spatial_hash.Node[Id, N]) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	pos := spatial_hash.Vec2[N]{X: n.GetX(), Y: n.GetY()}
+
+	// Nodes that did not move are not logged
+	if old, ok := ix.positions[n.GetId()]; !ok || old != pos {
+		if !ix.log(Record[Id, N]{Op: OpUpdate, Id: n.GetId(), X: pos.X, Y: pos.Y}) {
+			return
+		}
+
+		ix.positions[n.GetId()] = pos
+	}
+
+	ix.index.Update(n)
+}
+
+func (ix *Index[Id, N]) Search(x, y, radius N) spatial_hash.NodeSlice[Id, N] {
+	return ix.index.Search(x, y, radius)
+}
+
+func (ix *Index[Id, N]) QueryRect(x, y, width, height N) spatial_hash.NodeSlice[Id, N] {
+	return ix.index.QueryRect(x, y, width, height)
+}
+
+func (ix *Index[Id, N]) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.log(Record[Id, N]{Op: OpReset})
+
+	clear(ix.positions)
+	ix.index.Reset()
+}
+
+// Checkpoint logs the position of every node as a single record, so that recovery
+// does not need the records before it.
+func (ix *Index[Id, N]) Checkpoint() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.checkpoint()
+
+	return ix.flush()
+}
+
+func (ix *Index[Id, N]) checkpoint() {
+	nodes := make([]Entry[Id, N], 0, len(ix.positions))
+
+	for id, pos := range ix.positions {
+		nodes = append(nodes, Entry[Id, N]{Id: id, X: pos.X, Y: pos.Y})
+	}
+
+	ix.log(Record[Id, N]{Op: OpCheckpoint, Nodes: nodes})
+}
+
+// Rotate flushes the current log, then switches to w, starting it with a checkpoint.
+// Once Rotate returns nil, the previous log is no longer needed for recovery.
+//
+// Rotate is also how logging resumes after a write error: it switches to w even if the
+// previous log failed, returning that error, and the checkpoint holds every node, including
+// those mutated while nothing was logged. Err then reports only errors of the new log.
+func (ix *Index[Id, N]) Rotate(w io.Writer) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	var previous error
+
+	if err := ix.flush(); err != nil {
+		previous = fmt.Errorf("spatialwal: previous log: %w", err)
+	}
+
+	ix.setWriter(w)
+	ix.err = nil
+
+	ix.checkpoint()
+
+	return errors.Join(previous, ix.flush())
+}
+
+// Flush writes any buffered records to the log, and syncs it to stable storage
+// if it has a Sync method, as *os.File does. Besides write errors, it returns the
+// first mutation rejected since the previous Flush.
+func (ix *Index[Id, N]) Flush() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	rejected := ix.rejected
+	ix.rejected = nil
+
+	return errors.Join(ix.flush(), rejected)
+}
+
+func (ix *Index[Id, N]) flush() error {
+	if ix.err != nil {
+		return ix.err
+	}
+
+	if ix.err = ix.w.Flush(); ix.err != nil {
+		return ix.err
+	}
+
+	if s, ok := ix.dst.(interface{ Sync() error }); ok {
+		ix.err = s.Sync()
+	}
+
+	return ix.err
+}
+
+// Err returns the first error met while writing the log, and the first mutation rejected
+// since the last Flush. Once a write error occurs, nothing more is logged until Rotate
+// switches to a new log, but the wrapped index keeps being updated.
+func (ix *Index[Id, N]) Err() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	return errors.Join(ix.err, ix.rejected)
+}
+
+// Recover replays the log read from r and returns the last known position of every node.
+// A malformed last line, left by a write torn by a crash, is ignored; a malformed line
+// anywhere else fails with ErrCorrupt.
+func Recover[Id comparable, N spatial_hash.Number](r io.Reader) (map[Id]spatial_hash.Vec2[N], error) {
+	positions := make(map[Id]spatial_hash.Vec2[N])
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+
+	line := 0
+
+	// A malformed line is only an error if another line follows it
+	var malformed error
+
+	for scanner.Scan() {
+		line++
+
+		if malformed != nil {
+			return nil, malformed
+		}
+
+		var rec Record[Id, N]
+
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			malformed = fmt.Errorf("%w: line %d: %v", ErrCorrupt, line, err)
+
+			continue
+		}
+
+		switch rec.Op {
+		case OpPut, OpUpdate:
+			positions[rec.Id] = spatial_hash.Vec2[N]{X: rec.X, Y: rec.Y}
+
+		case OpRemove:
+			delete(positions, rec.Id)
+
+		case OpReset:
+			clear(positions)
+
+		case OpCheckpoint:
+			clear(positions)
+
+			for _, e := range rec.Nodes {
+				positions[e.Id] = spatial_hash.Vec2[N]{X: e.X, Y: e.Y}
+			}
+
+		default:
+			malformed = fmt.Errorf("%w: line %d: unknown op %q", ErrCorrupt, line, rec.Op)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
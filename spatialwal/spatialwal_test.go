@@ -0,0 +1,235 @@
+package spatialwal
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	spatial_hash "github.com/youdie323323/go-spatial-hash"
+	"github.com/youdie323323/go-spatial-hash/spatialtest"
+)
+
+// checkRecovered compares the positions recovered from log against points, skipping removed ones.
+func checkRecovered(t *testing.T, log []byte, points []*spatialtest.Point, removed map[int]bool) {
+	t.Helper()
+
+	positions, err := Recover[int, float64](bytes.NewReader(log))
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+
+	if len(positions) != len(points)-len(removed) {
+		t.Fatalf("Expected %d recovered nodes, got %d", len(points)-len(removed), len(positions))
+	}
+
+	for _, p := range points {
+		pos, ok := positions[p.Id]
+
+		if removed[p.Id] {
+			if ok {
+				t.Fatalf("Removed node %d was recovered", p.Id)
+			}
+
+			continue
+		}
+
+		if !ok || pos.X != p.X || pos.Y != p.Y {
+			t.Fatalf("Node %d: expected (%v, %v), got %v", p.Id, p.X, p.Y, pos)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var log bytes.Buffer
+
+	ix := New(spatial_hash.NewSpatialHash[int, float64](50), &log)
+
+	points := make([]*spatialtest.Point, 200)
+
+	for i := range points {
+		points[i] = spatialtest.NewPoint(i, 1000*rand.Float64(), 1000*rand.Float64())
+
+		ix.Put(points[i])
+	}
+
+	for range 5 {
+		for _, p := range points {
+			p.MoveTo(p.X+10*rand.Float64()-5, p.Y+10*rand.Float64()-5)
+
+			ix.Update(p)
+		}
+	}
+
+	removed := make(map[int]bool)
+
+	for _, p := range points[:20] {
+		ix.Remove(p)
+
+		removed[p.Id] = true
+	}
+
+	if err := ix.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	checkRecovered(t, log.Bytes(), points, removed)
+
+	// A record torn at the end of the log is ignored
+	torn := append(bytes.Clone(log.Bytes()), []byte(`{"op":"update","id":25,"x":1`)...)
+
+	checkRecovered(t, torn, points, removed)
+
+	// A malformed record in the middle is not
+	corrupt := []byte(`{"op":"put","id":1,"x":1,"y":1}` + "\ngarbage\n" + `{"op":"remove","id":1}` + "\n")
+
+	if _, err := Recover[int, float64](bytes.NewReader(corrupt)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+
+	if found := ix.Search(points[50].X, points[50].Y, 1); len(found) == 0 {
+		t.Error("Expected queries to reach the wrapped index")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	var first, second bytes.Buffer
+
+	ix := New(spatial_hash.NewSpatialHash[int, float64](50), &first)
+
+	points := make([]*spatialtest.Point, 100)
+
+	for i := range points {
+		points[i] = spatialtest.NewPoint(i, 1000*rand.Float64(), 1000*rand.Float64())
+
+		ix.Put(points[i])
+	}
+
+	if err := ix.Rotate(&second); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	// Nodes moved after the rotation are only in the new log
+	for _, p := range points[:10] {
+		p.MoveTo(p.X+1, p.Y+1)
+
+		ix.Update(p)
+	}
+
+	ix.Remove(points[99])
+
+	if err := ix.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if lines := strings.Count(second.String(), "\n"); lines != 12 {
+		t.Errorf("Expected a checkpoint and 11 records in the new log, got %d lines", lines)
+	}
+
+	checkRecovered(t, second.Bytes(), points, map[int]bool{99: true})
+
+	ix.Reset()
+	ix.Flush()
+
+	if positions, _ := Recover[int, float64](bytes.NewReader(second.Bytes())); len(positions) != 0 {
+		t.Errorf("Expected no nodes after a reset, got %d", len(positions))
+	}
+}
+
+func TestRejectUnencodable(t *testing.T) {
+	var log bytes.Buffer
+
+	sh := spatial_hash.NewSpatialHash[int, float64](50)
+	ix := New(sh, &log)
+
+	p, q := spatialtest.NewPoint(0, 10, 10), spatialtest.NewPoint(1, math.NaN(), 20)
+
+	ix.Put(p)
+	ix.Put(q)
+
+	if err := ix.Err(); err == nil {
+		t.Fatal("Expected the put at a NaN coordinate to be reported")
+	}
+
+	if len(sh.QueryRect(0, 0, 2000, 2000)) != 1 {
+		t.Error("Expected the rejected put not to be applied")
+	}
+
+	// Moving to an infinite coordinate is rejected too, and leaves the node where it was logged
+	p.MoveTo(math.Inf(1), 10)
+	ix.Update(p)
+
+	if err := ix.Flush(); err == nil {
+		t.Fatal("Expected Flush to report the rejected mutations")
+	}
+
+	if positions, _ := Recover[int, float64](bytes.NewReader(log.Bytes())); len(positions) != 1 || positions[0] != (spatial_hash.Vec2[float64]{X: 10, Y: 10}) {
+		t.Errorf("Expected only the first node at its first position, got %v", positions)
+	}
+
+	// The log keeps recording afterwards
+	p.MoveTo(30, 30)
+	ix.Update(p)
+
+	if err := ix.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	checkRecovered(t, log.Bytes(), []*spatialtest.Point{p}, nil)
+}
+
+// failingWriter fails every write once full.
+type failingWriter struct {
+	full bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.full {
+		return 0, errors.New("disk full")
+	}
+
+	return len(p), nil
+}
+
+func TestRotateAfterWriteError(t *testing.T) {
+	broken := &failingWriter{}
+
+	ix := New(spatial_hash.NewSpatialHash[int, float64](50), broken)
+
+	p, q := spatialtest.NewPoint(0, 10, 10), spatialtest.NewPoint(1, 20, 20)
+
+	ix.Put(p)
+
+	broken.full = true
+
+	if err := ix.Flush(); err == nil {
+		t.Fatal("Expected Flush to fail on a full disk")
+	}
+
+	// Mutated while nothing is logged
+	ix.Put(q)
+
+	p.MoveTo(15, 15)
+	ix.Update(p)
+
+	var fresh bytes.Buffer
+
+	if err := ix.Rotate(&fresh); err == nil {
+		t.Error("Expected Rotate to report the error of the previous log")
+	}
+
+	if err := ix.Err(); err != nil {
+		t.Fatalf("Expected the new log to be healthy, got %v", err)
+	}
+
+	q.MoveTo(25, 25)
+	ix.Update(q)
+
+	if err := ix.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	checkRecovered(t, fresh.Bytes(), []*spatialtest.Point{p, q}, nil)
+}
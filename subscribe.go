@@ -0,0 +1,169 @@
+package spatial_hash
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// CellEventKind is what happened to a node in a cell.
+type CellEventKind int
+
+const (
+	// CellAdded is a node stored in a cell: put there, or moved into it.
+	CellAdded CellEventKind = iota
+	// CellRemoved is a node no longer stored in a cell: removed, moved out of it, or reset.
+	CellRemoved
+)
+
+func (k CellEventKind) String() string {
+	switch k {
+	case CellAdded:
+		return "added"
+	case CellRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// CellEvent is a node added to or removed from cell (CellX, CellY).
+type CellEvent[Id comparable, N Number] struct {
+	Kind CellEventKind
+
+	CellX, CellY int
+
+	Node Node[Id, N]
+}
+
+// CellSubscription receives the events of a range of cells until cancelled.
+type CellSubscription[Id comparable, N Number] struct {
+	sh *SpatialHash[Id, N]
+
+	cells CellRect
+	fn    func(e CellEvent[Id, N])
+
+	// priority ranks the subscription when a degrading Ticker pauses subscriptions.
+	priority atomic.Int32
+	// dropped counts the events not delivered while paused.
+	dropped atomic.Int64
+}
+
+// SubscribeCells calls fn whenever a node is added to or removed from one of cells, so that
+// editors and debug tools pinned to grid coordinates can follow the cells they show. A bounded
+// node gets an event for every cell its bounding box enters or leaves. Nodes kept in a cell by
+// hysteresis stay there until they migrate. Reset visits every cell of the range, which is
+// meant to stay small.
+//
+// fn is called by the goroutine mutating the spatial hash, possibly from several at once, and
+// must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) SubscribeCells(cells CellRect, fn func(e CellEvent[Id, N])) *CellSubscription[Id, N] {
+	s := &CellSubscription[Id, N]{sh: sh, cells: cells, fn: fn}
+	s.priority.Store(int32(PriorityNormal))
+
+	for {
+		old := sh.subs.Load()
+
+		var subs []*CellSubscription[Id, N]
+		if old != nil {
+			subs = slices.Clone(*old)
+		}
+
+		subs = append(subs, s)
+
+		if sh.subs.CompareAndSwap(old, &subs) {
+			return s
+		}
+	}
+}
+
+// Cancel stops the subscription. Events may still be delivered by mutations in flight.
+// Cancelling more than once is a no-op.
+func (s *CellSubscription[Id, N]) Cancel() {
+	for {
+		old := s.sh.subs.Load()
+		if old == nil || !slices.Contains(*old, s) {
+			return
+		}
+
+		subs := slices.DeleteFunc(slices.Clone(*old), func(other *CellSubscription[Id, N]) bool { return other == s })
+
+		next := &subs
+		if len(subs) == 0 {
+			next = nil
+		}
+
+		if s.sh.subs.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Cells returns the range of cells the subscription follows.
+func (s *CellSubscription[Id, N]) Cells() CellRect {
+	return s.cells
+}
+
+// SetPriority sets the priority of the subscription, PriorityNormal by default. A Ticker
+// degrading under overload pauses subscriptions by increasing priority, such as those following
+// far regions set to PriorityLow, and never pauses PriorityCritical ones.
+func (s *CellSubscription[Id, N]) SetPriority(p Priority) {
+	s.priority.Store(int32(p))
+}
+
+// Dropped returns how many events were not delivered because the subscription was paused. A
+// subscriber seeing it grow should resynchronize with the cells it follows.
+func (s *CellSubscription[Id, N]) Dropped() int {
+	return int(s.dropped.Load())
+}
+
+// deliver calls fn with e, unless the subscription is paused.
+func (s *CellSubscription[Id, N]) deliver(e CellEvent[Id, N]) {
+	if s.priority.Load() < s.sh.pausedBelow.Load() {
+		s.dropped.Add(1)
+		s.sh.droppedEvents.Add(1)
+
+		return
+	}
+
+	s.fn(e)
+}
+
+// cellEvent delivers an event for n in cell c to the subscriptions following c.
+func (sh *SpatialHash[Id, N]) cellEvent(kind CellEventKind, c cell, n Node[Id, N]) {
+	subs := sh.subs.Load()
+	if subs == nil {
+		return
+	}
+
+	for _, s := range *subs {
+		if s.cells.Contains(c.x, c.y) {
+			s.deliver(CellEvent[Id, N]{Kind: kind, CellX: c.x, CellY: c.y, Node: n})
+		}
+	}
+}
+
+// cellsCleared delivers a removal event for every node in a subscribed cell, before they are all
+// cleared at once.
+func (sh *SpatialHash[Id, N]) cellsCleared() {
+	subs := sh.subs.Load()
+	if subs == nil {
+		return
+	}
+
+	for _, s := range *subs {
+		for cy := s.cells.MinY; cy <= s.cells.MaxY; cy++ {
+			for cx := s.cells.MinX; cx <= s.cells.MaxX; cx++ {
+				b, ok := sh.cellBucket(cx, cy)
+				if !ok {
+					continue
+				}
+
+				b.forEachEntry(func(e bucketEntry[Id, N]) bool {
+					s.deliver(CellEvent[Id, N]{Kind: CellRemoved, CellX: cx, CellY: cy, Node: e.node})
+
+					return true
+				})
+			}
+		}
+	}
+}
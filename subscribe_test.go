@@ -0,0 +1,98 @@
+package spatial_hash
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSpatialHashSubscribeCells(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	var events []string
+
+	sub := sh.SubscribeCells(CellRect{0, 0, 1, 0}, func(e CellEvent[int, float64]) {
+		events = append(events, fmt.Sprintf("%s %d (%d, %d)", e.Kind, e.Node.GetId(), e.CellX, e.CellY))
+	})
+
+	p := newPoint(0, 5, 5)
+	sh.Put(p)
+	sh.Put(newPoint(1, 50, 50)) // Outside the cells
+
+	p.x = 15
+	sh.Update(p)
+
+	p.x = 18
+	sh.Update(p) // Within its cell
+
+	p.x = 25
+	sh.Update(p)
+
+	q := newPoint(2, 3, 3)
+	sh.Put(q)
+	sh.Remove(q)
+	sh.Remove(q) // Not there anymore
+
+	expected := []string{
+		"added 0 (0, 0)",
+		"removed 0 (0, 0)",
+		"added 0 (1, 0)",
+		"removed 0 (1, 0)",
+		"added 2 (0, 0)",
+		"removed 2 (0, 0)",
+	}
+
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+
+	events = nil
+
+	sh.Put(newPoint(3, 12, 2))
+	sh.Reset()
+
+	if expected := []string{"added 3 (1, 0)", "removed 3 (1, 0)"}; !slices.Equal(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+
+	sub.Cancel()
+	sub.Cancel()
+
+	events = nil
+
+	sh.Put(newPoint(4, 5, 5))
+
+	if len(events) != 0 {
+		t.Errorf("Expected no events once cancelled, got %v", events)
+	}
+}
+
+func TestSpatialHashSubscribeCellsBounded(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	var events []string
+
+	sh.SubscribeCells(CellRect{0, 0, 2, 0}, func(e CellEvent[int, float64]) {
+		events = append(events, fmt.Sprintf("%s (%d, %d)", e.Kind, e.CellX, e.CellY))
+	})
+
+	// Spans cells 0 and 1
+	box := newBoxPoint(0, 10, 5, 4, 1)
+	sh.PutBounded(box)
+
+	// Spans cells 1 and 2
+	box.x = 16
+	sh.UpdateBounded(box)
+
+	sh.RemoveBounded(box)
+
+	expected := []string{
+		"added (0, 0)", "added (1, 0)",
+		"removed (0, 0)", "added (2, 0)",
+		"removed (1, 0)", "removed (2, 0)",
+	}
+
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+}
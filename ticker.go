@@ -0,0 +1,234 @@
+package spatial_hash
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TickStage is a stage of the pipeline a Ticker runs every tick, in order.
+type TickStage int
+
+const (
+	// StageSimulate starts the step with BeginStep, then runs the simulation moving nodes.
+	StageSimulate TickStage = iota
+	// StageMoves updates the nodes reported with NotifyMoved, with UpdateMoved.
+	StageMoves
+	// StageUpdate updates every node that moved, with UpdateAll, unless move notifications
+	// are enabled, in which case nodes are expected to be reported instead.
+	StageUpdate
+	// StageNotify runs the callbacks registered with OnUpdated, such as interest diffs sent to
+	// clients, once the spatial hash is up to date.
+	StageNotify
+	// StageQueries refreshes the scheduled queries due on the tick, with Scheduler.Tick.
+	StageQueries
+
+	tickStages = int(StageQueries) + 1
+)
+
+func (s TickStage) String() string {
+	switch s {
+	case StageSimulate:
+		return "simulate"
+	case StageMoves:
+		return "moves"
+	case StageUpdate:
+		return "update"
+	case StageNotify:
+		return "notify"
+	case StageQueries:
+		return "queries"
+	default:
+		return "unknown"
+	}
+}
+
+// TickStats are the metrics of one tick.
+type TickStats struct {
+	// Tick is the number of the tick, starting at 1.
+	Tick int
+
+	// Updated is the number of nodes updated by StageMoves and StageUpdate.
+	Updated int
+	// Refreshed is the number of scheduled queries refreshed by StageQueries.
+	Refreshed int
+
+	// Stages holds the time spent in each stage, indexed by TickStage.
+	Stages [tickStages]time.Duration
+	// Total is the time spent in the whole tick.
+	Total time.Duration
+
+	// Degradation reports what was skipped under SetDegradePolicy.
+	Degradation Degradation
+}
+
+// Ticker drives a spatial hash at a fixed timestep, running the stages of every tick in the
+// order queries need: the simulation moves nodes, the spatial hash is updated, listeners are
+// notified, then scheduled queries are refreshed, so that none of them sees the positions of
+// the previous tick.
+//
+// A Ticker must be driven by one goroutine at a time. Stats may be called from any goroutine.
+type Ticker[Id comparable, N Number] struct {
+	sh *SpatialHash[Id, N]
+
+	step     time.Duration
+	simulate func(dt time.Duration)
+
+	// scheduler is refreshed by StageQueries, or is nil.
+	scheduler *Scheduler[Id, N]
+	// notify holds the callbacks run by StageNotify.
+	notify []func(tick int)
+
+	// degrade reduces work under overload, or is nil if disabled.
+	degrade *degradation
+
+	// accumulated is the time Advance has been given and not yet spent on steps.
+	accumulated time.Duration
+
+	tick int
+
+	mu    sync.Mutex
+	stats TickStats
+}
+
+// NewTicker creates a ticker driving sh with steps of the given duration, calling simulate,
+// which may be nil, to move nodes at the start of every tick. It panics if step is not positive.
+func NewTicker[Id comparable, N Number](sh *SpatialHash[Id, N], step time.Duration, simulate func(dt time.Duration)) *Ticker[Id, N] {
+	if step <= 0 {
+		panic(fmt.Sprintf("spatial_hash: invalid ticker step %v", step))
+	}
+
+	return &Ticker[Id, N]{sh: sh, step: step, simulate: simulate}
+}
+
+// SetScheduler makes StageQueries refresh the queries of s, which must schedule queries on
+// the same spatial hash. A nil s disables the stage.
+//
+// SetScheduler must be called before the ticker is started.
+func (t *Ticker[Id, N]) SetScheduler(s *Scheduler[Id, N]) {
+	t.scheduler = s
+}
+
+// OnUpdated registers fn to be called by StageNotify with the number of the tick, after every
+// node has been updated and before scheduled queries are refreshed.
+//
+// OnUpdated must be called before the ticker is started.
+func (t *Ticker[Id, N]) OnUpdated(fn func(tick int)) {
+	t.notify = append(t.notify, fn)
+}
+
+// Step runs one tick and returns its metrics.
+func (t *Ticker[Id, N]) Step() TickStats {
+	t.tick++
+
+	stats := TickStats{Tick: t.tick}
+
+	dropped := t.sh.droppedEvents.Load()
+
+	if d := t.degrade; d != nil {
+		stats.Degradation.Level = d.level
+		t.sh.pausedBelow.Store(int32(d.pausedBelow()))
+	}
+
+	start := time.Now()
+	last := start
+
+	// lap records the time spent in stage since the previous lap.
+	lap := func(stage TickStage) {
+		now := time.Now()
+		stats.Stages[stage] = now.Sub(last)
+		last = now
+	}
+
+	t.sh.BeginStep()
+
+	if t.simulate != nil {
+		t.simulate(t.step)
+	}
+
+	lap(StageSimulate)
+
+	stats.Updated += t.sh.UpdateMoved()
+	lap(StageMoves)
+
+	if t.sh.tracker == nil {
+		stats.Updated += t.sh.UpdateAll()
+	}
+
+	lap(StageUpdate)
+
+	for _, fn := range t.notify {
+		fn(stats.Tick)
+	}
+
+	lap(StageNotify)
+
+	if t.scheduler != nil {
+		stats.Refreshed = t.refreshQueries(&stats.Degradation)
+	}
+
+	lap(StageQueries)
+
+	stats.Total = last.Sub(start)
+	stats.Degradation.DroppedEvents = int(t.sh.droppedEvents.Load() - dropped)
+
+	if t.degrade != nil {
+		t.degrade.adjust(stats.Total)
+	}
+
+	t.mu.Lock()
+	t.stats = stats
+	t.mu.Unlock()
+
+	return stats
+}
+
+// Advance adds elapsed wall-clock time, as measured by the caller's own loop, and runs as many
+// whole steps as it covers, returning how many were run. The time left over is kept for the
+// next call; Alpha tells how far into the next step it reaches.
+func (t *Ticker[Id, N]) Advance(elapsed time.Duration) int {
+	t.accumulated += elapsed
+
+	steps := 0
+
+	for t.accumulated >= t.step {
+		t.accumulated -= t.step
+
+		t.Step()
+		steps++
+	}
+
+	return steps
+}
+
+// Alpha returns the fraction of a step the time accumulated by Advance reaches past the last
+// tick, between 0 and 1, to render with SearchInterpolated.
+func (t *Ticker[Id, N]) Alpha() float64 {
+	return float64(t.accumulated) / float64(t.step)
+}
+
+// Run runs one tick every step of wall-clock time until ctx is done, and returns its error.
+// Ticks that fall behind are dropped rather than run in a burst.
+func (t *Ticker[Id, N]) Run(ctx context.Context) error {
+	clock := time.NewTicker(t.step)
+	defer clock.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-clock.C:
+			t.Step()
+		}
+	}
+}
+
+// Stats returns the metrics of the last tick, with a zero Tick if none has run yet.
+func (t *Ticker[Id, N]) Stats() TickStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stats
+}
@@ -0,0 +1,131 @@
+package spatial_hash
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTickerPipeline(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	observer := newPoint(0, 0, 0)
+	p := newPoint(1, 100, 0)
+
+	sh.Put(observer)
+	sh.Put(p)
+
+	sched := NewScheduler(sh, 1)
+	perception := sched.Register(observer, 20)
+
+	ticker := NewTicker(sh, 10*time.Millisecond, func(dt time.Duration) {
+		if dt != 10*time.Millisecond {
+			t.Errorf("Expected a step of 10ms, got %v", dt)
+		}
+
+		p.oldX = p.x
+		p.x = 5
+	})
+
+	ticker.SetScheduler(sched)
+
+	notified := 0
+
+	ticker.OnUpdated(func(tick int) {
+		notified = tick
+
+		// Listeners see the positions of this tick
+		if len(sh.Search(5, 0, 1)) != 1 {
+			t.Error("Expected the node at its new position when notified")
+		}
+	})
+
+	stats := ticker.Step()
+
+	if stats.Tick != 1 || notified != 1 {
+		t.Errorf("Expected tick 1 notified, got %d and %d", stats.Tick, notified)
+	}
+
+	if stats.Updated != 1 || stats.Refreshed != 1 {
+		t.Errorf("Expected 1 updated node and 1 refreshed query, got %d and %d", stats.Updated, stats.Refreshed)
+	}
+
+	// Scheduled queries are not a tick stale
+	if len(perception.Results()) != 1 {
+		t.Errorf("Expected 1 result, got %d", len(perception.Results()))
+	}
+
+	sum := time.Duration(0)
+
+	for _, d := range stats.Stages {
+		sum += d
+	}
+
+	if sum != stats.Total {
+		t.Errorf("Expected stages to add up to %v, got %v", stats.Total, sum)
+	}
+
+	if ticker.Stats() != stats {
+		t.Error("Expected the stats of the last tick")
+	}
+}
+
+func TestTickerMoveNotifications(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+	sh.SetMoveNotifications(true)
+
+	p, q := newPoint(0, 0, 0), newPoint(1, 0, 0)
+
+	sh.Put(p)
+	sh.Put(q)
+
+	ticker := NewTicker(sh, time.Millisecond, func(time.Duration) {
+		p.x, q.x = 50, 50
+
+		sh.NotifyMoved(p.id)
+	})
+
+	if stats := ticker.Step(); stats.Updated != 1 {
+		t.Errorf("Expected only the notified node to be updated, got %d", stats.Updated)
+	}
+}
+
+func TestTickerAdvance(t *testing.T) {
+	ticker := NewTicker(NewSpatialHash[int, float64](10), 10*time.Millisecond, nil)
+
+	if steps := ticker.Advance(25 * time.Millisecond); steps != 2 {
+		t.Errorf("Expected 2 steps, got %d", steps)
+	}
+
+	if alpha := ticker.Alpha(); alpha != 0.5 {
+		t.Errorf("Expected alpha 0.5, got %v", alpha)
+	}
+
+	if steps := ticker.Advance(5 * time.Millisecond); steps != 1 || ticker.Stats().Tick != 3 {
+		t.Errorf("Expected tick 3 after 1 more step, got %d after %d", ticker.Stats().Tick, steps)
+	}
+}
+
+func TestTickerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ticks := make(chan int, 1)
+
+	ticker := NewTicker(NewSpatialHash[int, float64](10), time.Millisecond, nil)
+	ticker.OnUpdated(func(tick int) {
+		if tick == 3 {
+			ticks <- tick
+		}
+	})
+
+	done := make(chan error)
+
+	go func() { done <- ticker.Run(ctx) }()
+
+	<-ticks
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,48 @@
+package spatial_hash
+
+// SearchTiers searches the nodes within the largest of radii once, and sorts them into one tier
+// per radius: each node goes into the first tier whose radius it lies within, so with ascending
+// radii, tier i holds the nodes between radii[i-1] and radii[i]. Bounded nodes are measured to
+// their bounding box. This replaces several concentric searches, such as melee, near and far
+// ranges, with a single scan.
+func (sh *SpatialHash[Id, N]) SearchTiers(x, y N, radii []N) []NodeSlice[Id, N] {
+	if len(radii) == 0 {
+		return nil
+	}
+
+	tiers := make([]NodeSlice[Id, N], len(radii))
+
+	radiiSq := make([]N, len(radii))
+
+	// Negative and NaN radii hold no nodes
+	maxRadius, valid := N(0), false
+
+	for i, r := range radii {
+		if r >= 0 {
+			radiiSq[i] = r * r
+			maxRadius, valid = max(maxRadius, r), true
+		}
+	}
+
+	if !valid {
+		return tiers
+	}
+
+	x, y = sh.Quantize(x), sh.Quantize(y)
+
+	sh.SearchFunc(x, y, maxRadius, func(n Node[Id, N]) bool {
+		distSq := sh.nodeDistanceSq(n, x, y)
+
+		for i, r := range radii {
+			if r >= 0 && distSq <= radiiSq[i] {
+				tiers[i] = append(tiers[i], n)
+
+				break
+			}
+		}
+
+		return true
+	})
+
+	return tiers
+}
@@ -0,0 +1,66 @@
+package spatial_hash
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSpatialHashSearchTiers(t *testing.T) {
+	nodes := CreateTestNodes(5000, 1000, 1000)
+
+	sh := NewSpatialHash[int, float64](50)
+
+	for _, n := range nodes {
+		sh.Put(n)
+	}
+
+	radii := []float64{10, 40, 120}
+
+	for range 200 {
+		x, y := 1000*rand.Float64(), 1000*rand.Float64()
+
+		tiers := sh.SearchTiers(x, y, radii)
+
+		if len(tiers) != len(radii) {
+			t.Fatalf("Expected %d tiers, got %d", len(radii), len(tiers))
+		}
+
+		total := 0
+
+		for i, tier := range tiers {
+			inner := 0.0
+			if i > 0 {
+				inner = radii[i-1]
+			}
+
+			for _, n := range tier {
+				d := math.Hypot(n.GetX()-x, n.GetY()-y)
+
+				if d > radii[i] || (i > 0 && d <= inner) {
+					t.Fatalf("Node at distance %v in tier %d", d, i)
+				}
+			}
+
+			total += len(tier)
+		}
+
+		if expected := len(NaiveSearch(nodes, x, y, radii[len(radii)-1])); total != expected {
+			t.Fatalf("Expected %d nodes across tiers, got %d", expected, total)
+		}
+	}
+
+	// Bounded nodes are measured to their bounding box
+	b := newBoxPoint(len(nodes), 2000, 2000, 30, 30)
+	sh.PutBounded(b)
+
+	tiers := sh.SearchTiers(2045, 2000, radii)
+
+	if len(tiers[0]) != 0 || len(tiers[1]) != 1 {
+		t.Errorf("Expected the box in the second tier, got tiers of %d and %d nodes", len(tiers[0]), len(tiers[1]))
+	}
+
+	if tiers := sh.SearchTiers(500, 500, []float64{-1, math.NaN()}); len(tiers[0]) != 0 || len(tiers[1]) != 0 {
+		t.Error("Expected invalid radii to hold no nodes")
+	}
+}
@@ -0,0 +1,79 @@
+package spatial_hash
+
+// TileNode is a node positioned on integer tile coordinates that a TileGrid can move.
+type TileNode[Id comparable] interface {
+	Node[Id, int]
+
+	// SetPos sets the current tile coordinates of the node.
+	SetPos(x, y int)
+}
+
+// TileGrid is a spatial hash for tile-based games, where every position is an integer
+// tile coordinate and every cell is exactly one tile.
+type TileGrid[Id comparable] struct {
+	sh *SpatialHash[Id, int]
+}
+
+// NewTileGrid creates an empty tile grid.
+func NewTileGrid[Id comparable]() *TileGrid[Id] {
+	return &TileGrid[Id]{sh: NewSpatialHash[Id, int](1)}
+}
+
+// Hash returns the underlying spatial hash, for queries the tile grid does not wrap.
+func (g *TileGrid[Id]) Hash() *SpatialHash[Id, int] {
+	return g.sh
+}
+
+// Put adds a node to the grid, on the tile it is positioned at.
+func (g *TileGrid[Id]) Put(n TileNode[Id]) {
+	g.sh.Put(n)
+}
+
+// Remove removes a node from the grid.
+func (g *TileGrid[Id]) Remove(n TileNode[Id]) {
+	g.sh.Remove(n)
+}
+
+// MoveToTile moves a node to tile (tx, ty) and updates its placement in the grid.
+func (g *TileGrid[Id]) MoveToTile(n TileNode[Id], tx, ty int) {
+	n.SetOldPos(n.GetX(), n.GetY())
+	n.SetPos(tx, ty)
+
+	g.sh.Update(n)
+}
+
+// GetAtTile returns the nodes standing on tile (tx, ty).
+func (g *TileGrid[Id]) GetAtTile(tx, ty int) NodeSlice[Id, int] {
+	return g.sh.AtPosition(tx, ty)
+}
+
+// NeighborsOfTile returns the nodes standing on the eight tiles surrounding tile (tx, ty),
+// excluding the tile itself.
+func (g *TileGrid[Id]) NeighborsOfTile(tx, ty int) NodeSlice[Id, int] {
+	var nodes NodeSlice[Id, int]
+
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			nodes = append(nodes, g.sh.AtPosition(tx+dx, ty+dy)...)
+		}
+	}
+
+	return nodes
+}
+
+// InTileRect returns the nodes standing on tiles from (minX, minY) to (maxX, maxY) inclusive.
+func (g *TileGrid[Id]) InTileRect(minX, minY, maxX, maxY int) NodeSlice[Id, int] {
+	var nodes NodeSlice[Id, int]
+
+	for ty := minY; ty <= maxY; ty++ {
+		for tx := minX; tx <= maxX; tx++ {
+			nodes = append(nodes, g.sh.AtPosition(tx, ty)...)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,52 @@
+package spatial_hash
+
+import "testing"
+
+// TilePoint is a basic implementation of the TileNode interface for testing.
+type TilePoint struct {
+	IntPoint
+}
+
+var _ TileNode[int] = (*TilePoint)(nil) // *TilePoint must implement TileNode
+
+func (n *TilePoint) SetPos(x, y int) { n.x, n.y = x, y }
+
+func TestTileGrid(t *testing.T) {
+	g := NewTileGrid[int]()
+
+	hero := &TilePoint{IntPoint{id: 1, x: 3, y: 4}}
+	goblin := &TilePoint{IntPoint{id: 2, x: 4, y: 4}}
+	chest := &TilePoint{IntPoint{id: 3, x: -1, y: -1}}
+
+	g.Put(hero)
+	g.Put(goblin)
+	g.Put(chest)
+
+	if result := g.GetAtTile(3, 4); len(result) != 1 || result[0].GetId() != 1 {
+		t.Errorf("Expected hero on (3, 4), got %v", result)
+	}
+
+	if result := g.NeighborsOfTile(3, 4); len(result) != 1 || result[0].GetId() != 2 {
+		t.Errorf("Expected only the goblin next to (3, 4), got %v", result)
+	}
+
+	g.MoveToTile(hero, 0, 0)
+
+	if result := g.GetAtTile(3, 4); len(result) != 0 {
+		t.Errorf("Expected nothing on (3, 4) after move, got %v", result)
+	}
+
+	if result := g.NeighborsOfTile(0, 0); len(result) != 1 || result[0].GetId() != 3 {
+		t.Errorf("Expected only the chest next to (0, 0), got %v", result)
+	}
+
+	if result := g.InTileRect(-1, -1, 4, 4); len(result) != 3 {
+		t.Errorf("Expected 3 nodes in rect, got %d", len(result))
+	}
+
+	g.Remove(goblin)
+
+	if result := g.GetAtTile(4, 4); len(result) != 0 {
+		t.Errorf("Expected nothing on (4, 4) after remove, got %v", result)
+	}
+}
@@ -0,0 +1,60 @@
+package spatial_hash
+
+// VisibilityGraph is a graph of nodes connected by clear lines of sight.
+type VisibilityGraph[Id comparable, N Number] struct {
+	// Nodes are the vertices of the graph.
+	Nodes NodeSlice[Id, N]
+
+	// Adjacent holds, for each node, the indices in Nodes of the nodes visible from it.
+	Adjacent [][]int
+}
+
+// VisibilityGraph extracts the nodes inside rect for which isWaypoint returns true, and connects
+// every pair with a clear LineOfSight between them, returning the adjacency list. Pairs further
+// apart than maxDistance are not connected, unless maxDistance is zero.
+//
+// This builds navigation graphs directly from the waypoints already in the spatial hash,
+// with blocked cells as walls.
+//
+// isWaypoint must not call methods of the spatial hash.
+func (sh *SpatialHash[Id, N]) VisibilityGraph(rect Rect[N], isWaypoint func(n Node[Id, N]) bool, maxDistance N) *VisibilityGraph[Id, N] {
+	g := &VisibilityGraph[Id, N]{}
+
+	t := sh.drainMu.RLock()
+
+	cells := sh.rectCells(rect)
+
+	sh.forEachInCells(cells.MinX, cells.MinY, cells.MaxX, cells.MaxY, func(n Node[Id, N]) bool {
+		if rect.Contains(n.GetX(), n.GetY()) && isWaypoint(n) {
+			g.Nodes = append(g.Nodes, n)
+		}
+
+		return true
+	})
+
+	sh.drainMu.RUnlock(t)
+
+	g.Adjacent = make([][]int, len(g.Nodes))
+
+	maxDistanceSq := maxDistance * maxDistance
+
+	for i, a := range g.Nodes {
+		ax, ay := a.GetX(), a.GetY()
+
+		for j := i + 1; j < len(g.Nodes); j++ {
+			b := g.Nodes[j]
+			bx, by := b.GetX(), b.GetY()
+
+			if maxDistance != 0 && distanceSq(bx-ax, by-ay) > maxDistanceSq {
+				continue
+			}
+
+			if sh.LineOfSight(ax, ay, bx, by) {
+				g.Adjacent[i] = append(g.Adjacent[i], j)
+				g.Adjacent[j] = append(g.Adjacent[j], i)
+			}
+		}
+	}
+
+	return g
+}
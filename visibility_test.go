@@ -0,0 +1,90 @@
+package spatial_hash
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSpatialHashTraverseCells(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	var visited [][2]int
+
+	sh.TraverseCells(5, 5, 35, 15, func(cx, cy int) bool {
+		visited = append(visited, [2]int{cx, cy})
+
+		return true
+	})
+
+	expected := [][2]int{{0, 0}, {1, 0}, {1, 1}, {2, 1}, {3, 1}}
+
+	if !slices.Equal(visited, expected) {
+		t.Errorf("Expected cells %v, got %v", expected, visited)
+	}
+
+	visited = visited[:0]
+
+	sh.TraverseCells(-5, -5, -25, -5, func(cx, cy int) bool {
+		visited = append(visited, [2]int{cx, cy})
+
+		return true
+	})
+
+	expected = [][2]int{{-1, -1}, {-2, -1}, {-3, -1}}
+
+	if !slices.Equal(visited, expected) {
+		t.Errorf("Expected cells %v, got %v", expected, visited)
+	}
+}
+
+func TestSpatialHashVisibilityGraph(t *testing.T) {
+	sh := NewSpatialHash[int, float64](10)
+
+	// Wall between x=50 and x=60, from y=0 to y=50
+	for cy := range 5 {
+		sh.SetCellBlocked(5, cy, true)
+	}
+
+	sh.Put(newPoint(0, 25, 25))  // Left of the wall
+	sh.Put(newPoint(1, 85, 25))  // Right of the wall
+	sh.Put(newPoint(2, 55, 75))  // Above the wall, sees both
+	sh.Put(newPoint(3, 25, 35))  // Not a waypoint
+	sh.Put(newPoint(4, 500, 25)) // Outside the region
+
+	g := sh.VisibilityGraph(Rect[float64]{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100}, func(n TestingNode) bool {
+		return n.GetId() != 3
+	}, 0)
+
+	if len(g.Nodes) != 3 {
+		t.Fatalf("Expected 3 waypoints, got %d", len(g.Nodes))
+	}
+
+	edges := make(map[[2]int]bool)
+
+	for i, adjacent := range g.Adjacent {
+		for _, j := range adjacent {
+			edges[[2]int{g.Nodes[i].GetId(), g.Nodes[j].GetId()}] = true
+		}
+	}
+
+	if edges[[2]int{0, 1}] || edges[[2]int{1, 0}] {
+		t.Error("Expected the wall to block waypoints 0 and 1")
+	}
+
+	for _, edge := range [][2]int{{0, 2}, {2, 0}, {1, 2}, {2, 1}} {
+		if !edges[edge] {
+			t.Errorf("Expected edge %v", edge)
+		}
+	}
+
+	// Waypoints 0 and 2 are about 58 apart
+	g = sh.VisibilityGraph(Rect[float64]{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100}, func(n TestingNode) bool {
+		return n.GetId() != 3
+	}, 50)
+
+	for i := range g.Adjacent {
+		if len(g.Adjacent[i]) != 0 {
+			t.Errorf("Expected no edges within distance 50, got %v for node %d", g.Adjacent[i], g.Nodes[i].GetId())
+		}
+	}
+}